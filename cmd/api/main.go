@@ -20,6 +20,6 @@ func main() {
 	}
 	logger.Info("Loading cfg", "redisURI", cfg.Redis.URI)
 
-	server, producer, consumer, tracerShutdown := app.SetupHttpServer(cfg, logger)
-	app.GracefulShutdown(server, producer, consumer, logger, tracerShutdown)
+	server, producer, scheduler, consumer, supervisor, tracerShutdown := app.SetupHttpServer(cfg, logger)
+	app.GracefulShutdown(server, producer, scheduler, consumer, supervisor, logger, tracerShutdown)
 }
@@ -20,6 +20,6 @@ func main() {
 	}
 	logger.Info("Loading cfg", "redisURI", cfg.Redis.URI)
 
-	workers, tracerShutdown := app.SetupWorkers(cfg, logger)
-	app.StartWorkers(logger, cfg, workers, tracerShutdown)
+	workers, cancelListener, tracerShutdown, supervisor, pool, monitor := app.SetupWorkers(cfg, logger)
+	app.StartWorkers(logger, cfg, workers, cancelListener, tracerShutdown, supervisor, pool, monitor)
 }
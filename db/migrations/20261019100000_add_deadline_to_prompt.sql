@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE prompts ADD COLUMN IF NOT EXISTS deadline TIMESTAMPTZ;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE prompts DROP COLUMN IF EXISTS deadline;
+-- +goose StatementEnd
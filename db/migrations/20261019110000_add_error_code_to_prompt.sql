@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE prompts ADD COLUMN IF NOT EXISTS error_code VARCHAR(32) NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE prompts DROP COLUMN IF EXISTS error_code;
+-- +goose StatementEnd
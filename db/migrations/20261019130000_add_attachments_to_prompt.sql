@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE prompts ADD COLUMN IF NOT EXISTS attachments JSONB NOT NULL DEFAULT '[]';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE prompts DROP COLUMN IF EXISTS attachments;
+-- +goose StatementEnd
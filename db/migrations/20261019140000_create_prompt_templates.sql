@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE prompt_templates
+(
+    id         UUID NOT NULL,
+    version    INT NOT NULL,
+    name       VARCHAR(255) NOT NULL,
+    body       TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    PRIMARY KEY (id, version)
+);
+
+ALTER TABLE prompts ADD COLUMN IF NOT EXISTS template_id UUID;
+ALTER TABLE prompts ADD COLUMN IF NOT EXISTS template_version INT;
+-- +goose StatementEnd
+
+CREATE INDEX idx_prompts_template ON prompts (template_id, template_version);
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_prompts_template;
+ALTER TABLE prompts DROP COLUMN IF EXISTS template_version;
+ALTER TABLE prompts DROP COLUMN IF EXISTS template_id;
+DROP TABLE prompt_templates;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE batches
+(
+    id         UUID PRIMARY KEY,
+    user_id    UUID NOT NULL,
+    status     VARCHAR(25) NOT NULL,
+    total      INT NOT NULL,
+    completed  INT NOT NULL DEFAULT 0,
+    failed     INT NOT NULL DEFAULT 0,
+    discarded  INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE prompts ADD COLUMN IF NOT EXISTS batch_id UUID REFERENCES batches (id);
+ALTER TABLE prompts ADD COLUMN IF NOT EXISTS batch_index INT;
+-- +goose StatementEnd
+
+CREATE INDEX idx_prompts_batch ON prompts (batch_id, batch_index) WHERE batch_id IS NOT NULL;
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_prompts_batch;
+ALTER TABLE prompts DROP COLUMN IF EXISTS batch_index;
+ALTER TABLE prompts DROP COLUMN IF EXISTS batch_id;
+DROP TABLE batches;
+-- +goose StatementEnd
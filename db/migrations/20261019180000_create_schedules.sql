@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE schedules
+(
+    id               UUID PRIMARY KEY,
+    user_id          UUID NOT NULL,
+    cron             VARCHAR(255) NOT NULL,
+    model_id         VARCHAR(255) NOT NULL,
+    text             TEXT NOT NULL DEFAULT '',
+    options          JSONB NOT NULL DEFAULT '{}',
+    priority         VARCHAR(25) NOT NULL DEFAULT 'normal',
+    timeout_ms       BIGINT NOT NULL DEFAULT 0,
+    template_id      UUID,
+    template_version INT,
+    variables        JSONB,
+    next_run_at      TIMESTAMPTZ NOT NULL,
+    last_run_at      TIMESTAMPTZ,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at       TIMESTAMPTZ
+);
+
+ALTER TABLE prompts ADD COLUMN IF NOT EXISTS run_at TIMESTAMPTZ;
+ALTER TABLE prompts ADD COLUMN IF NOT EXISTS schedule_id UUID REFERENCES schedules (id);
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS available_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+-- +goose StatementEnd
+
+CREATE INDEX idx_schedules_next_run_at ON schedules (next_run_at) WHERE deleted_at IS NULL;
+CREATE INDEX idx_schedules_user_id ON schedules (user_id) WHERE deleted_at IS NULL;
+CREATE INDEX idx_outbox_status_available_at ON outbox (status, available_at) WHERE status = 'pending';
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_outbox_status_available_at;
+DROP INDEX IF EXISTS idx_schedules_user_id;
+DROP INDEX IF EXISTS idx_schedules_next_run_at;
+ALTER TABLE outbox DROP COLUMN IF EXISTS available_at;
+ALTER TABLE prompts DROP COLUMN IF EXISTS schedule_id;
+ALTER TABLE prompts DROP COLUMN IF EXISTS run_at;
+DROP TABLE schedules;
+-- +goose StatementEnd
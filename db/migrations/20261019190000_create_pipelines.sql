@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE pipelines
+(
+    id         UUID PRIMARY KEY,
+    user_id    UUID NOT NULL,
+    status     VARCHAR(25) NOT NULL,
+    priority   VARCHAR(25) NOT NULL DEFAULT 'normal',
+    variables  JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE pipeline_steps
+(
+    pipeline_id      UUID NOT NULL REFERENCES pipelines (id),
+    name             VARCHAR(255) NOT NULL,
+    position         INT NOT NULL,
+    model_id         VARCHAR(255) NOT NULL,
+    body             TEXT NOT NULL DEFAULT '',
+    template_id      UUID,
+    template_version INT,
+    options          JSONB NOT NULL DEFAULT '{}',
+    depends_on       JSONB NOT NULL DEFAULT '[]',
+    status           VARCHAR(25) NOT NULL,
+    prompt_id        UUID,
+    error            TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (pipeline_id, name)
+);
+
+ALTER TABLE prompts ADD COLUMN IF NOT EXISTS pipeline_id UUID REFERENCES pipelines (id);
+ALTER TABLE prompts ADD COLUMN IF NOT EXISTS pipeline_step VARCHAR(255);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE prompts DROP COLUMN IF EXISTS pipeline_step;
+ALTER TABLE prompts DROP COLUMN IF EXISTS pipeline_id;
+DROP TABLE pipeline_steps;
+DROP TABLE pipelines;
+-- +goose StatementEnd
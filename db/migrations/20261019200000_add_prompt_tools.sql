@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE prompts ADD COLUMN IF NOT EXISTS tools JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE prompts ADD COLUMN IF NOT EXISTS tool_calls JSONB NOT NULL DEFAULT '[]';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE prompts DROP COLUMN IF EXISTS tool_calls;
+ALTER TABLE prompts DROP COLUMN IF EXISTS tools;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE batches DROP COLUMN IF EXISTS status;
+ALTER TABLE batches DROP COLUMN IF EXISTS completed;
+ALTER TABLE batches DROP COLUMN IF EXISTS failed;
+ALTER TABLE batches DROP COLUMN IF EXISTS discarded;
+ALTER TABLE batches DROP COLUMN IF EXISTS updated_at;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE batches ADD COLUMN IF NOT EXISTS status VARCHAR(25) NOT NULL DEFAULT 'Processing';
+ALTER TABLE batches ADD COLUMN IF NOT EXISTS completed INT NOT NULL DEFAULT 0;
+ALTER TABLE batches ADD COLUMN IF NOT EXISTS failed INT NOT NULL DEFAULT 0;
+ALTER TABLE batches ADD COLUMN IF NOT EXISTS discarded INT NOT NULL DEFAULT 0;
+ALTER TABLE batches ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Templates created before their owner was recorded can no longer be updated or deleted.
+ALTER TABLE prompt_templates ADD COLUMN IF NOT EXISTS user_id UUID;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE prompt_templates DROP COLUMN IF EXISTS user_id;
+-- +goose StatementEnd
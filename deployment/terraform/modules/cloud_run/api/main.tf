@@ -31,6 +31,7 @@ resource "google_secret_manager_secret_version" "app_config" {
     redis_consumer_group    = "ai_tasks_group"
     worker_id               = "worker"
     otel_collector_uri      = "otel-collector:4318"
+    blob_bucket             = var.blob_bucket
   })
 }
 
@@ -78,6 +79,23 @@ resource "google_cloud_run_v2_service" "backend" {
         }
       }
 
+      startup_probe {
+        http_get {
+          path = "/readyz"
+        }
+        period_seconds    = 5
+        timeout_seconds   = 3
+        failure_threshold = 12
+      }
+      liveness_probe {
+        http_get {
+          path = "/livez"
+        }
+        period_seconds    = 30
+        timeout_seconds   = 3
+        failure_threshold = 3
+      }
+
       env {
         name = "POSTGRES_PASSWORD"
         value_source {
@@ -104,3 +104,11 @@ variable "vpc_connector_name" {
   description = "The connector name of the internal network used for 'app -> redis' secure connection"
   type        = string
 }
+
+
+# ===== BLOB =====
+
+variable "blob_bucket" {
+  description = "The Cloud Storage bucket the API and the worker share the attachments through"
+  type        = string
+}
@@ -0,0 +1,36 @@
+resource "google_project_service" "storage_api" {
+  service            = "storage.googleapis.com"
+  disable_on_destroy = false
+}
+
+# Attachments are written by the API and read by the workers, the two services do not share a filesystem.
+resource "google_storage_bucket" "blobs" {
+  name                        = "${var.project_id}-${var.bucket_name}"
+  location                    = var.region
+  uniform_bucket_level_access = true
+  public_access_prevention    = "enforced"
+  force_destroy               = false
+
+  lifecycle_rule {
+    condition {
+      age = var.retention_days
+    }
+    action {
+      type = "Delete"
+    }
+  }
+
+  depends_on = [google_project_service.storage_api]
+}
+
+resource "google_storage_bucket_iam_member" "api_writer" {
+  bucket = google_storage_bucket.blobs.name
+  role   = "roles/storage.objectUser"
+  member = "serviceAccount:${var.api_service_account_email}"
+}
+
+resource "google_storage_bucket_iam_member" "worker_reader" {
+  bucket = google_storage_bucket.blobs.name
+  role   = "roles/storage.objectViewer"
+  member = "serviceAccount:${var.worker_service_account_email}"
+}
@@ -0,0 +1,4 @@
+output "blob_bucket" {
+  description = "The bucket the API and the worker share the attachments through"
+  value       = google_storage_bucket.blobs.name
+}
@@ -0,0 +1,31 @@
+variable "project_id" {
+  description = "The ID of the GCP project"
+  type        = string
+}
+
+variable "region" {
+  description = "The region for the GCP resources"
+  type        = string
+}
+
+variable "bucket_name" {
+  description = "The name of the bucket keeping the prompt attachments, prefixed with the project ID"
+  type        = string
+  default     = "blobs"
+}
+
+variable "retention_days" {
+  description = "The number of days the attachments are kept"
+  type        = number
+  default     = 30
+}
+
+variable "api_service_account_email" {
+  description = "The service account of the API, which writes the attachments"
+  type        = string
+}
+
+variable "worker_service_account_email" {
+  description = "The service account of the worker, which reads the attachments"
+  type        = string
+}
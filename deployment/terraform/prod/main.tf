@@ -56,6 +56,15 @@ module "secrets" {
   depends_on = [module.iam_api, module.iam_worker, module.memory_store]
 }
 
+module "storage" {
+  source                       = "../modules/storage"
+  project_id                   = var.project_id
+  region                       = var.region
+  api_service_account_email    = module.iam_api.cloud_run_service_account_email
+  worker_service_account_email = module.iam_worker.cloud_run_service_account_email
+
+  depends_on = [module.iam_api, module.iam_worker]
+}
 
 module "api" {
   source                = "../modules/cloud_run/api"
@@ -78,6 +87,8 @@ module "api" {
 
   vpc_connector_name = module.vpc.vpc_connector_name
 
+  blob_bucket = module.storage.blob_bucket
+
   otel_resource_secret_id = module.secrets.otel_resource
   otel_endpoint_secret_id = module.secrets.otel_endpoint
   otel_headers_secret_id = module.secrets.otel_headers
@@ -85,7 +96,8 @@ module "api" {
   depends_on = [
     module.cloud_sql,
     module.memory_store,
-    module.secrets
+    module.secrets,
+    module.storage
   ]
 }
 
@@ -107,6 +119,8 @@ module "worker" {
 
   gemini_api_key_secret_id = module.secrets.gemini_api_key_secret_id
 
+  blob_bucket = module.storage.blob_bucket
+
   otel_resource_secret_id = module.secrets.otel_resource
   otel_endpoint_secret_id = module.secrets.otel_endpoint
   otel_headers_secret_id = module.secrets.otel_headers
@@ -114,6 +128,7 @@ module "worker" {
   depends_on = [
     module.cloud_sql,
     module.memory_store,
-    module.secrets
+    module.secrets,
+    module.storage
   ]
 }
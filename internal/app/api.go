@@ -5,18 +5,31 @@ import (
 	"ai-orchestrator/internal/config/api"
 	"ai-orchestrator/internal/config/connector"
 	"ai-orchestrator/internal/config/setup"
+	"ai-orchestrator/internal/domain/model"
 	"ai-orchestrator/internal/infra/broker"
+	"ai-orchestrator/internal/infra/cache"
 	"ai-orchestrator/internal/infra/manager"
 	"ai-orchestrator/internal/infra/persistence"
+	batchRepo "ai-orchestrator/internal/infra/persistence/repository/batch"
 	outboxRepo "ai-orchestrator/internal/infra/persistence/repository/outbox"
+	pipelineRepo "ai-orchestrator/internal/infra/persistence/repository/pipeline"
 	promptRepo "ai-orchestrator/internal/infra/persistence/repository/prompt"
+	scheduleRepo "ai-orchestrator/internal/infra/persistence/repository/schedule"
+	templateRepo "ai-orchestrator/internal/infra/persistence/repository/template"
+	"ai-orchestrator/internal/infra/telemetry/health"
 	"ai-orchestrator/internal/infra/telemetry/tracing"
 	"ai-orchestrator/internal/infra/websocket"
+	catalogueHandler "ai-orchestrator/internal/transport/http/handler/catalogue"
 	promptHandler "ai-orchestrator/internal/transport/http/handler/prompt"
+	templateHandler "ai-orchestrator/internal/transport/http/handler/template"
 	"ai-orchestrator/internal/transport/http/helper"
 	"ai-orchestrator/internal/transport/middleware"
+	socketHandler "ai-orchestrator/internal/transport/socket/handler/prompt"
 	"ai-orchestrator/internal/transport/stream"
+	pipelineUsecase "ai-orchestrator/internal/use_case/pipeline"
 	savePromptUsecase "ai-orchestrator/internal/use_case/prompt"
+	scheduleUsecase "ai-orchestrator/internal/use_case/schedule"
+	templateUsecase "ai-orchestrator/internal/use_case/template"
 	"context"
 	"errors"
 	"github.com/gorilla/mux"
@@ -29,7 +42,7 @@ import (
 	"time"
 )
 
-func SetupHttpServer(cfg *api.Config, l *slog.Logger) (*http.Server, *manager.Relay, *stream.Consumer, func(context.Context) error) {
+func SetupHttpServer(cfg *api.Config, l *slog.Logger) (*http.Server, *manager.Relay, *manager.Scheduler, *stream.Consumer, *manager.Supervisor, func(context.Context) error) {
 	ctx := context.Background()
 
 	redisClient, err := connector.ConnectToRedis(cfg.App.Environment, cfg.Redis.URI)
@@ -63,25 +76,31 @@ func SetupHttpServer(cfg *api.Config, l *slog.Logger) (*http.Server, *manager.Re
 		os.Exit(1)
 	}
 
-	producer, err := broker.NewProducer(l, redisClient, &cfg.Redis.PubStream)
+	// Every priority class has its own stream, so bulk prompts do not delay the interactive ones.
+	producers := make(map[string]manager.Producer, len(model.Priorities))
+	for _, priority := range model.Priorities {
+		producer, err := broker.NewProducer(l, redisClient, broker.LaneConfig(cfg.Redis.PubStream, priority))
+		if err != nil {
+			l.Error("Failed to initiate producer.", "error", err, "priority", priority)
+			os.Exit(1)
+		}
+		producers[string(priority)] = producer
+	}
+	backoffManager, err := manager.NewBackoff(l, &cfg.App.Backoff)
 	if err != nil {
-		l.Error("Failed to initiate producer.", "error", err)
+		l.Error("Failed to initiate backoffManager.", "error", err)
 		os.Exit(1)
 	}
-	relay, err := manager.NewRelayService(l, transactor, outbox, producer, &cfg.App.Backoff)
+
+	relay, err := manager.NewRelayService(l, transactor, outbox, producers, backoffManager)
 	if err != nil {
 		l.Error("Failed to initiate relay.", "error", err)
 		os.Exit(1)
 	}
 
-	upgrader := &wslib.Upgrader{
-		CheckOrigin: func(r *http.Request) bool { return true }, // Restrict in production!
-	}
-
-	hub := websocket.NewHub()
-	socket, err := websocket.NewManager(l, upgrader, hub)
+	supervisor, err := manager.NewSupervisor(l, backoffManager)
 	if err != nil {
-		l.Error("Failed to initiate websocket.", "error", err)
+		l.Error("Failed to initiate supervisor.", "error", err)
 		os.Exit(1)
 	}
 
@@ -91,54 +110,224 @@ func SetupHttpServer(cfg *api.Config, l *slog.Logger) (*http.Server, *manager.Re
 		os.Exit(1)
 	}
 
-	savePrompt, err := savePromptUsecase.NewSavePromptUsecase(l, pr, transactor, outbox)
+	catalogue, err := setup.NewCatalogue(cfg.Models)
+	if err != nil {
+		l.Error("Failed to initiate model catalogue.", "error", err)
+		os.Exit(1)
+	}
+
+	timeoutPolicy, err := savePromptUsecase.NewTimeoutPolicy(&cfg.App.Timeout)
+	if err != nil {
+		l.Error("Failed to initiate timeout policy.", "error", err)
+		os.Exit(1)
+	}
+
+	generationPolicy, err := savePromptUsecase.NewGenerationPolicy(&cfg.App.Generation)
+	if err != nil {
+		l.Error("Failed to initiate generation policy.", "error", err)
+		os.Exit(1)
+	}
+
+	blobs, err := setup.NewBlobStore(ctx, l, cfg.App.Blob)
+	if err != nil {
+		l.Error("Failed to initiate blob store.", "error", err)
+		os.Exit(1)
+	}
+
+	tr, err := templateRepo.NewRepository(l, postgresClient)
+	if err != nil {
+		l.Error("Failed to initiate template repository.", "error", err)
+		os.Exit(1)
+	}
+
+	templates, err := templateUsecase.NewTemplateUsecase(l, tr)
+	if err != nil {
+		l.Error("Failed to initiate template usecase.", "error", err)
+		os.Exit(1)
+	}
+
+	cacheService, err := cache.NewService(l, redisClient)
+	if err != nil {
+		l.Error("Failed to initiate cache service.", "error", err)
+		os.Exit(1)
+	}
+
+	responseCache, err := savePromptUsecase.NewResponseCache(l, cacheService, catalogue, &cfg.Redis.Cache)
+	if err != nil {
+		l.Error("Failed to initiate response cache.", "error", err)
+		os.Exit(1)
+	}
+
+	// Cached results are published straight to the results stream, bypassing the workers.
+	resultProducer, err := broker.NewProducer(l, redisClient, &cfg.Redis.SubStream)
+	if err != nil {
+		l.Error("Failed to initiate result producer.", "error", err)
+		os.Exit(1)
+	}
+
+	savePrompt, err := savePromptUsecase.NewSavePromptUsecase(l, pr, transactor, outbox, catalogue, timeoutPolicy, generationPolicy, blobs, templates, responseCache, resultProducer, cfg.App.Validation.MaxPromptLength)
 	if err != nil {
 		l.Error("Failed to initiate save prompt usecase.", "error", err)
 		os.Exit(1)
 	}
 
-	ph, err := promptHandler.NewHandler(l, savePrompt)
+	cancelSignal, err := broker.NewCancelSignal(l, redisClient, &cfg.Redis.Cancellation)
+	if err != nil {
+		l.Error("Failed to initiate cancel signal.", "error", err)
+		os.Exit(1)
+	}
+
+	sr, err := scheduleRepo.NewRepository(l, postgresClient)
+	if err != nil {
+		l.Error("Failed to initiate schedule repository.", "error", err)
+		os.Exit(1)
+	}
+
+	schedules, err := scheduleUsecase.NewScheduleUsecase(l, sr, savePrompt)
+	if err != nil {
+		l.Error("Failed to initiate schedule usecase.", "error", err)
+		os.Exit(1)
+	}
+
+	scheduler, err := manager.NewScheduler(l, schedules, &cfg.App.Scheduler)
+	if err != nil {
+		l.Error("Failed to initiate scheduler.", "error", err)
+		os.Exit(1)
+	}
+
+	br, err := batchRepo.NewRepository(l, postgresClient)
+	if err != nil {
+		l.Error("Failed to initiate batch repository.", "error", err)
+		os.Exit(1)
+	}
+
+	saveBatch, err := savePromptUsecase.NewSaveBatchUsecase(l, savePrompt, pr, br, transactor, outbox)
+	if err != nil {
+		l.Error("Failed to initiate save batch usecase.", "error", err)
+		os.Exit(1)
+	}
+
+	plr, err := pipelineRepo.NewRepository(l, postgresClient)
+	if err != nil {
+		l.Error("Failed to initiate pipeline repository.", "error", err)
+		os.Exit(1)
+	}
+
+	pipelines, err := pipelineUsecase.NewPipelineUsecase(l, plr, savePrompt)
+	if err != nil {
+		l.Error("Failed to initiate pipeline usecase.", "error", err)
+		os.Exit(1)
+	}
+
+	cancelPrompt, err := savePromptUsecase.NewCancelPromptUsecase(l, pr, cancelSignal, outbox, pipelines)
+	if err != nil {
+		l.Error("Failed to initiate cancel prompt usecase.", "error", err)
+		os.Exit(1)
+	}
+
+	validator, err := promptHandler.NewValidator(&cfg.App.Validation, catalogue)
+	if err != nil {
+		l.Error("Failed to initiate request validator.", "error", err)
+		os.Exit(1)
+	}
+
+	ph, err := promptHandler.NewHandler(l, savePrompt, cancelPrompt, schedules, validator)
 	if err != nil {
 		l.Error("Failed to initiate prompt handler.", "error", err)
 		os.Exit(1)
 	}
 
-	saveResponse, err := savePromptUsecase.NewSaveResponse(l, socket, pr)
+	bh, err := promptHandler.NewBatchHandler(l, saveBatch, validator, &cfg.App.Validation.Batch)
 	if err != nil {
-		l.Error("Failed to initiate save response.", "error", err)
+		l.Error("Failed to initiate batch handler.", "error", err)
 		os.Exit(1)
 	}
 
-	tracePropagator := &tracing.PropagationConfig{
-		AppID:     cfg.App.ID,
-		ProcessID: "save_response",
+	plh, err := promptHandler.NewPipelineHandler(l, pipelines, validator, &cfg.App.Validation.Pipeline)
+	if err != nil {
+		l.Error("Failed to initiate pipeline handler.", "error", err)
+		os.Exit(1)
 	}
-	backoffManager, err := manager.NewBackoff(l, &cfg.App.Backoff)
+
+	sch, err := promptHandler.NewScheduleHandler(l, schedules)
 	if err != nil {
-		l.Error("Failed to initiate backoffManager.", "error", err)
+		l.Error("Failed to initiate schedule handler.", "error", err)
+		os.Exit(1)
+	}
+
+	ch, err := catalogueHandler.NewHandler(l, catalogue)
+	if err != nil {
+		l.Error("Failed to initiate catalogue handler.", "error", err)
+		os.Exit(1)
+	}
+
+	th, err := templateHandler.NewHandler(l, templates)
+	if err != nil {
+		l.Error("Failed to initiate template handler.", "error", err)
+		os.Exit(1)
+	}
+
+	getPrompt, err := savePromptUsecase.NewGetPromptUsecase(l, pr)
+	if err != nil {
+		l.Error("Failed to initiate get prompt usecase.", "error", err)
+		os.Exit(1)
+	}
+
+	sh, err := socketHandler.NewHandler(l, savePrompt, getPrompt, cancelPrompt, validator)
+	if err != nil {
+		l.Error("Failed to initiate socket prompt handler.", "error", err)
+		os.Exit(1)
+	}
+
+	upgrader := &wslib.Upgrader{
+		CheckOrigin: func(r *http.Request) bool { return true }, // Restrict in production!
+	}
+
+	hub := websocket.NewHub()
+	socket, err := websocket.NewManager(l, upgrader, hub, sh)
+	if err != nil {
+		l.Error("Failed to initiate websocket.", "error", err)
 		os.Exit(1)
 	}
 
-	consumer, err := stream.NewConsumer(0, l, redisClient, saveResponse, &cfg.Redis.SubStream, &cfg.App.Backoff, tracePropagator, *backoffManager)
+	saveResponse, err := savePromptUsecase.NewSaveResponse(l, socket, pr, responseCache, pipelines)
+	if err != nil {
+		l.Error("Failed to initiate save response.", "error", err)
+		os.Exit(1)
+	}
+
+	tracePropagator := &tracing.PropagationConfig{
+		AppID:     cfg.App.ID,
+		ProcessID: "save_response",
+	}
+	consumer, err := stream.NewConsumer(0, l, redisClient, saveResponse, &cfg.Redis.SubStream, nil, &cfg.App.Backoff, tracePropagator, backoffManager)
 	if err != nil {
 		l.Error("Failed to initiate consumer.", "error", err)
 		os.Exit(1)
 	}
 
-	r := registerRoutes(ph, socket, l)
+	probe := health.NewProbe()
+	probe.AddLiveness("relay", health.Heartbeat(relay.LastHeartbeat, relay.HeartbeatTimeout()))
+	probe.AddReadiness("postgres", postgresCheck(postgresClient), true)
+	probe.AddReadiness("redis", redisCheck(redisClient), true)
+	probe.AddReadiness("consumer_groups", consumer.CheckGroups, true)
+	probe.AddReadiness("consumers", consumersCheck(supervisor), true)
+
+	r := registerRoutes(ph, bh, plh, sch, ch, th, socket, probe, l)
 
 	l.Info("Starting server")
 
 	return &http.Server{
-		Addr:         ":" + cfg.App.Port,
-		Handler:      r,
-		IdleTimeout:  120 * time.Second,
-		ReadTimeout:  1 * time.Second,
-		WriteTimeout: 1 * time.Second,
-	}, relay, consumer, closer
+		Addr:              ":" + cfg.App.Port,
+		Handler:           r,
+		ReadHeaderTimeout: cfg.App.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.App.Server.ReadTimeout,
+		WriteTimeout:      cfg.App.Server.WriteTimeout,
+		IdleTimeout:       cfg.App.Server.IdleTimeout,
+	}, relay, scheduler, consumer, supervisor, closer
 }
 
-func registerRoutes(handler *promptHandler.Handler, socketManager *websocket.Manager, logger logger.Logger) *mux.Router {
+func registerRoutes(handler *promptHandler.Handler, batchHandler *promptHandler.BatchHandler, pipelinesHandler *promptHandler.PipelineHandler, schedulesHandler *promptHandler.ScheduleHandler, modelsHandler *catalogueHandler.Handler, templatesHandler *templateHandler.Handler, socketManager *websocket.Manager, probe *health.Probe, logger logger.Logger) *mux.Router {
 	r := mux.NewRouter()
 
 	recoveryManager := middleware.NewRecoveryManager(logger)
@@ -146,7 +335,24 @@ func registerRoutes(handler *promptHandler.Handler, socketManager *websocket.Man
 	r.Use(middleware.TracingMiddleware)
 
 	r.HandleFunc("/ask", handler.PostPrompt).Methods(http.MethodPost)
+	r.HandleFunc("/prompts/{id}", handler.DeletePrompt).Methods(http.MethodDelete)
+	r.HandleFunc("/batches", batchHandler.PostBatch).Methods(http.MethodPost)
+	r.HandleFunc("/batches/{id}", batchHandler.GetBatch).Methods(http.MethodGet)
+	r.HandleFunc("/batches/{id}/results", batchHandler.GetBatchResults).Methods(http.MethodGet)
+	r.HandleFunc("/pipelines", pipelinesHandler.PostPipeline).Methods(http.MethodPost)
+	r.HandleFunc("/pipelines/{id}", pipelinesHandler.GetPipeline).Methods(http.MethodGet)
+	r.HandleFunc("/schedules", schedulesHandler.ListSchedules).Methods(http.MethodGet)
+	r.HandleFunc("/schedules/{id}", schedulesHandler.GetSchedule).Methods(http.MethodGet)
+	r.HandleFunc("/schedules/{id}", schedulesHandler.DeleteSchedule).Methods(http.MethodDelete)
+	r.HandleFunc("/models", modelsHandler.ListModels).Methods(http.MethodGet)
+	r.HandleFunc("/templates", templatesHandler.ListTemplates).Methods(http.MethodGet)
+	r.HandleFunc("/templates", templatesHandler.CreateTemplate).Methods(http.MethodPost)
+	r.HandleFunc("/templates/{id}", templatesHandler.GetTemplate).Methods(http.MethodGet)
+	r.HandleFunc("/templates/{id}", templatesHandler.UpdateTemplate).Methods(http.MethodPut)
+	r.HandleFunc("/templates/{id}", templatesHandler.DeleteTemplate).Methods(http.MethodDelete)
 	r.HandleFunc("/health", healthCheck).Methods(http.MethodGet)
+	r.HandleFunc("/livez", probe.Livez).Methods(http.MethodGet)
+	r.HandleFunc("/readyz", probe.Readyz).Methods(http.MethodGet)
 
 	r.HandleFunc("/ws", socketManager.ServeWS).Methods(http.MethodGet)
 
@@ -157,7 +363,7 @@ func healthCheck(rw http.ResponseWriter, _ *http.Request) {
 	helper.WriteJSONResponse(rw, http.StatusOK, nil)
 }
 
-func GracefulShutdown(server *http.Server, relay *manager.Relay, consumer *stream.Consumer, logger *slog.Logger, tracerShutdown func(context.Context) error) {
+func GracefulShutdown(server *http.Server, relay *manager.Relay, scheduler *manager.Scheduler, consumer *stream.Consumer, supervisor *manager.Supervisor, logger *slog.Logger, tracerShutdown func(context.Context) error) {
 	appCtx, appCancel := context.WithCancel(context.Background())
 	defer appCancel()
 
@@ -182,14 +388,22 @@ func GracefulShutdown(server *http.Server, relay *manager.Relay, consumer *strea
 	}()
 
 	go func() {
-		logger.Info("Starting consumer")
-		if err := consumer.Consume(appCtx); err != nil {
+		logger.Info("Starting scheduler")
+		if err := scheduler.Start(appCtx); err != nil {
 			if !errors.Is(err, context.Canceled) {
-				logger.Error("error occurred in consumer", "error", err)
+				logger.Error("error occurred in scheduler", "error", err)
 			}
 		}
 	}()
 
+	consumerDone := make(chan struct{})
+	go func() {
+		defer close(consumerDone)
+
+		logger.Info("Starting consumer")
+		supervisor.Run(appCtx, consumer.WorkerID, consumer.Consume)
+	}()
+
 	select {
 	case sig := <-sigChan:
 		logger.Info("received terminate signal", "sig", sig)
@@ -206,6 +420,13 @@ func GracefulShutdown(server *http.Server, relay *manager.Relay, consumer *strea
 		logger.Error("error during server shutdown", "error", err, "addr", server.Addr)
 	}
 
+	// The consumer finishes the result it is saving, within its drain timeout.
+	select {
+	case <-consumerDone:
+	case <-ctx.Done():
+		logger.Error("consumer did not stop before the shutdown timeout")
+	}
+
 	if err := tracerShutdown(ctx); err != nil {
 		logger.Error("error occurred when shutting down tracer", "error", err)
 	}
@@ -0,0 +1,68 @@
+package app
+
+import (
+	"ai-orchestrator/internal/infra/manager"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
+	"slices"
+	"strings"
+)
+
+func postgresCheck(db *sqlx.DB) func(ctx context.Context) error {
+	return db.PingContext
+}
+
+func redisCheck(client *redis.Client) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
+	}
+}
+
+// consumersCheck fails when none of the supervised consumers runs.
+func consumersCheck(supervisor *manager.Supervisor) func(ctx context.Context) error {
+	return func(context.Context) error {
+		alive, total := supervisor.Alive()
+		if alive == 0 {
+			return fmt.Errorf("none of %d consumers runs", total)
+		}
+
+		return nil
+	}
+}
+
+// taskCheck fails when the supervised task does not run.
+func taskCheck(supervisor *manager.Supervisor, id string) func(ctx context.Context) error {
+	return func(context.Context) error {
+		status, ok := supervisor.Statuses()[id]
+		if !ok {
+			return fmt.Errorf("%s is not started", id)
+		}
+		if !status.Alive {
+			return fmt.Errorf("%s is restarting after %d restarts: %s", id, status.Restarts, status.LastError)
+		}
+
+		return nil
+	}
+}
+
+// circuitsCheck fails when a circuit is not closed. The instance still serves
+// the other models, so the check only degrades the readiness.
+func circuitsCheck(breaker *manager.CircuitBreaker) func(ctx context.Context) error {
+	return func(context.Context) error {
+		var open []string
+		for circuit, state := range breaker.States() {
+			if state != manager.CircuitClosed {
+				open = append(open, circuit+" is "+string(state))
+			}
+		}
+		if len(open) == 0 {
+			return nil
+		}
+		slices.Sort(open)
+
+		return errors.New(strings.Join(open, ", "))
+	}
+}
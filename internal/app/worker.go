@@ -4,25 +4,33 @@ import (
 	"ai-orchestrator/internal/config/connector"
 	"ai-orchestrator/internal/config/setup"
 	"ai-orchestrator/internal/config/worker"
+	"ai-orchestrator/internal/domain/gateway"
+	"ai-orchestrator/internal/domain/model"
+	"ai-orchestrator/internal/infra/ai"
 	"ai-orchestrator/internal/infra/ai/gemini"
 	"ai-orchestrator/internal/infra/broker"
 	"ai-orchestrator/internal/infra/manager"
+	"ai-orchestrator/internal/infra/ratelimit"
+	"ai-orchestrator/internal/infra/telemetry/health"
+	"ai-orchestrator/internal/infra/telemetry/metrics"
 	"ai-orchestrator/internal/infra/telemetry/tracing"
 	prompt2 "ai-orchestrator/internal/transport/stream"
 	"ai-orchestrator/internal/use_case/prompt"
 	"context"
-	"errors"
+	"encoding/json"
 	"google.golang.org/genai"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
-	"sync"
 	"syscall"
 	"time"
 )
 
-func SetupWorkers(cfg *worker.Config, l *slog.Logger) ([]*prompt2.Consumer, func(context.Context) error) {
+// cancelListenerID names the cancellation listener among the supervised tasks.
+const cancelListenerID = "cancel_listener"
+
+func SetupWorkers(cfg *worker.Config, l *slog.Logger) ([]*prompt2.Consumer, func(context.Context), func(context.Context) error, *manager.Supervisor, *manager.Pool, http.Handler) {
 	ctx := context.Background()
 
 	redisClient, err := connector.ConnectToRedis(cfg.App.Environment, cfg.Redis.URI)
@@ -53,12 +61,57 @@ func SetupWorkers(cfg *worker.Config, l *slog.Logger) ([]*prompt2.Consumer, func
 		os.Exit(1)
 	}
 
-	aiProvider, err := gemini.NewClient(l, client, *backoffManager)
+	geminiProvider, err := gemini.NewClient(l, client)
 	if err != nil {
 		l.Error("Failed to initiate ai provider.", "error", err)
 		os.Exit(1)
 	}
-	sendPromptUsecase, err := prompt.NewSendPromptUsecase(l, aiProvider, producer)
+
+	catalogue, err := setup.NewCatalogue(cfg.Models)
+	if err != nil {
+		l.Error("Failed to initiate model catalogue.", "error", err)
+		os.Exit(1)
+	}
+
+	breaker, err := manager.NewCircuitBreaker(l, &cfg.App.CircuitBreaker)
+	if err != nil {
+		l.Error("Failed to initiate circuit breaker.", "error", err)
+		os.Exit(1)
+	}
+
+	limiter, err := ratelimit.NewLimiter(l, redisClient, &cfg.App.RateLimit)
+	if err != nil {
+		l.Error("Failed to initiate rate limiter.", "error", err)
+		os.Exit(1)
+	}
+
+	aiProvider, err := ai.NewRouter(l, catalogue, map[string]gateway.AIProvider{
+		"gemini": geminiProvider,
+	}, breaker, limiter, backoffManager)
+	if err != nil {
+		l.Error("Failed to initiate ai router.", "error", err)
+		os.Exit(1)
+	}
+	cancelSignal, err := broker.NewCancelSignal(l, redisClient, &cfg.Redis.Cancellation)
+	if err != nil {
+		l.Error("Failed to initiate cancel signal.", "error", err)
+		os.Exit(1)
+	}
+
+	blobs, err := setup.NewBlobStore(ctx, l, cfg.App.Blob)
+	if err != nil {
+		l.Error("Failed to initiate blob store.", "error", err)
+		os.Exit(1)
+	}
+
+	// The worker has no database of its own, so no lookup hooks are exposed yet.
+	toolRegistry, err := setup.NewToolRegistry(l, &cfg.App.Tools, nil)
+	if err != nil {
+		l.Error("Failed to initiate tool registry.", "error", err)
+		os.Exit(1)
+	}
+
+	sendPromptUsecase, err := prompt.NewSendPromptUsecase(l, aiProvider, catalogue, producer, cancelSignal, blobs, toolRegistry, &cfg.App.Tools, &cfg.App.StructuredOutput)
 	if err != nil {
 		l.Error("Failed to initiate sendPrompUsecase.", "error", err)
 		os.Exit(1)
@@ -71,8 +124,20 @@ func SetupWorkers(cfg *worker.Config, l *slog.Logger) ([]*prompt2.Consumer, func
 		ProcessID: "send_to_ai",
 	}
 
-	for i := 1; i <= cfg.App.NumberOfWorkers; i++ {
-		consumer, err := prompt2.NewConsumer(i, l, redisClient, sendPromptUsecase, &cfg.Redis.SubStream, &cfg.App.Backoff, tracePropagator, *backoffManager)
+	weights := cfg.App.Priority.Weights
+	lanes := []prompt2.Lane{
+		{Stream: broker.LaneConfig(cfg.Redis.SubStream, model.PriorityHigh).ID, Weight: weights.High},
+		{Stream: broker.LaneConfig(cfg.Redis.SubStream, model.PriorityNormal).ID, Weight: weights.Normal},
+		{Stream: broker.LaneConfig(cfg.Redis.SubStream, model.PriorityLow).ID, Weight: weights.Low},
+	}
+
+	// With autoscaling, consumers are prepared for the largest pool and the pool runs some of them.
+	numberOfWorkers := cfg.App.NumberOfWorkers
+	if cfg.App.Autoscale.Enabled {
+		numberOfWorkers = cfg.App.Autoscale.MaxWorkers
+	}
+	for i := 1; i <= numberOfWorkers; i++ {
+		consumer, err := prompt2.NewConsumer(i, l, redisClient, sendPromptUsecase, &cfg.Redis.SubStream, lanes, &cfg.App.Backoff, tracePropagator, backoffManager)
 		if err != nil {
 			l.Error("Failed to initiate consumer.", "error", err)
 			os.Exit(1)
@@ -80,10 +145,62 @@ func SetupWorkers(cfg *worker.Config, l *slog.Logger) ([]*prompt2.Consumer, func
 		workers = append(workers, consumer)
 	}
 
-	return workers, closer
+	supervisor, err := manager.NewSupervisor(l, backoffManager)
+	if err != nil {
+		l.Error("Failed to initiate supervisor.", "error", err)
+		os.Exit(1)
+	}
+
+	// The listener has a supervisor of its own, so it does not count as a consumer.
+	listenerSupervisor, err := manager.NewSupervisor(l, backoffManager)
+	if err != nil {
+		l.Error("Failed to initiate listener supervisor.", "error", err)
+		os.Exit(1)
+	}
+	cancelListener := func(ctx context.Context) {
+		listenerSupervisor.Run(ctx, cancelListenerID, func(ctx context.Context) error {
+			return cancelSignal.Listen(ctx, sendPromptUsecase.Cancel)
+		})
+	}
+
+	streams := make([]string, 0, len(lanes))
+	for _, lane := range lanes {
+		streams = append(streams, lane.Stream)
+	}
+	backlog, err := broker.NewBacklog(l, redisClient, streams, cfg.Redis.SubStream.Group.ID)
+	if err != nil {
+		l.Error("Failed to initiate backlog.", "error", err)
+		os.Exit(1)
+	}
+
+	pool, err := manager.NewPool(l, &cfg.App.Autoscale, backlog)
+	if err != nil {
+		l.Error("Failed to initiate worker pool.", "error", err)
+		os.Exit(1)
+	}
+
+	registry := metrics.NewRegistry()
+	registry.Register(breaker)
+	registry.Register(supervisor)
+	registry.Register(listenerSupervisor)
+	registry.Register(backlog)
+	registry.Register(pool)
+
+	// The consumers share their lanes, checking the groups of one checks them all.
+	probe := health.NewProbe()
+	probe.AddReadiness("redis", redisCheck(redisClient), true)
+	if len(workers) > 0 {
+		probe.AddReadiness("consumer_groups", workers[0].CheckGroups, true)
+	}
+	probe.AddReadiness("consumers", consumersCheck(supervisor), true)
+	// Without cancellations the worker still processes its prompts, only the discarded ones are not stopped early.
+	probe.AddReadiness("cancel_listener", taskCheck(listenerSupervisor, cancelListenerID), false)
+	probe.AddReadiness("circuits", circuitsCheck(breaker), false)
+
+	return workers, cancelListener, closer, supervisor, pool, workerMonitor(l, breaker, supervisor, backlog, pool, registry, probe)
 }
 
-func StartWorkers(logger *slog.Logger, cfg *worker.Config, workers []*prompt2.Consumer, tracerShutdown func(context.Context) error) {
+func StartWorkers(logger *slog.Logger, cfg *worker.Config, workers []*prompt2.Consumer, cancelListener func(context.Context), tracerShutdown func(context.Context) error, supervisor *manager.Supervisor, pool *manager.Pool, monitor http.Handler) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -96,29 +213,32 @@ func StartWorkers(logger *slog.Logger, cfg *worker.Config, workers []*prompt2.Co
 		cancel()
 	}()
 
-	go addHealthCheck(logger, &cfg.App)
-
-	var wg sync.WaitGroup
-	logger.Info("Starting workers...", "numberOfWorkers", len(workers))
+	go addHealthCheck(logger, &cfg.App, monitor)
 
-	for _, w := range workers {
-		wg.Add(1)
+	// Cancellations keep being received while the workers drain.
+	listenerCtx, stopListener := context.WithCancel(context.Background())
+	defer stopListener()
 
-		go func(w *prompt2.Consumer) {
-			defer wg.Done()
+	listenerDone := make(chan struct{})
+	go func() {
+		defer close(listenerDone)
+		logger.Info("Starting cancellation listener")
+		// A failed listener is restarted, like the consumers.
+		cancelListener(listenerCtx)
+	}()
 
-			if err := w.Consume(ctx); err != nil {
-				if !errors.Is(err, context.Canceled) {
-					logger.Error("Worker failed", "id", w.WorkerID, "error", err)
-				}
-			}
-			logger.Info("Worker stopped gracefully", "id", w.WorkerID)
-		}(w)
-	}
+	logger.Info("Starting workers...", "numberOfWorkers", len(workers))
 
-	logger.Info("All workers are running. Waiting for tasks...")
+	// The pool returns once all its workers stopped.
+	pool.Run(ctx, len(workers), func(ctx context.Context, i int) {
+		w := workers[i]
 
-	wg.Wait()
+		// A failed consumer is restarted, so a Redis outage does not empty the pool.
+		supervisor.Run(ctx, w.WorkerID, w.Consume)
+		logger.Info("Worker stopped gracefully", "id", w.WorkerID)
+	})
+	stopListener()
+	<-listenerDone
 
 	logger.Info("Shutting down tracer...")
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -131,13 +251,10 @@ func StartWorkers(logger *slog.Logger, cfg *worker.Config, workers []*prompt2.Co
 	logger.Info("System shutdown complete.")
 }
 
-func addHealthCheck(logger *slog.Logger, cfg *worker.AppConfig) {
+func addHealthCheck(logger *slog.Logger, cfg *worker.AppConfig, monitor http.Handler) {
 	server := &http.Server{
-		Addr: ":" + cfg.Port,
-		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.WriteHeader(http.StatusOK)
-			w.Write([]byte("Worker is running"))
-		}),
+		Addr:    ":" + cfg.Port,
+		Handler: monitor,
 	}
 
 	logger.Info("Starting health check server", "port", cfg.Port)
@@ -147,3 +264,71 @@ func addHealthCheck(logger *slog.Logger, cfg *worker.AppConfig) {
 		os.Exit(1)
 	}
 }
+
+type workerBacklog struct {
+	Streams map[string]broker.StreamBacklog `json:"streams"`
+	Lag     int64                           `json:"lag"`
+	Pending int64                           `json:"pending"`
+	Workers int                             `json:"workers"`
+}
+
+type workerHealth struct {
+	Status   string                          `json:"status"`
+	Alive    int                             `json:"alive"`
+	Workers  map[string]manager.TaskStatus   `json:"workers"`
+	Circuits map[string]manager.CircuitState `json:"circuits"`
+}
+
+// workerMonitor serves the metrics on /metrics, and the health of the worker
+// with the state of its consumers and circuits on any other path. An open
+// circuit or a consumer waiting to restart degrades the worker; it is
+// unavailable only when none of its consumers runs.
+func workerMonitor(l *slog.Logger, breaker *manager.CircuitBreaker, supervisor *manager.Supervisor, backlog *broker.Backlog, pool *manager.Pool, registry *metrics.Registry, probe *health.Probe) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", registry)
+	// /backlog is the scaling signal of the platform, e.g. for a KEDA metrics-api scaler on "lag".
+	mux.HandleFunc("/backlog", func(w http.ResponseWriter, r *http.Request) {
+		streams, err := backlog.Streams(r.Context())
+		if err != nil {
+			l.ErrorContext(r.Context(), "Failed to read the backlog.", "error", err)
+			http.Error(w, "backlog unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		res := workerBacklog{Streams: streams, Workers: pool.Size()}
+		for _, s := range streams {
+			res.Lag += s.Lag
+			res.Pending += s.Pending
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(res)
+	})
+	mux.HandleFunc("/livez", probe.Livez)
+	mux.HandleFunc("/readyz", probe.Readyz)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		alive, total := supervisor.Alive()
+		health := workerHealth{Status: "ok", Alive: alive, Workers: supervisor.Statuses(), Circuits: breaker.States()}
+		for _, state := range health.Circuits {
+			if state != manager.CircuitClosed {
+				health.Status = "degraded"
+			}
+		}
+		if alive < total {
+			health.Status = "degraded"
+		}
+
+		code := http.StatusOK
+		if alive == 0 {
+			health.Status = "unavailable"
+			code = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(health)
+	})
+
+	return mux
+}
@@ -4,13 +4,15 @@ import (
 	"ai-orchestrator/internal/config/shared"
 	"fmt"
 	"strings"
+	"time"
 )
 
 type Config struct {
-	App      AppConfig          `yaml:"app"`
-	Postgres PostgresConfig     `yaml:"postgres"`
-	Redis    shared.RedisConfig `yaml:"redis"`
-	OTEL     shared.OtelConfig  `yaml:"otel"`
+	App      AppConfig            `yaml:"app"`
+	Postgres PostgresConfig       `yaml:"postgres"`
+	Redis    shared.RedisConfig   `yaml:"redis"`
+	OTEL     shared.OtelConfig    `yaml:"otel"`
+	Models   []shared.ModelConfig `yaml:"models"`
 }
 
 type AppConfig struct {
@@ -19,7 +21,48 @@ type AppConfig struct {
 	Environment   string `yaml:"env" env:"APP_ENV" env-default:"development"`
 	MigrationsDir string `yaml:"migrations_dir" env:"MIGRATIONS_DIR"`
 
-	Backoff shared.BackoffConfig `yaml:"backoff"`
+	Backoff    shared.BackoffConfig    `yaml:"backoff"`
+	Scheduler  shared.SchedulerConfig  `yaml:"scheduler"`
+	Timeout    shared.TimeoutConfig    `yaml:"timeout"`
+	Generation shared.GenerationConfig `yaml:"generation"`
+	Validation ValidationConfig        `yaml:"validation"`
+
+	Blob   shared.BlobConfig `yaml:"blob"`
+	Server ServerConfig      `yaml:"server"`
+}
+
+// ServerConfig bounds the connections of the HTTP server. The read and write
+// timeouts cover a whole request, so they leave room for batch uploads and
+// result downloads of the maximum body size.
+type ServerConfig struct {
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT" env-default:"5s"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"SERVER_READ_TIMEOUT" env-default:"60s"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"SERVER_WRITE_TIMEOUT" env-default:"60s"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT" env-default:"120s"`
+}
+
+type ValidationConfig struct {
+	MaxBodyBytes    int64 `yaml:"max_body_bytes" env:"VALIDATION_MAX_BODY_BYTES" env-default:"1048576"`
+	MaxPromptLength int   `yaml:"max_prompt_length" env:"VALIDATION_MAX_PROMPT_LENGTH" env-default:"32768"`
+
+	Attachments AttachmentsConfig `yaml:"attachments"`
+	Batch       BatchConfig       `yaml:"batch"`
+	Pipeline    PipelineConfig    `yaml:"pipeline"`
+}
+
+type PipelineConfig struct {
+	MaxSteps int `yaml:"max_steps" env:"PIPELINE_MAX_STEPS" env-default:"20"`
+}
+
+type BatchConfig struct {
+	MaxSize      int   `yaml:"max_size" env:"BATCH_MAX_SIZE" env-default:"1000"`
+	MaxBodyBytes int64 `yaml:"max_body_bytes" env:"BATCH_MAX_BODY_BYTES" env-default:"16777216"`
+}
+
+type AttachmentsConfig struct {
+	MaxCount     int      `yaml:"max_count" env:"ATTACHMENTS_MAX_COUNT" env-default:"4"`
+	MaxSize      int64    `yaml:"max_size" env:"ATTACHMENTS_MAX_SIZE" env-default:"5242880"`
+	AllowedTypes []string `yaml:"allowed_types" env:"ATTACHMENTS_ALLOWED_TYPES" env-default:"image/png,image/jpeg,image/webp,application/pdf,text/plain"`
 }
 
 type PostgresConfig struct {
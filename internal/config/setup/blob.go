@@ -0,0 +1,24 @@
+package setup
+
+import (
+	"ai-orchestrator/internal/common/logger"
+	"ai-orchestrator/internal/config/shared"
+	"ai-orchestrator/internal/domain/gateway"
+	"ai-orchestrator/internal/infra/blob/gcs"
+	"ai-orchestrator/internal/infra/blob/local"
+	"context"
+	"fmt"
+)
+
+// NewBlobStore creates the store of the configured driver. "local" needs the
+// API and the worker to share a volume, "gcs" works across services.
+func NewBlobStore(ctx context.Context, l logger.Logger, cfg shared.BlobConfig) (gateway.BlobStore, error) {
+	switch cfg.Driver {
+	case "local":
+		return local.NewStore(l, cfg.Dir)
+	case "gcs":
+		return gcs.NewStore(ctx, l, cfg.Bucket)
+	default:
+		return nil, fmt.Errorf("unsupported blob driver %q", cfg.Driver)
+	}
+}
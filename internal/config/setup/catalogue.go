@@ -0,0 +1,36 @@
+package setup
+
+import (
+	"ai-orchestrator/internal/config/shared"
+	"ai-orchestrator/internal/domain/model"
+)
+
+func NewCatalogue(cfg []shared.ModelConfig) (*model.Catalogue, error) {
+	models := make([]model.Model, 0, len(cfg))
+	for _, m := range cfg {
+		models = append(models, model.Model{
+			ID:              m.ID,
+			Provider:        m.Provider,
+			DisplayName:     m.DisplayName,
+			ContextWindow:   m.ContextWindow,
+			InputPrice:      m.Price.Input,
+			OutputPrice:     m.Price.Output,
+			Enabled:         m.Enabled,
+			Default:         m.Default,
+			DefaultTimeout:  m.Timeout.Default,
+			MaxTimeout:      m.Timeout.Max,
+			MaxOutputTokens: m.MaxOutputTokens,
+			JSONMode:        m.JSONMode,
+			Attachments:     m.Attachments,
+			Tools:           m.Tools,
+			CacheTTL:        m.CacheTTL,
+			Fallbacks:       m.Fallbacks,
+			Quota: model.Quota{
+				RequestsPerMinute: m.RateLimit.RequestsPerMinute,
+				TokensPerMinute:   m.RateLimit.TokensPerMinute,
+			},
+		})
+	}
+
+	return model.NewCatalogue(models)
+}
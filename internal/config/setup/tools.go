@@ -0,0 +1,36 @@
+package setup
+
+import (
+	"ai-orchestrator/internal/common/logger"
+	"ai-orchestrator/internal/config/shared"
+	"ai-orchestrator/internal/infra/tools"
+)
+
+// NewToolRegistry registers the built-in tools the worker is configured for:
+// http_fetch needs allowed hosts and db_lookup needs lookup hooks.
+func NewToolRegistry(l logger.Logger, cfg *shared.ToolsConfig, hooks map[string]tools.LookupHook) (*tools.Registry, error) {
+	registry, err := tools.NewRegistry(l, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	registry.Register(tools.NewCalculator())
+
+	if len(cfg.HTTPFetch.AllowedHosts) > 0 {
+		fetch, err := tools.NewHTTPFetch(cfg)
+		if err != nil {
+			return nil, err
+		}
+		registry.Register(fetch)
+	}
+
+	if len(hooks) > 0 {
+		lookup, err := tools.NewDBLookup(hooks)
+		if err != nil {
+			return nil, err
+		}
+		registry.Register(lookup)
+	}
+
+	return registry, nil
+}
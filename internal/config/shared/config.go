@@ -7,7 +7,8 @@ type RedisConfig struct {
 	PubStream StreamConfig `yaml:"pub_stream"`
 	SubStream StreamConfig `yaml:"sub_stream"`
 
-	Cache CacheConfig `yaml:"cache"`
+	Cache        CacheConfig        `yaml:"cache"`
+	Cancellation CancellationConfig `yaml:"cancellation"`
 }
 
 type StreamConfig struct {
@@ -17,6 +18,8 @@ type StreamConfig struct {
 	UseDelApprox bool          `yaml:"use_del_approx"`
 	ReadCount    int64         `yaml:"read_count"`
 	BlockTime    time.Duration `yaml:"block_time"`
+	// DrainTimeout is how long the running task may take once the consumer stops.
+	DrainTimeout time.Duration `yaml:"drain_timeout"`
 
 	Group GroupConfig `yaml:"group"`
 }
@@ -26,18 +29,148 @@ type GroupConfig struct {
 	ConsumerPrimarilyID string `yaml:"consumer_primarily_id"`
 }
 
+// CacheConfig configures the response cache. Models of the catalogue may override the TTL.
 type CacheConfig struct {
-	TTL time.Duration `yaml:"ttl" env:"CACHE_TTL" env-default:"5m"`
+	Enabled bool          `yaml:"enabled" env:"CACHE_ENABLED" env-default:"false"`
+	TTL     time.Duration `yaml:"ttl" env:"CACHE_TTL" env-default:"5m"`
 }
 
+type CancellationConfig struct {
+	Channel string        `yaml:"channel" env:"CANCEL_CHANNEL" env-default:"prompt_cancellations"`
+	TTL     time.Duration `yaml:"ttl" env:"CANCEL_TTL" env-default:"1h"`
+}
+
+// BackoffConfig grows the delay between retries by Factor from Min up to Max.
+// Jitter is "proportional", "full" or "decorrelated".
 type BackoffConfig struct {
 	Min          time.Duration `yaml:"min" env:"BACKOFF_MIN" env-default:"1s"`
 	Max          time.Duration `yaml:"max" env:"BACKOFF_MAX" env-default:"60s"`
 	Factor       float64       `yaml:"factor" env:"BACKOFF_FACTOR" env-default:"2"`
+	Jitter       string        `yaml:"jitter" env:"BACKOFF_JITTER" env-default:"full"`
 	PollInterval time.Duration `yaml:"poll_interval" env:"POLL_INTERVAL" env-default:"1s"`
 	MaxRetries   int           `yaml:"max_retries" env:"MAX_RETRIES" env-default:"5"`
 }
 
+// CircuitBreakerConfig trips the circuit of a model when at least MinRequests
+// calls were made in the Window and FailureRate of them failed. The circuit
+// stays open for OpenTimeout, then lets HalfOpenRequests probe calls through.
+type CircuitBreakerConfig struct {
+	Window           time.Duration `yaml:"window" env:"CIRCUIT_BREAKER_WINDOW" env-default:"60s"`
+	MinRequests      int           `yaml:"min_requests" env:"CIRCUIT_BREAKER_MIN_REQUESTS" env-default:"10"`
+	FailureRate      float64       `yaml:"failure_rate" env:"CIRCUIT_BREAKER_FAILURE_RATE" env-default:"0.5"`
+	OpenTimeout      time.Duration `yaml:"open_timeout" env:"CIRCUIT_BREAKER_OPEN_TIMEOUT" env-default:"30s"`
+	HalfOpenRequests int           `yaml:"half_open_requests" env:"CIRCUIT_BREAKER_HALF_OPEN_REQUESTS" env-default:"3"`
+}
+
+// RateLimitConfig paces the calls to the providers to stay under their quotas.
+// The buckets are kept in Redis, so the quotas are shared by all the workers.
+// A call waits for its buckets at most MaxWait, and fails as rate limited after.
+type RateLimitConfig struct {
+	Enabled   bool                   `yaml:"enabled" env:"RATE_LIMIT_ENABLED" env-default:"false"`
+	MaxWait   time.Duration          `yaml:"max_wait" env:"RATE_LIMIT_MAX_WAIT" env-default:"30s"`
+	Providers map[string]QuotaConfig `yaml:"providers"`
+}
+
+// QuotaConfig is a quota per minute, zero means unlimited.
+type QuotaConfig struct {
+	RequestsPerMinute int `yaml:"requests_per_minute"`
+	TokensPerMinute   int `yaml:"tokens_per_minute"`
+}
+
+// SchedulerConfig configures how often the due schedules are fired.
+type SchedulerConfig struct {
+	PollInterval time.Duration `yaml:"poll_interval" env:"SCHEDULER_POLL_INTERVAL" env-default:"5s"`
+	BatchSize    int           `yaml:"batch_size" env:"SCHEDULER_BATCH_SIZE" env-default:"50"`
+}
+
+// AutoscaleConfig sizes the worker pool by the lag of the streams: a worker
+// per TargetBacklog messages, between MinWorkers and MaxWorkers. The pool grows
+// at once and shrinks a worker at a time, once it stayed too large for
+// ScaleDownDelay.
+type AutoscaleConfig struct {
+	Enabled        bool          `yaml:"enabled" env:"AUTOSCALE_ENABLED" env-default:"false"`
+	MinWorkers     int           `yaml:"min_workers" env:"AUTOSCALE_MIN_WORKERS" env-default:"1"`
+	MaxWorkers     int           `yaml:"max_workers" env:"AUTOSCALE_MAX_WORKERS" env-default:"8"`
+	TargetBacklog  int64         `yaml:"target_backlog" env:"AUTOSCALE_TARGET_BACKLOG" env-default:"10"`
+	Interval       time.Duration `yaml:"interval" env:"AUTOSCALE_INTERVAL" env-default:"15s"`
+	ScaleDownDelay time.Duration `yaml:"scale_down_delay" env:"AUTOSCALE_SCALE_DOWN_DELAY" env-default:"1m"`
+}
+
+// ToolsConfig sandboxes the built-in tools the models may call.
+type ToolsConfig struct {
+	MaxRounds      int           `yaml:"max_rounds" env:"TOOLS_MAX_ROUNDS" env-default:"5"`
+	Timeout        time.Duration `yaml:"timeout" env:"TOOLS_TIMEOUT" env-default:"10s"`
+	MaxOutputBytes int           `yaml:"max_output_bytes" env:"TOOLS_MAX_OUTPUT_BYTES" env-default:"32768"`
+
+	HTTPFetch HTTPFetchConfig `yaml:"http_fetch"`
+}
+
+// HTTPFetchConfig lists the hosts the http_fetch tool may reach. A leading dot
+// allows the subdomains too. The tool is disabled without hosts.
+type HTTPFetchConfig struct {
+	AllowedHosts []string `yaml:"allowed_hosts" env:"TOOLS_HTTP_FETCH_ALLOWED_HOSTS"`
+}
+
+// StructuredOutputConfig bounds the repair prompts sent when an answer does not
+// match the response schema of the prompt.
+type StructuredOutputConfig struct {
+	MaxRepairs int `yaml:"max_repairs" env:"STRUCTURED_OUTPUT_MAX_REPAIRS" env-default:"2"`
+}
+
+// TimeoutConfig bounds how long a prompt may be processed. Models of the
+// catalogue may override the global limits.
+type TimeoutConfig struct {
+	Default time.Duration `yaml:"default" env:"TIMEOUT_DEFAULT" env-default:"60s"`
+	Max     time.Duration `yaml:"max" env:"TIMEOUT_MAX" env-default:"300s"`
+}
+
+type TimeoutLimits struct {
+	Default time.Duration `yaml:"default"`
+	Max     time.Duration `yaml:"max"`
+}
+
+// GenerationConfig bounds the generation options clients may pass. Models of
+// the catalogue may override the global limits.
+type GenerationConfig struct {
+	MaxOutputTokens  int32 `yaml:"max_output_tokens" env:"GENERATION_MAX_OUTPUT_TOKENS" env-default:"8192"`
+	MaxStopSequences int   `yaml:"max_stop_sequences" env:"GENERATION_MAX_STOP_SEQUENCES" env-default:"5"`
+	JSONMode         bool  `yaml:"json_mode" env:"GENERATION_JSON_MODE" env-default:"true"`
+}
+
+// ModelConfig is an entry of the model catalogue.
+type ModelConfig struct {
+	ID            string      `yaml:"id"`
+	Provider      string      `yaml:"provider"`
+	DisplayName   string      `yaml:"display_name"`
+	ContextWindow int         `yaml:"context_window"`
+	Price         PriceConfig `yaml:"price"`
+	Enabled       bool        `yaml:"enabled"`
+	Default       bool        `yaml:"default"`
+
+	Timeout         TimeoutLimits `yaml:"timeout"`
+	MaxOutputTokens int32         `yaml:"max_output_tokens"`
+	JSONMode        *bool         `yaml:"json_mode"`
+	Attachments     bool          `yaml:"attachments"`
+	Tools           bool          `yaml:"tools"`
+	CacheTTL        time.Duration `yaml:"cache_ttl"`
+	Fallbacks       []string      `yaml:"fallbacks"`
+	RateLimit       QuotaConfig   `yaml:"rate_limit"`
+}
+
+// PriceConfig is in USD per one million tokens.
+type PriceConfig struct {
+	Input  float64 `yaml:"input"`
+	Output float64 `yaml:"output"`
+}
+
+// BlobConfig selects the blob store: "local" keeps blobs under Dir, "gcs" in
+// the Cloud Storage Bucket.
+type BlobConfig struct {
+	Driver string `yaml:"driver" env:"BLOB_DRIVER" env-default:"local"`
+	Dir    string `yaml:"dir" env:"BLOB_DIR" env-default:"/app/data/blobs"`
+	Bucket string `yaml:"bucket" env:"BLOB_BUCKET"`
+}
+
 type OtelConfig struct {
 	URI string `yaml:"uri" env:"OTEL_URI"`
 }
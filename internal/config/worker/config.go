@@ -5,9 +5,10 @@ import (
 )
 
 type Config struct {
-	App   AppConfig          `yaml:"app"`
-	Redis shared.RedisConfig `yaml:"redis"`
-	OTEL  shared.OtelConfig  `yaml:"otel"`
+	App    AppConfig            `yaml:"app"`
+	Redis  shared.RedisConfig   `yaml:"redis"`
+	OTEL   shared.OtelConfig    `yaml:"otel"`
+	Models []shared.ModelConfig `yaml:"models"`
 }
 
 type AppConfig struct {
@@ -16,5 +17,25 @@ type AppConfig struct {
 	Environment     string `yaml:"env" env:"APP_ENV" env-default:"development"`
 	NumberOfWorkers int    `yaml:"number_of_workers" env:"NUMBER_OF_WORKERS" env-default:"1"`
 
-	Backoff shared.BackoffConfig `yaml:"backoff"`
+	Backoff  shared.BackoffConfig `yaml:"backoff"`
+	Blob     shared.BlobConfig    `yaml:"blob"`
+	Priority PriorityConfig       `yaml:"priority"`
+	Tools    shared.ToolsConfig   `yaml:"tools"`
+
+	StructuredOutput shared.StructuredOutputConfig `yaml:"structured_output"`
+	CircuitBreaker   shared.CircuitBreakerConfig   `yaml:"circuit_breaker"`
+	RateLimit        shared.RateLimitConfig        `yaml:"rate_limit"`
+	Autoscale        shared.AutoscaleConfig        `yaml:"autoscale"`
+}
+
+// PriorityConfig weights the priority lanes: with backlog in every lane, the
+// lanes are served in a smooth weighted round-robin by their share.
+type PriorityConfig struct {
+	Weights PriorityWeights `yaml:"weights"`
+}
+
+type PriorityWeights struct {
+	High   int `yaml:"high" env:"PRIORITY_WEIGHT_HIGH" env-default:"6"`
+	Normal int `yaml:"normal" env:"PRIORITY_WEIGHT_NORMAL" env-default:"3"`
+	Low    int `yaml:"low" env:"PRIORITY_WEIGHT_LOW" env-default:"1"`
 }
@@ -1,12 +1,43 @@
 package gateway
 
 import (
+	"ai-orchestrator/internal/domain/model"
 	"context"
+	"encoding/json"
 	"errors"
 )
 
 var ErrNilProvider = errors.New("provider is nil")
 
+type Request struct {
+	Model   string
+	Prompt  string
+	Options model.GenerationOptions
+
+	// Attachments carry their content, providers send them as inline parts.
+	Attachments []model.Attachment
+
+	// Tools are the functions the model may call. ToolCalls are the calls made
+	// so far with their results, replayed after the prompt in round order.
+	Tools     []Tool
+	ToolCalls []model.ToolCall
+}
+
+// Tool declares a function to the model. Parameters is a JSON Schema object.
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  json.RawMessage
+}
+
+// Response is either the final text, or the tools the model wants called before answering.
+// Model is the catalogue model that produced it.
+type Response struct {
+	Text      string
+	ToolCalls []model.ToolCall
+	Model     string
+}
+
 type AIProvider interface {
-	Generate(ctx context.Context, model, prompt string) (string, error)
+	Generate(ctx context.Context, request Request) (Response, error)
 }
@@ -0,0 +1,17 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrNilBlobStore = errors.New("blob store is nil")
+	ErrBlobNotFound = errors.New("blob not found")
+)
+
+// BlobStore keeps binary content, such as prompt attachments, shared by the API and the workers.
+type BlobStore interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+}
@@ -0,0 +1,59 @@
+package gateway
+
+import (
+	"ai-orchestrator/internal/domain/model"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ProviderError classifies a provider failure with a domain error code while
+// keeping the original error for logs.
+type ProviderError struct {
+	Code model.ErrorCode
+	Err  error
+}
+
+func NewProviderError(code model.ErrorCode, err error) *ProviderError {
+	return &ProviderError{
+		Code: code,
+		Err:  err,
+	}
+}
+
+func (e *ProviderError) Error() string {
+	if e.Err == nil {
+		return string(e.Code)
+	}
+	return string(e.Code) + ": " + e.Err.Error()
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// CircuitOpenError rejects a call without reaching the provider, because the
+// circuit of the model is open. The circuit lets calls through after RetryAfter.
+type CircuitOpenError struct {
+	Circuit    string
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit %s is open, retry after %s", e.Circuit, e.RetryAfter)
+}
+
+// ErrorCodeOf returns the code of the first ProviderError in the chain.
+// Unclassified errors are reported as internal ones.
+func ErrorCodeOf(err error) model.ErrorCode {
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) {
+		return providerErr.Code
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return model.ErrorCodeTimeout
+	}
+
+	return model.ErrorCodeInternal
+}
@@ -0,0 +1,17 @@
+package model
+
+import "errors"
+
+var ErrInvalidAttachments = errors.New("invalid attachments")
+
+// Attachment is a file sent along with the prompt. The content lives in the
+// blob store under Key; Data is only populated while the file is in transit
+// and is never persisted nor carried in task payloads.
+type Attachment struct {
+	Key      string `json:"key"`
+	Name     string `json:"name"`
+	MimeType string `json:"mime_type"`
+	Size     int64  `json:"size"`
+
+	Data []byte `json:"-"`
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"time"
+)
+
+var (
+	ErrBatchNotFound    = errors.New("batch not found")
+	ErrBatchNotComplete = errors.New("batch is still processing")
+)
+
+type BatchStatus string
+
+var (
+	BatchProcessing BatchStatus = "Processing"
+	BatchCompleted  BatchStatus = "Completed"
+)
+
+// Batch groups prompts submitted together. The counters follow the statuses of
+// its prompts, the batch is completed once every prompt has been completed,
+// failed or discarded.
+type Batch struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Status    BatchStatus
+	Total     int
+	Completed int
+	Failed    int
+	Discarded int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (b *Batch) Pending() int {
+	return b.Total - b.Completed - b.Failed - b.Discarded
+}
+
+// BatchRef places a prompt in its batch. Index is the position of the prompt in the submitted list.
+type BatchRef struct {
+	ID    uuid.UUID
+	Index int
+}
@@ -0,0 +1,145 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+var (
+	ErrUnknownModel  = errors.New("unknown model")
+	ErrModelDisabled = errors.New("model is disabled")
+	ErrNoModel       = errors.New("model is not specified and there is no default one")
+)
+
+// Model describes an AI model offered to clients and how to reach it.
+type Model struct {
+	ID            string
+	Provider      string
+	DisplayName   string
+	ContextWindow int
+
+	// Prices are in USD per one million tokens.
+	InputPrice  float64
+	OutputPrice float64
+
+	Enabled bool
+	Default bool
+
+	// Limits override the global ones when non-zero.
+	DefaultTimeout  time.Duration
+	MaxTimeout      time.Duration
+	MaxOutputTokens int32
+	JSONMode        *bool
+
+	// Attachments tells whether the provider accepts files as inline parts of the prompt.
+	Attachments bool
+
+	// Tools tells whether the provider supports function calling.
+	Tools bool
+
+	// CacheTTL overrides the global response cache TTL when non-zero.
+	CacheTTL time.Duration
+
+	// Fallbacks are the models tried in order when the provider of this one is
+	// still rate limited or unavailable once its retries are exhausted.
+	Fallbacks []string
+
+	// Quota paces the calls to the model on top of the quota of its provider.
+	Quota Quota
+}
+
+// Quota is a limit per minute, zero means unlimited.
+type Quota struct {
+	RequestsPerMinute int
+	TokensPerMinute   int
+}
+
+// Catalogue is the set of models known to the system. Enabling a new model is
+// a configuration change: the API validates requests and the worker routes
+// them to providers using the catalogue only.
+type Catalogue struct {
+	models []Model
+	byID   map[string]Model
+	def    *Model
+}
+
+func NewCatalogue(models []Model) (*Catalogue, error) {
+	c := &Catalogue{
+		models: models,
+		byID:   make(map[string]Model, len(models)),
+	}
+
+	for i, m := range models {
+		if m.ID == "" {
+			return nil, errors.New("model id is empty")
+		}
+		if m.Provider == "" {
+			return nil, fmt.Errorf("model %q has no provider", m.ID)
+		}
+		if _, exists := c.byID[m.ID]; exists {
+			return nil, fmt.Errorf("model %q is declared twice", m.ID)
+		}
+		c.byID[m.ID] = m
+
+		if m.Default {
+			if c.def != nil {
+				return nil, fmt.Errorf("models %q and %q are both marked as default", c.def.ID, m.ID)
+			}
+			if !m.Enabled {
+				return nil, fmt.Errorf("default model %q is disabled", m.ID)
+			}
+			c.def = &models[i]
+		}
+	}
+
+	for _, m := range models {
+		for i, fallback := range m.Fallbacks {
+			if _, ok := c.byID[fallback]; !ok {
+				return nil, fmt.Errorf("fallback %q of model %q is not declared", fallback, m.ID)
+			}
+			if fallback == m.ID || slices.Contains(m.Fallbacks[:i], fallback) {
+				return nil, fmt.Errorf("fallback %q of model %q is listed twice", fallback, m.ID)
+			}
+		}
+	}
+
+	return c, nil
+}
+
+func (c *Catalogue) Get(id string) (Model, bool) {
+	m, ok := c.byID[id]
+	return m, ok
+}
+
+// Resolve returns the enabled model with the given ID, or the default model when the ID is empty.
+func (c *Catalogue) Resolve(id string) (Model, error) {
+	if id == "" {
+		if c.def == nil {
+			return Model{}, ErrNoModel
+		}
+		return *c.def, nil
+	}
+
+	m, ok := c.byID[id]
+	if !ok {
+		return Model{}, fmt.Errorf("%w: %q", ErrUnknownModel, id)
+	}
+	if !m.Enabled {
+		return Model{}, fmt.Errorf("%w: %q", ErrModelDisabled, id)
+	}
+
+	return m, nil
+}
+
+func (c *Catalogue) Enabled() []Model {
+	enabled := make([]Model, 0, len(c.models))
+	for _, m := range c.models {
+		if m.Enabled {
+			enabled = append(enabled, m)
+		}
+	}
+
+	return enabled
+}
@@ -0,0 +1,218 @@
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		wantErr bool
+	}{
+		{name: "every minute", expr: "* * * * *"},
+		{name: "descriptor", expr: "@daily"},
+		{name: "descriptor in upper case", expr: "@HOURLY"},
+		{name: "names and ranges", expr: "0 8 * jan-jun mon-fri"},
+		{name: "lists and steps", expr: "*/15 0-6/2 1,15 * *"},
+		{name: "step from a value", expr: "5/20 * * * *"},
+		{name: "sunday as 7", expr: "0 0 * * 7"},
+		{name: "surrounding spaces", expr: "  0 0 * * *  "},
+		{name: "empty", expr: "", wantErr: true},
+		{name: "missing field", expr: "* * * *", wantErr: true},
+		{name: "extra field", expr: "* * * * * *", wantErr: true},
+		{name: "unknown descriptor", expr: "@reboot", wantErr: true},
+		{name: "minute out of range", expr: "60 * * * *", wantErr: true},
+		{name: "hour out of range", expr: "* 24 * * *", wantErr: true},
+		{name: "day of month out of range", expr: "* * 0 * *", wantErr: true},
+		{name: "month out of range", expr: "* * * 13 *", wantErr: true},
+		{name: "day of week out of range", expr: "* * * * 8", wantErr: true},
+		{name: "zero step", expr: "*/0 * * * *", wantErr: true},
+		{name: "reversed range", expr: "5-1 * * * *", wantErr: true},
+		{name: "unknown name", expr: "* * * foo *", wantErr: true},
+		{name: "empty list item", expr: "1,,2 * * * *", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseCron(tt.expr)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCron) {
+					t.Fatalf("ParseCron(%q) error = %v, want %v", tt.expr, err, ErrInvalidCron)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseCron(%q) unexpected error: %v", tt.expr, err)
+			}
+		})
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{
+			name: "next quarter",
+			expr: "*/15 * * * *",
+			from: utc(2026, 10, 19, 10, 7, 30),
+			want: utc(2026, 10, 19, 10, 15, 0),
+		},
+		{
+			name: "strictly after a matching time",
+			expr: "0 8 * * *",
+			from: utc(2026, 10, 19, 8, 0, 0),
+			want: utc(2026, 10, 20, 8, 0, 0),
+		},
+		{
+			name: "across a day",
+			expr: "30 6 * * *",
+			from: utc(2026, 10, 19, 23, 59, 0),
+			want: utc(2026, 10, 20, 6, 30, 0),
+		},
+		{
+			name: "across a month",
+			expr: "0 0 1 * *",
+			from: utc(2026, 10, 19, 12, 0, 0),
+			want: utc(2026, 11, 1, 0, 0, 0),
+		},
+		{
+			name: "skips months without the day",
+			expr: "30 23 31 * *",
+			from: utc(2026, 10, 31, 23, 45, 0),
+			want: utc(2026, 12, 31, 23, 30, 0),
+		},
+		{
+			name: "across a year",
+			expr: "@yearly",
+			from: utc(2026, 12, 31, 23, 59, 0),
+			want: utc(2027, 1, 1, 0, 0, 0),
+		},
+		{
+			name: "next leap day",
+			expr: "0 0 29 feb *",
+			from: utc(2026, 3, 1, 0, 0, 0),
+			want: utc(2028, 2, 29, 0, 0, 0),
+		},
+		{
+			name: "never",
+			expr: "0 0 30 2 *",
+			from: utc(2026, 10, 19, 0, 0, 0),
+			want: time.Time{},
+		},
+		{
+			name: "weekdays over a weekend",
+			expr: "0 9 * * mon-fri",
+			from: utc(2026, 10, 23, 10, 0, 0),
+			want: utc(2026, 10, 26, 9, 0, 0),
+		},
+		{
+			name: "sunday as 7",
+			expr: "0 12 * * 7",
+			from: utc(2026, 10, 19, 0, 0, 0),
+			want: utc(2026, 10, 25, 12, 0, 0),
+		},
+		{
+			name: "restricted days match either field",
+			expr: "0 0 1 * mon",
+			from: utc(2026, 10, 27, 0, 0, 0),
+			want: utc(2026, 11, 1, 0, 0, 0),
+		},
+		{
+			name: "day of month starting with a star matches both fields",
+			expr: "0 0 */2 * mon",
+			from: utc(2026, 10, 19, 12, 0, 0),
+			want: utc(2026, 11, 9, 0, 0, 0),
+		},
+		{
+			name: "day of week starting with a star matches both fields",
+			expr: "0 0 13 * */6",
+			from: utc(2026, 10, 19, 0, 0, 0),
+			want: utc(2026, 12, 13, 0, 0, 0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cron, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q) unexpected error: %v", tt.expr, err)
+			}
+			if got := cron.Next(tt.from); !got.Equal(tt.want) {
+				t.Fatalf("Next(%s) = %s, want %s", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+// The expressions are evaluated in UTC, so a firing does not move nor repeat
+// when the clocks of the caller change.
+func TestCronNextDST(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want []time.Time
+	}{
+		{
+			name: "spring forward",
+			expr: "30 6 * * *",
+			from: time.Date(2026, 3, 7, 12, 0, 0, 0, newYork),
+			want: []time.Time{
+				utc(2026, 3, 8, 6, 30, 0),
+				utc(2026, 3, 9, 6, 30, 0),
+			},
+		},
+		{
+			name: "fall back",
+			expr: "30 5 * * *",
+			from: time.Date(2026, 10, 31, 12, 0, 0, 0, newYork),
+			want: []time.Time{
+				utc(2026, 11, 1, 5, 30, 0),
+				utc(2026, 11, 2, 5, 30, 0),
+			},
+		},
+		{
+			name: "hourly over the repeated hour",
+			expr: "@hourly",
+			from: time.Date(2026, 11, 1, 1, 30, 0, 0, newYork),
+			want: []time.Time{
+				utc(2026, 11, 1, 6, 0, 0),
+				utc(2026, 11, 1, 7, 0, 0),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cron, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q) unexpected error: %v", tt.expr, err)
+			}
+
+			next := tt.from
+			for _, want := range tt.want {
+				next = cron.Next(next)
+				if !next.Equal(want) || next.Location() != time.UTC {
+					t.Fatalf("Next = %s, want %s", next, want)
+				}
+			}
+		})
+	}
+}
+
+func utc(year int, month time.Month, day, hour, minute, sec int) time.Time {
+	return time.Date(year, month, day, hour, minute, sec, 0, time.UTC)
+}
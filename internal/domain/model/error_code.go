@@ -0,0 +1,36 @@
+package model
+
+// ErrorCode is a stable, client-facing reason of a prompt failure.
+// Providers map their own errors into these codes, so clients never depend on
+// the wording of a particular SDK.
+type ErrorCode string
+
+const (
+	ErrorCodeRateLimited         ErrorCode = "rate_limited"
+	ErrorCodeInvalidRequest      ErrorCode = "invalid_request"
+	ErrorCodeContentBlocked      ErrorCode = "content_blocked"
+	ErrorCodeTimeout             ErrorCode = "timeout"
+	ErrorCodeProviderUnavailable ErrorCode = "provider_unavailable"
+	ErrorCodeToolLoop            ErrorCode = "tool_loop_exceeded"
+	ErrorCodeInvalidOutput       ErrorCode = "invalid_output"
+	ErrorCodeInternal            ErrorCode = "internal"
+)
+
+var errorMessages = map[ErrorCode]string{
+	ErrorCodeRateLimited:         "The model provider is rate limiting requests. Please try again later.",
+	ErrorCodeInvalidRequest:      "The model provider rejected the request as invalid.",
+	ErrorCodeContentBlocked:      "The prompt or the response was blocked by the content safety filters.",
+	ErrorCodeTimeout:             "The prompt was not processed before its deadline.",
+	ErrorCodeProviderUnavailable: "The model provider is temporarily unavailable. Please try again later.",
+	ErrorCodeToolLoop:            "The model kept calling tools without answering.",
+	ErrorCodeInvalidOutput:       "The model did not answer with JSON matching the response schema.",
+	ErrorCodeInternal:            "An internal error occurred while processing the prompt.",
+}
+
+// Message returns a sanitised description of the code that is safe to show to users.
+func (c ErrorCode) Message() string {
+	if msg, ok := errorMessages[c]; ok {
+		return msg
+	}
+	return errorMessages[ErrorCodeInternal]
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+var ErrInvalidGenerationOptions = errors.New("invalid generation options")
+
+// GenerationOptions tune how the model produces the response. Zero values mean
+// "use the provider default". The JSON form is the one carried in task payloads
+// and persisted with the prompt.
+type GenerationOptions struct {
+	SystemInstruction string   `json:"system_instruction,omitempty"`
+	Temperature       *float32 `json:"temperature,omitempty"`
+	TopP              *float32 `json:"top_p,omitempty"`
+	MaxOutputTokens   int32    `json:"max_output_tokens,omitempty"`
+	StopSequences     []string `json:"stop_sequences,omitempty"`
+
+	// JSONMode asks the model to answer with JSON. ResponseSchema, when set,
+	// is a JSON Schema the answer must follow and implies JSONMode.
+	JSONMode       bool            `json:"json_mode,omitempty"`
+	ResponseSchema json.RawMessage `json:"response_schema,omitempty"`
+}
+
+func (o GenerationOptions) WantsJSON() bool {
+	return o.JSONMode || len(o.ResponseSchema) > 0
+}
@@ -0,0 +1,526 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidSchema  = errors.New("invalid JSON schema")
+	ErrSchemaMismatch = errors.New("JSON does not match the schema")
+)
+
+// maxSchemaViolations bounds the violations reported for a document, enough
+// for the model to repair its answer.
+const maxSchemaViolations = 10
+
+// JSONSchema validates documents against the subset of JSON Schema the
+// providers support for structured output: type, enum, const, properties,
+// required, additionalProperties, items, the numeric, string and array bounds,
+// pattern, allOf, anyOf, oneOf, not, and local $ref. Other keywords, like
+// format, are ignored.
+type JSONSchema struct {
+	root     map[string]any
+	patterns map[string]*regexp.Regexp
+}
+
+func ParseJSONSchema(raw json.RawMessage) (*JSONSchema, error) {
+	var root map[string]any
+	if err := json.Unmarshal(raw, &root); err != nil || root == nil {
+		return nil, fmt.Errorf("%w: must be a JSON object", ErrInvalidSchema)
+	}
+
+	s := &JSONSchema{root: root, patterns: make(map[string]*regexp.Regexp)}
+	if err := s.compile(root, "#"); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// compile checks the keywords the validation relies on, so a broken schema is
+// rejected on submission rather than when the answer comes back.
+func (s *JSONSchema) compile(node map[string]any, path string) error {
+	if t, ok := node["type"]; ok {
+		types, ok := schemaTypes(t)
+		if !ok {
+			return fmt.Errorf("%w: %s/type must be a type name or a list of them", ErrInvalidSchema, path)
+		}
+		for _, name := range types {
+			if !slices.Contains([]string{"object", "array", "string", "number", "integer", "boolean", "null"}, name) {
+				return fmt.Errorf("%w: %s/type %q is unknown", ErrInvalidSchema, path, name)
+			}
+		}
+	}
+
+	if p, ok := node["pattern"]; ok {
+		pattern, ok := p.(string)
+		if !ok {
+			return fmt.Errorf("%w: %s/pattern must be a string", ErrInvalidSchema, path)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("%w: %s/pattern: %v", ErrInvalidSchema, path, err)
+		}
+		s.patterns[pattern] = re
+	}
+
+	if r, ok := node["required"]; ok {
+		if _, ok := stringList(r); !ok {
+			return fmt.Errorf("%w: %s/required must be a list of strings", ErrInvalidSchema, path)
+		}
+	}
+
+	if ref, ok := node["$ref"]; ok {
+		name, ok := ref.(string)
+		if !ok {
+			return fmt.Errorf("%w: %s/$ref must be a string", ErrInvalidSchema, path)
+		}
+		if _, err := s.resolve(name); err != nil {
+			return fmt.Errorf("%w: %s/$ref: %v", ErrInvalidSchema, path, err)
+		}
+	}
+
+	for _, keyword := range []string{"properties", "$defs", "definitions"} {
+		children, ok := node[keyword]
+		if !ok {
+			continue
+		}
+		schemas, ok := children.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%w: %s/%s must be an object", ErrInvalidSchema, path, keyword)
+		}
+		for name, child := range schemas {
+			if err := s.compileChild(child, path+"/"+keyword+"/"+name); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, keyword := range []string{"items", "not"} {
+		if child, ok := node[keyword]; ok {
+			if err := s.compileChild(child, path+"/"+keyword); err != nil {
+				return err
+			}
+		}
+	}
+	if child, ok := node["additionalProperties"]; ok {
+		if _, isBool := child.(bool); !isBool {
+			if err := s.compileChild(child, path+"/additionalProperties"); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, keyword := range []string{"allOf", "anyOf", "oneOf"} {
+		children, ok := node[keyword]
+		if !ok {
+			continue
+		}
+		list, ok := children.([]any)
+		if !ok || len(list) == 0 {
+			return fmt.Errorf("%w: %s/%s must be a non-empty list", ErrInvalidSchema, path, keyword)
+		}
+		for i, child := range list {
+			if err := s.compileChild(child, fmt.Sprintf("%s/%s/%d", path, keyword, i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *JSONSchema) compileChild(child any, path string) error {
+	node, ok := child.(map[string]any)
+	if !ok {
+		return fmt.Errorf("%w: %s must be an object", ErrInvalidSchema, path)
+	}
+
+	return s.compile(node, path)
+}
+
+// resolve follows a local reference like "#/$defs/address".
+func (s *JSONSchema) resolve(ref string) (map[string]any, error) {
+	if ref == "#" {
+		return s.root, nil
+	}
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("only local references are supported, got %q", ref)
+	}
+
+	var node any = s.root
+	for _, token := range strings.Split(ref[2:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		object, ok := node.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("reference %q does not resolve", ref)
+		}
+		if node, ok = object[token]; !ok {
+			return nil, fmt.Errorf("reference %q does not resolve", ref)
+		}
+	}
+
+	schema, ok := node.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("reference %q is not a schema", ref)
+	}
+
+	return schema, nil
+}
+
+// Validate checks the document. The error lists the violations with the path
+// of the offending values.
+func (s *JSONSchema) Validate(document json.RawMessage) error {
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("%w: not valid JSON: %v", ErrSchemaMismatch, err)
+	}
+	if decoder.More() {
+		return fmt.Errorf("%w: not valid JSON: trailing data after the document", ErrSchemaMismatch)
+	}
+
+	violations := s.validate(s.root, value, "$", 0)
+	if len(violations) == 0 {
+		return nil
+	}
+	if len(violations) > maxSchemaViolations {
+		violations = append(violations[:maxSchemaViolations], "...")
+	}
+
+	return fmt.Errorf("%w: %s", ErrSchemaMismatch, strings.Join(violations, "; "))
+}
+
+// maxRefDepth stops recursive references on self-referencing documents.
+const maxRefDepth = 64
+
+func (s *JSONSchema) validate(node map[string]any, value any, path string, depth int) []string {
+	if ref, ok := node["$ref"].(string); ok {
+		if depth >= maxRefDepth {
+			return []string{path + ": schema references nest too deep"}
+		}
+		target, err := s.resolve(ref)
+		if err != nil {
+			return []string{path + ": " + err.Error()}
+		}
+		return s.validate(target, value, path, depth+1)
+	}
+
+	if t, ok := node["type"]; ok {
+		types, _ := schemaTypes(t)
+		if !slices.ContainsFunc(types, func(name string) bool { return hasType(value, name) }) {
+			return []string{fmt.Sprintf("%s: must be of type %s, got %s", path, strings.Join(types, " or "), typeName(value))}
+		}
+	}
+
+	var violations []string
+	if enum, ok := node["enum"].([]any); ok {
+		if !slices.ContainsFunc(enum, func(v any) bool { return jsonEqual(v, value) }) {
+			violations = append(violations, fmt.Sprintf("%s: must be one of %s", path, compact(enum)))
+		}
+	}
+	if c, ok := node["const"]; ok && !jsonEqual(c, value) {
+		violations = append(violations, fmt.Sprintf("%s: must be %s", path, compact(c)))
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		violations = append(violations, s.validateObject(node, v, path, depth)...)
+	case []any:
+		violations = append(violations, s.validateArray(node, v, path, depth)...)
+	case string:
+		violations = append(violations, s.validateString(node, v, path)...)
+	case json.Number:
+		violations = append(violations, validateNumber(node, v, path)...)
+	}
+
+	if list, ok := node["allOf"].([]any); ok {
+		for _, child := range list {
+			violations = append(violations, s.validate(child.(map[string]any), value, path, depth)...)
+		}
+	}
+	if list, ok := node["anyOf"].([]any); ok {
+		if s.matches(list, value, path, depth) == 0 {
+			violations = append(violations, path+": must match at least one schema of anyOf")
+		}
+	}
+	if list, ok := node["oneOf"].([]any); ok {
+		if n := s.matches(list, value, path, depth); n != 1 {
+			violations = append(violations, fmt.Sprintf("%s: must match exactly one schema of oneOf, matches %d", path, n))
+		}
+	}
+	if not, ok := node["not"].(map[string]any); ok {
+		if len(s.validate(not, value, path, depth)) == 0 {
+			violations = append(violations, path+": must not match the schema of not")
+		}
+	}
+
+	return violations
+}
+
+func (s *JSONSchema) matches(list []any, value any, path string, depth int) int {
+	n := 0
+	for _, child := range list {
+		if len(s.validate(child.(map[string]any), value, path, depth)) == 0 {
+			n++
+		}
+	}
+
+	return n
+}
+
+func (s *JSONSchema) validateObject(node map[string]any, object map[string]any, path string, depth int) []string {
+	var violations []string
+
+	required, _ := stringList(node["required"])
+	for _, name := range required {
+		if _, ok := object[name]; !ok {
+			violations = append(violations, fmt.Sprintf("%s: property %q is required", path, name))
+		}
+	}
+
+	properties, _ := node["properties"].(map[string]any)
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		child := path + "." + name
+		if schema, ok := properties[name].(map[string]any); ok {
+			violations = append(violations, s.validate(schema, object[name], child, depth)...)
+			continue
+		}
+
+		switch additional := node["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				violations = append(violations, fmt.Sprintf("%s: property %q is not allowed", path, name))
+			}
+		case map[string]any:
+			violations = append(violations, s.validate(additional, object[name], child, depth)...)
+		}
+	}
+
+	if n, ok := bound(node, "minProperties"); ok && float64(len(object)) < n {
+		violations = append(violations, fmt.Sprintf("%s: must have at least %v properties", path, n))
+	}
+	if n, ok := bound(node, "maxProperties"); ok && float64(len(object)) > n {
+		violations = append(violations, fmt.Sprintf("%s: must have at most %v properties", path, n))
+	}
+
+	return violations
+}
+
+func (s *JSONSchema) validateArray(node map[string]any, array []any, path string, depth int) []string {
+	var violations []string
+
+	if n, ok := bound(node, "minItems"); ok && float64(len(array)) < n {
+		violations = append(violations, fmt.Sprintf("%s: must have at least %v items", path, n))
+	}
+	if n, ok := bound(node, "maxItems"); ok && float64(len(array)) > n {
+		violations = append(violations, fmt.Sprintf("%s: must have at most %v items", path, n))
+	}
+	if unique, _ := node["uniqueItems"].(bool); unique {
+		for i := range array {
+			if slices.ContainsFunc(array[:i], func(v any) bool { return jsonEqual(v, array[i]) }) {
+				violations = append(violations, fmt.Sprintf("%s[%d]: duplicates a previous item", path, i))
+			}
+		}
+	}
+
+	if items, ok := node["items"].(map[string]any); ok {
+		for i, item := range array {
+			violations = append(violations, s.validate(items, item, fmt.Sprintf("%s[%d]", path, i), depth)...)
+		}
+	}
+
+	return violations
+}
+
+func (s *JSONSchema) validateString(node map[string]any, str string, path string) []string {
+	var violations []string
+
+	length := float64(utf8.RuneCountInString(str))
+	if n, ok := bound(node, "minLength"); ok && length < n {
+		violations = append(violations, fmt.Sprintf("%s: must be at least %v characters long", path, n))
+	}
+	if n, ok := bound(node, "maxLength"); ok && length > n {
+		violations = append(violations, fmt.Sprintf("%s: must be at most %v characters long", path, n))
+	}
+	if pattern, ok := node["pattern"].(string); ok && !s.patterns[pattern].MatchString(str) {
+		violations = append(violations, fmt.Sprintf("%s: must match the pattern %q", path, pattern))
+	}
+
+	return violations
+}
+
+func validateNumber(node map[string]any, number json.Number, path string) []string {
+	var violations []string
+
+	v, _ := number.Float64()
+	if n, ok := bound(node, "minimum"); ok && v < n {
+		violations = append(violations, fmt.Sprintf("%s: must be at least %v", path, n))
+	}
+	if n, ok := bound(node, "maximum"); ok && v > n {
+		violations = append(violations, fmt.Sprintf("%s: must be at most %v", path, n))
+	}
+	if n, ok := bound(node, "exclusiveMinimum"); ok && v <= n {
+		violations = append(violations, fmt.Sprintf("%s: must be greater than %v", path, n))
+	}
+	if n, ok := bound(node, "exclusiveMaximum"); ok && v >= n {
+		violations = append(violations, fmt.Sprintf("%s: must be less than %v", path, n))
+	}
+	if n, ok := bound(node, "multipleOf"); ok && n > 0 {
+		if q := v / n; math.Abs(q-math.Round(q)) > 1e-9 {
+			violations = append(violations, fmt.Sprintf("%s: must be a multiple of %v", path, n))
+		}
+	}
+
+	return violations
+}
+
+func schemaTypes(t any) ([]string, bool) {
+	if name, ok := t.(string); ok {
+		return []string{name}, true
+	}
+
+	return stringList(t)
+}
+
+func stringList(v any) ([]string, bool) {
+	list, ok := v.([]any)
+	if !ok {
+		return nil, false
+	}
+
+	strs := make([]string, 0, len(list))
+	for _, item := range list {
+		str, ok := item.(string)
+		if !ok {
+			return nil, false
+		}
+		strs = append(strs, str)
+	}
+
+	return strs, true
+}
+
+func bound(node map[string]any, keyword string) (float64, bool) {
+	switch n := node[keyword].(type) {
+	case float64:
+		return n, true
+	case json.Number:
+		v, err := n.Float64()
+		return v, err == nil
+	}
+
+	return 0, false
+}
+
+func hasType(value any, name string) bool {
+	switch name {
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		v, err := n.Float64()
+		return err == nil && v == math.Trunc(v)
+	case "number":
+		_, ok := value.(json.Number)
+		return ok
+	}
+
+	return typeName(value) == name
+}
+
+func typeName(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number, float64:
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+
+	return fmt.Sprintf("%T", value)
+}
+
+// jsonEqual compares decoded values, numbers by value whatever their notation.
+func jsonEqual(a, b any) bool {
+	if an, ok := number(a); ok {
+		bn, ok := number(b)
+		return ok && an == bn
+	}
+
+	switch av := a.(type) {
+	case []any:
+		bv, ok := b.([]any)
+		return ok && slices.EqualFunc(av, bv, jsonEqual)
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k, v := range av {
+			if w, ok := bv[k]; !ok || !jsonEqual(v, w) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return a == b
+}
+
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+
+	return 0, false
+}
+
+func compact(v any) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// ExtractJSON returns the JSON document of a model answer, without the
+// markdown code fence models tend to wrap it in.
+func ExtractJSON(text string) json.RawMessage {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "```") && strings.HasSuffix(text, "```") && len(text) >= 6 {
+		text = strings.TrimSuffix(text[3:], "```")
+		// The language tag of the fence, if any, stays on the first line.
+		if i := strings.IndexByte(text, '\n'); i >= 0 && !strings.ContainsAny(text[:i], "{[\"") {
+			text = text[i+1:]
+		}
+		text = strings.TrimSpace(text)
+	}
+
+	return json.RawMessage(text)
+}
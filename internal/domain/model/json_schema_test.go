@@ -0,0 +1,177 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseJSONSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		wantErr bool
+	}{
+		{name: "empty schema", schema: `{}`},
+		{name: "nested schemas", schema: `{"type": "object", "properties": {"tags": {"type": "array", "items": {"type": "string"}}}}`},
+		{name: "local reference", schema: `{"$defs": {"id": {"type": "integer"}}, "properties": {"id": {"$ref": "#/$defs/id"}}}`},
+		{name: "unknown keyword", schema: `{"format": "email"}`},
+		{name: "not an object", schema: `[]`, wantErr: true},
+		{name: "not JSON", schema: `{`, wantErr: true},
+		{name: "null", schema: `null`, wantErr: true},
+		{name: "unknown type", schema: `{"type": "date"}`, wantErr: true},
+		{name: "type not a name", schema: `{"type": 1}`, wantErr: true},
+		{name: "invalid pattern", schema: `{"pattern": "("}`, wantErr: true},
+		{name: "required not strings", schema: `{"required": [1]}`, wantErr: true},
+		{name: "properties not an object", schema: `{"properties": []}`, wantErr: true},
+		{name: "invalid nested schema", schema: `{"items": {"type": "date"}}`, wantErr: true},
+		{name: "empty anyOf", schema: `{"anyOf": []}`, wantErr: true},
+		{name: "remote reference", schema: `{"$ref": "https://example.com/schema.json"}`, wantErr: true},
+		{name: "dangling reference", schema: `{"$ref": "#/$defs/missing"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseJSONSchema(json.RawMessage(tt.schema))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidSchema) {
+					t.Fatalf("ParseJSONSchema(%s) error = %v, want %v", tt.schema, err, ErrInvalidSchema)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseJSONSchema(%s) unexpected error: %v", tt.schema, err)
+			}
+		})
+	}
+}
+
+func TestJSONSchemaValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		document string
+		// violation is a part of the expected error, none when empty.
+		violation string
+	}{
+		{name: "type object", schema: `{"type": "object"}`, document: `{}`},
+		{name: "type object mismatch", schema: `{"type": "object"}`, document: `[]`, violation: "$: must be of type object, got array"},
+		{name: "type integer", schema: `{"type": "integer"}`, document: `4.0`},
+		{name: "type integer mismatch", schema: `{"type": "integer"}`, document: `4.5`, violation: "must be of type integer"},
+		{name: "type null", schema: `{"type": "null"}`, document: `null`},
+		{name: "type list", schema: `{"type": ["string", "null"]}`, document: `null`},
+		{name: "type list mismatch", schema: `{"type": ["string", "null"]}`, document: `true`, violation: "must be of type string or null, got boolean"},
+
+		{name: "enum", schema: `{"enum": ["red", 1]}`, document: `1.0`},
+		{name: "enum mismatch", schema: `{"enum": ["red", 1]}`, document: `"blue"`, violation: `must be one of ["red",1]`},
+		{name: "const", schema: `{"const": {"a": [1, 2]}}`, document: `{"a": [1, 2]}`},
+		{name: "const mismatch", schema: `{"const": {"a": [1, 2]}}`, document: `{"a": [2, 1]}`, violation: "must be {\"a\":[1,2]}"},
+
+		{name: "properties", schema: `{"properties": {"age": {"type": "integer"}}}`, document: `{"age": 3}`},
+		{name: "properties mismatch", schema: `{"properties": {"age": {"type": "integer"}}}`, document: `{"age": "3"}`, violation: "$.age: must be of type integer"},
+		{name: "required", schema: `{"required": ["name"]}`, document: `{"name": ""}`},
+		{name: "required missing", schema: `{"required": ["name"]}`, document: `{}`, violation: `property "name" is required`},
+		{name: "additionalProperties false", schema: `{"properties": {"a": {}}, "additionalProperties": false}`, document: `{"a": 1}`},
+		{name: "additionalProperties false mismatch", schema: `{"properties": {"a": {}}, "additionalProperties": false}`, document: `{"a": 1, "b": 2}`, violation: `property "b" is not allowed`},
+		{name: "additionalProperties schema", schema: `{"additionalProperties": {"type": "string"}}`, document: `{"b": 2}`, violation: "$.b: must be of type string"},
+		{name: "minProperties", schema: `{"minProperties": 2}`, document: `{"a": 1}`, violation: "must have at least 2 properties"},
+		{name: "maxProperties", schema: `{"maxProperties": 1}`, document: `{"a": 1, "b": 2}`, violation: "must have at most 1 properties"},
+
+		{name: "items", schema: `{"items": {"type": "number"}}`, document: `[1, 2.5]`},
+		{name: "items mismatch", schema: `{"items": {"type": "number"}}`, document: `[1, "2"]`, violation: "$[1]: must be of type number"},
+		{name: "minItems", schema: `{"minItems": 1}`, document: `[]`, violation: "must have at least 1 items"},
+		{name: "maxItems", schema: `{"maxItems": 1}`, document: `[1, 2]`, violation: "must have at most 1 items"},
+		{name: "uniqueItems", schema: `{"uniqueItems": true}`, document: `[1, "1"]`},
+		{name: "uniqueItems mismatch", schema: `{"uniqueItems": true}`, document: `[1, 1.0]`, violation: "$[1]: duplicates a previous item"},
+
+		{name: "minimum", schema: `{"minimum": 1}`, document: `1`},
+		{name: "minimum mismatch", schema: `{"minimum": 1}`, document: `0.5`, violation: "must be at least 1"},
+		{name: "maximum", schema: `{"maximum": 1}`, document: `2`, violation: "must be at most 1"},
+		{name: "exclusiveMinimum", schema: `{"exclusiveMinimum": 1}`, document: `1`, violation: "must be greater than 1"},
+		{name: "exclusiveMaximum", schema: `{"exclusiveMaximum": 1}`, document: `1`, violation: "must be less than 1"},
+		{name: "multipleOf", schema: `{"multipleOf": 0.1}`, document: `0.3`},
+		{name: "multipleOf mismatch", schema: `{"multipleOf": 2}`, document: `3`, violation: "must be a multiple of 2"},
+
+		{name: "minLength counts characters", schema: `{"minLength": 2}`, document: `"é"`, violation: "must be at least 2 characters long"},
+		{name: "maxLength", schema: `{"maxLength": 2}`, document: `"abc"`, violation: "must be at most 2 characters long"},
+		{name: "pattern", schema: `{"pattern": "^[a-z]+$"}`, document: `"abc"`},
+		{name: "pattern mismatch", schema: `{"pattern": "^[a-z]+$"}`, document: `"ABC"`, violation: `must match the pattern "^[a-z]+$"`},
+
+		{name: "allOf", schema: `{"allOf": [{"minimum": 1}, {"maximum": 3}]}`, document: `4`, violation: "must be at most 3"},
+		{name: "anyOf", schema: `{"anyOf": [{"type": "string"}, {"type": "integer"}]}`, document: `1`},
+		{name: "anyOf mismatch", schema: `{"anyOf": [{"type": "string"}, {"type": "integer"}]}`, document: `true`, violation: "must match at least one schema of anyOf"},
+		{name: "oneOf", schema: `{"oneOf": [{"type": "integer"}, {"type": "string"}]}`, document: `"a"`},
+		{name: "oneOf mismatch", schema: `{"oneOf": [{"type": "integer"}, {"type": "number"}]}`, document: `1`, violation: "must match exactly one schema of oneOf, matches 2"},
+		{name: "not", schema: `{"not": {"type": "null"}}`, document: `null`, violation: "must not match the schema of not"},
+
+		{name: "reference", schema: `{"$defs": {"id": {"type": "integer"}}, "items": {"$ref": "#/$defs/id"}}`, document: `[1, "2"]`, violation: "$[1]: must be of type integer"},
+		{
+			name:      "recursive reference",
+			schema:    `{"type": "object", "properties": {"children": {"type": "array", "items": {"$ref": "#"}}}, "additionalProperties": false}`,
+			document:  `{"children": [{"children": [{"name": "leaf"}]}]}`,
+			violation: `$.children[0].children[0]: property "name" is not allowed`,
+		},
+		{name: "ignored keyword", schema: `{"format": "email"}`, document: `"not an email"`},
+
+		{name: "not JSON", schema: `{}`, document: `{"a": `, violation: "not valid JSON"},
+		{name: "trailing data", schema: `{}`, document: `{} {}`, violation: "trailing data after the document"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := ParseJSONSchema(json.RawMessage(tt.schema))
+			if err != nil {
+				t.Fatalf("ParseJSONSchema(%s) unexpected error: %v", tt.schema, err)
+			}
+
+			err = schema.Validate(json.RawMessage(tt.document))
+			if tt.violation == "" {
+				if err != nil {
+					t.Fatalf("Validate(%s) unexpected error: %v", tt.document, err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrSchemaMismatch) || !strings.Contains(err.Error(), tt.violation) {
+				t.Fatalf("Validate(%s) error = %v, want %q", tt.document, err, tt.violation)
+			}
+		})
+	}
+}
+
+func TestJSONSchemaValidateBoundsViolations(t *testing.T) {
+	schema, err := ParseJSONSchema(json.RawMessage(`{"items": {"type": "string"}}`))
+	if err != nil {
+		t.Fatalf("ParseJSONSchema unexpected error: %v", err)
+	}
+
+	err = schema.Validate(json.RawMessage(`[1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12]`))
+	if got := strings.Count(err.Error(), "must be of type string"); got != maxSchemaViolations {
+		t.Fatalf("Validate reported %d violations, want %d", got, maxSchemaViolations)
+	}
+	if !strings.HasSuffix(err.Error(), "; ...") {
+		t.Fatalf("Validate error = %v, want the truncation marker", err)
+	}
+}
+
+func TestExtractJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "bare document", text: ` {"a": 1} `, want: `{"a": 1}`},
+		{name: "fenced", text: "```\n{\"a\": 1}\n```", want: `{"a": 1}`},
+		{name: "fenced with a language", text: "```json\n[1, 2]\n```", want: `[1, 2]`},
+		{name: "fenced on one line", text: "```{\"a\": 1}```", want: `{"a": 1}`},
+		{name: "unterminated fence", text: "```json\n{}", want: "```json\n{}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(ExtractJSON(tt.text)); got != tt.want {
+				t.Fatalf("ExtractJSON(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"maps"
+	"time"
+)
+
+var (
+	ErrPipelineNotFound = errors.New("pipeline not found")
+	ErrInvalidPipeline  = errors.New("invalid pipeline")
+)
+
+type PipelineStatus string
+
+var (
+	PipelineProcessing PipelineStatus = "Processing"
+	PipelineCompleted  PipelineStatus = "Completed"
+	PipelineFailed     PipelineStatus = "Failed"
+)
+
+type StepStatus string
+
+var (
+	StepPending   StepStatus = "Pending"
+	StepRunning   StepStatus = "Running"
+	StepCompleted StepStatus = "Completed"
+	StepFailed    StepStatus = "Failed"
+	StepSkipped   StepStatus = "Skipped"
+)
+
+// Pipeline chains prompts: a step is submitted once the steps it depends on
+// are completed, and its template may use their responses. The pipeline fails
+// with its first failed step, the steps left are skipped.
+type Pipeline struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Status    PipelineStatus
+	Priority  Priority
+	Variables map[string]any
+	Steps     []PipelineStep
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// PipelineStep renders its prompt either from the inline Body or from a stored
+// template. Both see the pipeline variables, and the responses of the completed
+// steps under .steps, such as {{.steps.research}}.
+type PipelineStep struct {
+	Name      string
+	ModelID   string
+	Body      string
+	Template  *TemplateRef
+	Options   GenerationOptions
+	DependsOn []string
+	Status    StepStatus
+
+	// The prompt of the step, and its result once processed.
+	PromptID  *uuid.UUID
+	Response  string
+	Error     string
+	ErrorCode ErrorCode
+}
+
+// PipelineRef places a prompt in its pipeline.
+type PipelineRef struct {
+	ID   uuid.UUID
+	Step string
+}
+
+// Validate checks the steps form a directed acyclic graph of valid templates.
+func (p *Pipeline) Validate() error {
+	if len(p.Steps) == 0 {
+		return fmt.Errorf("%w: no steps", ErrInvalidPipeline)
+	}
+
+	steps := make(map[string]*PipelineStep, len(p.Steps))
+	for i := range p.Steps {
+		step := &p.Steps[i]
+		if step.Name == "" {
+			return fmt.Errorf("%w: step %d has no name", ErrInvalidPipeline, i)
+		}
+		if _, ok := steps[step.Name]; ok {
+			return fmt.Errorf("%w: step %q is defined twice", ErrInvalidPipeline, step.Name)
+		}
+		if (step.Body == "") == (step.Template == nil) {
+			return fmt.Errorf("%w: step %q needs either a prompt or a template", ErrInvalidPipeline, step.Name)
+		}
+		if step.Body != "" {
+			t := Template{Name: step.Name, Body: step.Body}
+			if _, err := t.Parse(); err != nil {
+				return fmt.Errorf("%w: step %q: %w", ErrInvalidPipeline, step.Name, err)
+			}
+		}
+		steps[step.Name] = step
+	}
+
+	for _, step := range p.Steps {
+		for _, dep := range step.DependsOn {
+			if _, ok := steps[dep]; !ok || dep == step.Name {
+				return fmt.Errorf("%w: step %q depends on an unknown step %q", ErrInvalidPipeline, step.Name, dep)
+			}
+		}
+	}
+
+	// Every step gets resolved in dependency order unless there is a cycle.
+	resolved := make(map[string]bool, len(p.Steps))
+	for len(resolved) < len(p.Steps) {
+		progress := false
+		for _, step := range p.Steps {
+			if resolved[step.Name] || !p.dependenciesIn(step, resolved) {
+				continue
+			}
+			resolved[step.Name] = true
+			progress = true
+		}
+		if !progress {
+			return fmt.Errorf("%w: the steps depend on each other in a cycle", ErrInvalidPipeline)
+		}
+	}
+
+	return nil
+}
+
+// Ready returns the pending steps whose dependencies are all completed.
+func (p *Pipeline) Ready() []*PipelineStep {
+	completed := make(map[string]bool, len(p.Steps))
+	for _, step := range p.Steps {
+		completed[step.Name] = step.Status == StepCompleted
+	}
+
+	var ready []*PipelineStep
+	for i := range p.Steps {
+		step := &p.Steps[i]
+		if step.Status == StepPending && p.dependenciesIn(*step, completed) {
+			ready = append(ready, step)
+		}
+	}
+
+	return ready
+}
+
+func (p *Pipeline) dependenciesIn(step PipelineStep, names map[string]bool) bool {
+	for _, dep := range step.DependsOn {
+		if !names[dep] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Done tells whether every step is completed.
+func (p *Pipeline) Done() bool {
+	for _, step := range p.Steps {
+		if step.Status != StepCompleted {
+			return false
+		}
+	}
+
+	return true
+}
+
+// StepVariables returns the variables a step is rendered with.
+func (p *Pipeline) StepVariables() map[string]any {
+	variables := maps.Clone(p.Variables)
+	if variables == nil {
+		variables = map[string]any{}
+	}
+
+	responses := make(map[string]any, len(p.Steps))
+	for _, step := range p.Steps {
+		if step.Status == StepCompleted {
+			responses[step.Name] = step.Response
+		}
+	}
+	variables["steps"] = responses
+
+	return variables
+}
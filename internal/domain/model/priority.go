@@ -0,0 +1,36 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidPriority = errors.New("invalid priority")
+
+// Priority is the class of service of a prompt. Every class is processed from
+// its own stream, so bulk work does not delay interactive prompts.
+type Priority string
+
+const (
+	PriorityHigh   Priority = "high"
+	PriorityNormal Priority = "normal"
+	PriorityLow    Priority = "low"
+)
+
+// Priorities lists the classes from the most to the least urgent.
+var Priorities = []Priority{PriorityHigh, PriorityNormal, PriorityLow}
+
+// ParsePriority validates the priority requested by a client, an empty one meaning normal.
+func ParsePriority(s string) (Priority, error) {
+	if s == "" {
+		return PriorityNormal, nil
+	}
+
+	for _, p := range Priorities {
+		if Priority(s) == p {
+			return p, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %q, expected one of high, normal, low", ErrInvalidPriority, s)
+}
@@ -1,6 +1,17 @@
 package model
 
-import "github.com/google/uuid"
+import (
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"time"
+)
+
+var (
+	ErrPromptNotFound       = errors.New("prompt not found")
+	ErrPromptNotCancellable = errors.New("prompt is already processed")
+	ErrInvalidTimeout       = errors.New("invalid timeout")
+)
 
 type Status string
 
@@ -12,11 +23,47 @@ var (
 )
 
 type Prompt struct {
-	ID       uuid.UUID
-	UserID   uuid.UUID
-	ModelID  string
-	Text     string
-	Response string
-	Status   Status
-	Error    string
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	ModelID   string
+	Text      string
+	Response  string
+	Status    Status
+	Error     string
+	ErrorCode ErrorCode
+	Options   GenerationOptions
+	Priority  Priority
+
+	// ServedModelID is the model that answered, another one than ModelID when
+	// the prompt fell back to a model of its fallback chain.
+	ServedModelID string
+
+	Attachments []Attachment
+
+	// StructuredOutput is the JSON document of the response, for prompts with
+	// a response schema. It is set only once the response matches the schema.
+	StructuredOutput json.RawMessage
+
+	// Tools lists the built-in tools the model may call, ToolCalls records the calls it made.
+	Tools     []string
+	ToolCalls []ToolCall
+
+	// Cached tells the response was served from the response cache. BypassCache
+	// forces a fresh response and is not persisted.
+	Cached      bool
+	BypassCache bool
+
+	Batch    *BatchRef
+	Pipeline *PipelineRef
+
+	// RunAt delays the processing of the prompt. ScheduleID links the prompt to the schedule that fired it.
+	RunAt      time.Time
+	ScheduleID *uuid.UUID
+
+	// Template is set when the text is rendered from a template, the version is resolved on submission.
+	Template *TemplateRef
+
+	// Timeout is the processing time requested by the client, resolved into Deadline on submission.
+	Timeout  time.Duration
+	Deadline time.Time
 }
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"time"
+)
+
+var ErrScheduleNotFound = errors.New("schedule not found")
+
+// Schedule submits a fresh prompt every time its cron expression fires. The
+// prompt is kept unresolved, so a template without a version renders its
+// latest version at every firing.
+type Schedule struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Cron      string
+	Prompt    Prompt
+	NextRunAt time.Time
+	LastRunAt *time.Time
+	CreatedAt time.Time
+}
+
+// NewPrompt creates the prompt of a firing.
+func (s *Schedule) NewPrompt() Prompt {
+	prompt := Prompt{
+		ID:         uuid.New(),
+		UserID:     s.UserID,
+		ModelID:    s.Prompt.ModelID,
+		Text:       s.Prompt.Text,
+		Options:    s.Prompt.Options,
+		Priority:   s.Prompt.Priority,
+		Timeout:    s.Prompt.Timeout,
+		ScheduleID: &s.ID,
+	}
+	if ref := s.Prompt.Template; ref != nil {
+		prompt.Template = &TemplateRef{
+			ID:        ref.ID,
+			Version:   ref.Version,
+			Variables: ref.Variables,
+		}
+	}
+
+	return prompt
+}
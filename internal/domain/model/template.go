@@ -0,0 +1,60 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var (
+	ErrTemplateNotFound = errors.New("template not found")
+	ErrInvalidTemplate  = errors.New("invalid template")
+	ErrTemplateRender   = errors.New("failed to render template")
+	ErrTemplateConflict = errors.New("template was updated concurrently")
+)
+
+// Template is a reusable prompt with text/template placeholders. Every update
+// creates a new version, so prompts keep pointing at the text they were rendered from.
+// Any user may render a template, only its owner may update or delete it.
+type Template struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Version   int
+	Name      string
+	Body      string
+	CreatedAt time.Time
+}
+
+// Parse checks the body and prepares it for rendering. Variables missing at render time are an error.
+func (t *Template) Parse() (*template.Template, error) {
+	parsed, err := template.New(t.Name).Option("missingkey=error").Parse(t.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTemplate, err)
+	}
+
+	return parsed, nil
+}
+
+func (t *Template) Render(variables map[string]any) (string, error) {
+	parsed, err := t.Parse()
+	if err != nil {
+		return "", err
+	}
+
+	var text strings.Builder
+	if err = parsed.Execute(&text, variables); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrTemplateRender, err)
+	}
+
+	return text.String(), nil
+}
+
+// TemplateRef points at the template a prompt is rendered from. A zero Version means the latest one.
+type TemplateRef struct {
+	ID        uuid.UUID
+	Version   int
+	Variables map[string]any
+}
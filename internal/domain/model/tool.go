@@ -0,0 +1,49 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var ErrInvalidTools = errors.New("invalid tools")
+
+// Built-in tools a prompt may let the model call. Workers only declare the
+// ones they are configured for.
+const (
+	ToolHTTPFetch  = "http_fetch"
+	ToolCalculator = "calculator"
+	ToolDBLookup   = "db_lookup"
+)
+
+var Tools = []string{ToolHTTPFetch, ToolCalculator, ToolDBLookup}
+
+// ValidateTools checks the names requested for a prompt.
+func ValidateTools(names []string) error {
+	for i, name := range names {
+		if !slices.Contains(Tools, name) {
+			return fmt.Errorf("%w: unknown tool %q, expected one of %v", ErrInvalidTools, name, Tools)
+		}
+		if slices.Contains(names[:i], name) {
+			return fmt.Errorf("%w: tool %q is listed twice", ErrInvalidTools, name)
+		}
+	}
+
+	return nil
+}
+
+// ToolCall is a call the model made during the generation of a prompt, along
+// with the result sent back to it. Round orders the calls: the calls of a
+// round are requested by the same model response.
+type ToolCall struct {
+	ID        string          `json:"id,omitempty"`
+	Round     int             `json:"round"`
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+	Output    string          `json:"output,omitempty"`
+	Error     string          `json:"error,omitempty"`
+
+	// Signature is opaque provider state that must be replayed along with the call.
+	Signature []byte `json:"signature,omitempty"`
+}
@@ -2,22 +2,33 @@ package gemini
 
 import (
 	"ai-orchestrator/internal/common/logger"
-	"ai-orchestrator/internal/infra/manager"
+	"ai-orchestrator/internal/domain/gateway"
+	"ai-orchestrator/internal/domain/model"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"google.golang.org/api/googleapi"
 	"google.golang.org/genai"
 	"net/http"
+	"reflect"
+	"strconv"
+	"time"
 )
 
+var (
+	ErrContentBlocked    = errors.New("content blocked by safety filters")
+	ErrModelNotSpecified = errors.New("model is not specified")
+)
+
+// Client makes a single call per Generate, the router retries it under the
+// backoff and the quota.
 type Client struct {
 	logger logger.Logger
 	client *genai.Client
-
-	backoff manager.Backoff
 }
 
-func NewClient(l logger.Logger, client *genai.Client, backoff manager.Backoff) (*Client, error) {
+func NewClient(l logger.Logger, client *genai.Client) (*Client, error) {
 	if l == nil {
 		return nil, logger.ErrNilLogger
 	}
@@ -26,58 +37,318 @@ func NewClient(l logger.Logger, client *genai.Client, backoff manager.Backoff) (
 	}
 
 	return &Client{
-		logger:  l,
-		client:  client,
-		backoff: backoff,
+		logger: l,
+		client: client,
 	}, nil
 }
 
-func (c *Client) Generate(ctx context.Context, model, prompt string) (string, error) {
-	if model == "" {
-		model = "gemini-3-flash-preview"
+func (c *Client) Generate(ctx context.Context, request gateway.Request) (gateway.Response, error) {
+	modelID := request.Model
+	if modelID == "" {
+		return gateway.Response{}, gateway.NewProviderError(model.ErrorCodeInvalidRequest, ErrModelNotSpecified)
 	}
 
-	res, err := manager.WithBackoff[*genai.GenerateContentResponse](
-		ctx,
-		&c.backoff,
-		func(ctx context.Context) (*genai.GenerateContentResponse, error) {
-			return c.client.Models.GenerateContent(
-				ctx,
-				model,
-				genai.Text(prompt),
-				nil,
-			)
-		},
-		IsRetryable)
+	config, err := buildConfig(request.Options, request.Tools)
+	if err != nil {
+		c.logger.ErrorContext(ctx, "Failed to build generation config.", "err", err, "model", modelID)
+		return gateway.Response{}, mapError(err)
+	}
 
+	contents, err := buildContents(request)
+	if err != nil {
+		c.logger.ErrorContext(ctx, "Failed to build contents.", "err", err, "model", modelID)
+		return gateway.Response{}, mapError(err)
+	}
+
+	res, err := c.client.Models.GenerateContent(
+		ctx,
+		modelID,
+		contents,
+		config,
+	)
+	if err == nil {
+		err = blockedError(res)
+	}
 	if err != nil {
-		c.logger.ErrorContext(ctx, "Prompt to model failed.", "err", err, "model", model)
-		return "", err
+		c.logger.ErrorContext(ctx, "Prompt to model failed.", "err", err, "model", modelID)
+		return gateway.Response{}, mapError(withRetryDelay(err))
+	}
+
+	if calls := toolCalls(res); len(calls) > 0 {
+		c.logger.DebugContext(ctx, "Model requested tool calls.", "calls", len(calls))
+		return gateway.Response{ToolCalls: calls}, nil
 	}
 
 	c.logger.DebugContext(ctx, "Prompt to model completed.", "response", res.Text())
-	return res.Text(), nil
+	return gateway.Response{Text: res.Text()}, nil
 }
 
-func IsRetryable(err error) bool {
+// buildContents sends the attachments as inline parts preceding the prompt
+// text, followed by the tool calls made so far: for every round, the calls of
+// the model then their results.
+func buildContents(request gateway.Request) ([]*genai.Content, error) {
+	parts := make([]*genai.Part, 0, len(request.Attachments)+1)
+	for _, a := range request.Attachments {
+		parts = append(parts, genai.NewPartFromBytes(a.Data, a.MimeType))
+	}
+	parts = append(parts, genai.NewPartFromText(request.Prompt))
+
+	contents := []*genai.Content{genai.NewContentFromParts(parts, genai.RoleUser)}
+
+	calls := request.ToolCalls
+	for len(calls) > 0 {
+		round := calls[0].Round
+		end := 1
+		for end < len(calls) && calls[end].Round == round {
+			end++
+		}
+
+		callParts := make([]*genai.Part, 0, end)
+		resultParts := make([]*genai.Part, 0, end)
+		for _, call := range calls[:end] {
+			var args map[string]any
+			if len(call.Arguments) > 0 {
+				if err := json.Unmarshal(call.Arguments, &args); err != nil {
+					return nil, gateway.NewProviderError(model.ErrorCodeInternal, fmt.Errorf("decode tool call arguments: %w", err))
+				}
+			}
+
+			callParts = append(callParts, &genai.Part{
+				FunctionCall:     &genai.FunctionCall{ID: call.ID, Name: call.Name, Args: args},
+				ThoughtSignature: call.Signature,
+			})
+
+			result := map[string]any{"output": call.Output}
+			if call.Error != "" {
+				result = map[string]any{"error": call.Error}
+			}
+			resultParts = append(resultParts, &genai.Part{
+				FunctionResponse: &genai.FunctionResponse{ID: call.ID, Name: call.Name, Response: result},
+			})
+		}
+
+		contents = append(contents,
+			genai.NewContentFromParts(callParts, genai.RoleModel),
+			genai.NewContentFromParts(resultParts, genai.RoleUser),
+		)
+		calls = calls[end:]
+	}
+
+	return contents, nil
+}
+
+// toolCalls returns the function calls of the response, keeping the thought
+// signatures the model expects back with them.
+func toolCalls(res *genai.GenerateContentResponse) []model.ToolCall {
+	if res == nil || len(res.Candidates) == 0 || res.Candidates[0] == nil || res.Candidates[0].Content == nil {
+		return nil
+	}
+
+	var calls []model.ToolCall
+	for _, part := range res.Candidates[0].Content.Parts {
+		if part == nil || part.FunctionCall == nil {
+			continue
+		}
+
+		arguments, _ := json.Marshal(part.FunctionCall.Args)
+		calls = append(calls, model.ToolCall{
+			ID:        part.FunctionCall.ID,
+			Name:      part.FunctionCall.Name,
+			Arguments: arguments,
+			Signature: part.ThoughtSignature,
+		})
+	}
+
+	return calls
+}
+
+// buildConfig translates domain generation options and tools into the SDK config.
+// It returns nil when nothing is set, so the model defaults apply.
+func buildConfig(options model.GenerationOptions, tools []gateway.Tool) (*genai.GenerateContentConfig, error) {
+	config := &genai.GenerateContentConfig{
+		Temperature:     options.Temperature,
+		TopP:            options.TopP,
+		MaxOutputTokens: options.MaxOutputTokens,
+		StopSequences:   options.StopSequences,
+	}
+
+	if options.SystemInstruction != "" {
+		config.SystemInstruction = genai.NewContentFromText(options.SystemInstruction, genai.RoleUser)
+	}
+
+	if options.WantsJSON() {
+		config.ResponseMIMEType = "application/json"
+	}
+	if len(options.ResponseSchema) > 0 {
+		var schema any
+		if err := json.Unmarshal(options.ResponseSchema, &schema); err != nil {
+			return nil, gateway.NewProviderError(model.ErrorCodeInvalidRequest, fmt.Errorf("decode response schema: %w", err))
+		}
+		config.ResponseJsonSchema = schema
+	}
+
+	if len(tools) > 0 {
+		declarations := make([]*genai.FunctionDeclaration, 0, len(tools))
+		for _, tool := range tools {
+			var parameters any
+			if err := json.Unmarshal(tool.Parameters, &parameters); err != nil {
+				return nil, gateway.NewProviderError(model.ErrorCodeInternal, fmt.Errorf("decode parameters of tool %q: %w", tool.Name, err))
+			}
+			declarations = append(declarations, &genai.FunctionDeclaration{
+				Name:                 tool.Name,
+				Description:          tool.Description,
+				ParametersJsonSchema: parameters,
+			})
+		}
+		config.Tools = []*genai.Tool{{FunctionDeclarations: declarations}}
+	}
+
+	if reflect.ValueOf(*config).IsZero() {
+		return nil, nil
+	}
+	return config, nil
+}
+
+// retryDelayError carries the delay the provider asked to wait before
+// retrying, for the backoff to honour it.
+type retryDelayError struct {
+	err   error
+	delay time.Duration
+}
+
+func (e *retryDelayError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryDelayError) Unwrap() error {
+	return e.err
+}
+
+func (e *retryDelayError) RetryAfter() time.Duration {
+	return e.delay
+}
+
+// withRetryDelay attaches the delay of a google.rpc.RetryInfo detail, or of a
+// Retry-After header, to the error.
+func withRetryDelay(err error) error {
 	if err == nil {
-		return false
+		return nil
+	}
+	if delay, ok := retryDelay(err); ok {
+		return &retryDelayError{err: err, delay: delay}
+	}
+
+	return err
+}
+
+func retryDelay(err error) (time.Duration, bool) {
+	var details []map[string]any
+	var apiErr genai.APIError
+	var apiErrPtr *genai.APIError
+	switch {
+	case errors.As(err, &apiErr):
+		details = apiErr.Details
+	case errors.As(err, &apiErrPtr):
+		details = apiErrPtr.Details
+	}
+	for _, detail := range details {
+		if detail["@type"] != "type.googleapis.com/google.rpc.RetryInfo" {
+			continue
+		}
+		raw, _ := detail["retryDelay"].(string)
+		if delay, parseErr := time.ParseDuration(raw); parseErr == nil && delay > 0 {
+			return delay, true
+		}
+	}
+
+	var e *googleapi.Error
+	if errors.As(err, &e) && e.Header != nil {
+		header := e.Header.Get("Retry-After")
+		if seconds, parseErr := strconv.Atoi(header); parseErr == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if at, parseErr := http.ParseTime(header); parseErr == nil && time.Until(at) > 0 {
+			return time.Until(at), true
+		}
+	}
+
+	return 0, false
+}
+
+// statusCode extracts the HTTP status of errors returned by both the genai SDK
+// and the legacy googleapi transport.
+func statusCode(err error) (int, bool) {
+	var apiErr genai.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code, true
+	}
+
+	var apiErrPtr *genai.APIError
+	if errors.As(err, &apiErrPtr) {
+		return apiErrPtr.Code, true
 	}
 
 	var e *googleapi.Error
 	if errors.As(err, &e) {
-		switch e.Code {
-		case http.StatusTooManyRequests, // 429
-			http.StatusInternalServerError, // 500
-			http.StatusBadGateway,          // 502
-			http.StatusServiceUnavailable,  // 503
-			http.StatusGatewayTimeout:      // 504
-			return true
-		default:
-			// 400, 401, 403, 404 should NOT be retried
-			return false
+		return e.Code, true
+	}
+
+	return 0, false
+}
+
+// mapError translates SDK errors into domain error codes.
+func mapError(err error) error {
+	var providerErr *gateway.ProviderError
+	if errors.As(err, &providerErr) {
+		return err
+	}
+	if errors.Is(err, ErrContentBlocked) {
+		return gateway.NewProviderError(model.ErrorCodeContentBlocked, err)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return gateway.NewProviderError(model.ErrorCodeTimeout, err)
+	}
+
+	code, ok := statusCode(err)
+	if !ok {
+		return gateway.NewProviderError(model.ErrorCodeInternal, err)
+	}
+
+	switch {
+	case code == http.StatusTooManyRequests:
+		return gateway.NewProviderError(model.ErrorCodeRateLimited, err)
+	case code == http.StatusBadRequest, code == http.StatusNotFound:
+		return gateway.NewProviderError(model.ErrorCodeInvalidRequest, err)
+	case code == http.StatusGatewayTimeout:
+		return gateway.NewProviderError(model.ErrorCodeTimeout, err)
+	case code >= http.StatusInternalServerError:
+		return gateway.NewProviderError(model.ErrorCodeProviderUnavailable, err)
+	default:
+		// 401, 403 and the rest are misconfigurations on our side.
+		return gateway.NewProviderError(model.ErrorCodeInternal, err)
+	}
+}
+
+// blockedError reports responses that were filtered instead of answered.
+func blockedError(res *genai.GenerateContentResponse) error {
+	if res == nil {
+		return nil
+	}
+
+	if res.PromptFeedback != nil && res.PromptFeedback.BlockReason != "" {
+		return fmt.Errorf("%w: prompt blocked with reason %s", ErrContentBlocked, res.PromptFeedback.BlockReason)
+	}
+
+	if len(res.Candidates) > 0 && res.Candidates[0] != nil {
+		switch reason := res.Candidates[0].FinishReason; reason {
+		case genai.FinishReasonSafety,
+			genai.FinishReasonProhibitedContent,
+			genai.FinishReasonBlocklist,
+			genai.FinishReasonSPII,
+			genai.FinishReasonImageSafety:
+			return fmt.Errorf("%w: response finished with reason %s", ErrContentBlocked, reason)
 		}
 	}
 
-	return false
+	return nil
 }
@@ -0,0 +1,145 @@
+package ai
+
+import (
+	"ai-orchestrator/internal/common/logger"
+	"ai-orchestrator/internal/domain/gateway"
+	"ai-orchestrator/internal/domain/model"
+	"ai-orchestrator/internal/infra/manager"
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNilCatalogue = errors.New("model catalogue is nil")
+	ErrNilBreaker   = errors.New("circuit breaker is nil")
+	ErrNilLimiter   = errors.New("rate limiter is nil")
+)
+
+// Limiter paces the calls under the quotas of the providers and the models.
+type Limiter interface {
+	Wait(ctx context.Context, m model.Model, tokens int) error
+}
+
+type Catalogue interface {
+	Resolve(id string) (model.Model, error)
+	Enabled() []model.Model
+}
+
+// Router is an AIProvider that dispatches each request to the provider the
+// model is assigned to in the catalogue. Requests to a model whose circuit is
+// open fail fast, without reaching the provider; the others are retried under
+// the backoff, every attempt waiting for the quota.
+type Router struct {
+	logger    logger.Logger
+	catalogue Catalogue
+	providers map[string]gateway.AIProvider
+	breaker   *manager.CircuitBreaker
+	limiter   Limiter
+	backoff   *manager.Backoff
+}
+
+func NewRouter(l logger.Logger, catalogue Catalogue, providers map[string]gateway.AIProvider, breaker *manager.CircuitBreaker, limiter Limiter, backoff *manager.Backoff) (*Router, error) {
+	if l == nil {
+		return nil, logger.ErrNilLogger
+	}
+	if catalogue == nil {
+		return nil, ErrNilCatalogue
+	}
+	if breaker == nil {
+		return nil, ErrNilBreaker
+	}
+	if limiter == nil {
+		return nil, ErrNilLimiter
+	}
+	if backoff == nil {
+		return nil, manager.ErrNilBackoff
+	}
+
+	for name, provider := range providers {
+		if provider == nil {
+			return nil, fmt.Errorf("%w: %s", gateway.ErrNilProvider, name)
+		}
+	}
+	for _, m := range catalogue.Enabled() {
+		if _, ok := providers[m.Provider]; !ok {
+			return nil, fmt.Errorf("provider %q of model %q is not registered", m.Provider, m.ID)
+		}
+	}
+
+	return &Router{
+		logger:    l,
+		catalogue: catalogue,
+		providers: providers,
+		breaker:   breaker,
+		limiter:   limiter,
+		backoff:   backoff,
+	}, nil
+}
+
+func (r *Router) Generate(ctx context.Context, request gateway.Request) (gateway.Response, error) {
+	m, err := r.catalogue.Resolve(request.Model)
+	if err != nil {
+		r.logger.WarnContext(ctx, "Failed to route prompt.", "error", err, "model", request.Model)
+		return gateway.Response{}, gateway.NewProviderError(model.ErrorCodeInvalidRequest, err)
+	}
+
+	r.logger.DebugContext(ctx, "Routing prompt", "model", m.ID, "provider", m.Provider)
+
+	circuit := m.Provider + "/" + m.ID
+	if wait, ok := r.breaker.Allow(circuit); !ok {
+		r.logger.WarnContext(ctx, "Circuit is open, failing fast.", "circuit", circuit, "retry_after", wait)
+		err := &gateway.CircuitOpenError{Circuit: circuit, RetryAfter: wait}
+		return gateway.Response{Model: m.ID}, gateway.NewProviderError(model.ErrorCodeProviderUnavailable, err)
+	}
+
+	request.Model = m.ID
+	provider := r.providers[m.Provider]
+	tokens := EstimateTokens(request)
+
+	// Every attempt is charged to the quota, a retry is a call like any other.
+	var quotaErr error
+	res, err := manager.WithBackoff(ctx, r.backoff, func(ctx context.Context) (gateway.Response, error) {
+		if err := r.limiter.Wait(ctx, m, tokens); err != nil {
+			quotaErr = err
+			return gateway.Response{}, err
+		}
+		return provider.Generate(ctx, request)
+	}, isRetryable)
+	res.Model = m.ID
+
+	if quotaErr != nil {
+		r.breaker.Record(circuit, manager.Ignored)
+		r.logger.WarnContext(ctx, "Call exceeds the quota.", "error", quotaErr, "model", m.ID)
+		return res, gateway.NewProviderError(model.ErrorCodeRateLimited, quotaErr)
+	}
+	r.breaker.Record(circuit, outcome(err))
+
+	return res, err
+}
+
+// isRetryable retries the failures of the provider that may pass: the quota
+// errors are not, the limiter already waited as long as allowed.
+func isRetryable(err error) bool {
+	switch gateway.ErrorCodeOf(err) {
+	case model.ErrorCodeRateLimited, model.ErrorCodeProviderUnavailable, model.ErrorCodeTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// outcome counts only the failures telling the provider is unhealthy, the
+// retries are exhausted by then.
+func outcome(err error) manager.Outcome {
+	if err == nil {
+		return manager.Success
+	}
+
+	switch gateway.ErrorCodeOf(err) {
+	case model.ErrorCodeRateLimited, model.ErrorCodeProviderUnavailable:
+		return manager.Failure
+	default:
+		return manager.Ignored
+	}
+}
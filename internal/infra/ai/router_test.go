@@ -0,0 +1,127 @@
+package ai
+
+import (
+	"ai-orchestrator/internal/config/shared"
+	"ai-orchestrator/internal/domain/gateway"
+	"ai-orchestrator/internal/domain/model"
+	"ai-orchestrator/internal/infra/manager"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+var testModel = model.Model{ID: "gemini-2.5-flash", Provider: "gemini", Enabled: true}
+
+type stubCatalogue struct{}
+
+func (stubCatalogue) Resolve(string) (model.Model, error) {
+	return testModel, nil
+}
+
+func (stubCatalogue) Enabled() []model.Model {
+	return []model.Model{testModel}
+}
+
+// countingLimiter counts the calls it paces, and fails from the given one on.
+type countingLimiter struct {
+	waits  int
+	failAt int
+}
+
+func (l *countingLimiter) Wait(context.Context, model.Model, int) error {
+	l.waits++
+	if l.failAt > 0 && l.waits >= l.failAt {
+		return errors.New("quota exhausted")
+	}
+
+	return nil
+}
+
+// scriptedProvider fails with the given errors in turn, then answers.
+type scriptedProvider struct {
+	errs  []error
+	calls int
+}
+
+func (p *scriptedProvider) Generate(context.Context, gateway.Request) (gateway.Response, error) {
+	p.calls++
+	if p.calls <= len(p.errs) {
+		return gateway.Response{}, p.errs[p.calls-1]
+	}
+
+	return gateway.Response{Text: "answer"}, nil
+}
+
+func TestRouterRetries(t *testing.T) {
+	rateLimited := gateway.NewProviderError(model.ErrorCodeRateLimited, errors.New("429"))
+	invalid := gateway.NewProviderError(model.ErrorCodeInvalidRequest, errors.New("400"))
+
+	tests := []struct {
+		name      string
+		errs      []error
+		failAt    int
+		wantCalls int
+		wantWaits int
+		wantCode  model.ErrorCode
+	}{
+		{name: "answer", wantCalls: 1, wantWaits: 1},
+		{name: "every retry waits for the quota", errs: []error{rateLimited, rateLimited}, wantCalls: 3, wantWaits: 3},
+		{name: "retries exhausted", errs: []error{rateLimited, rateLimited, rateLimited}, wantCalls: 3, wantWaits: 3, wantCode: model.ErrorCodeRateLimited},
+		{name: "not retryable", errs: []error{invalid}, wantCalls: 1, wantWaits: 1, wantCode: model.ErrorCodeInvalidRequest},
+		{name: "quota exhausted on a retry", errs: []error{rateLimited}, failAt: 2, wantCalls: 1, wantWaits: 2, wantCode: model.ErrorCodeRateLimited},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &scriptedProvider{errs: tt.errs}
+			limiter := &countingLimiter{failAt: tt.failAt}
+			router := newTestRouter(t, provider, limiter)
+
+			res, err := router.Generate(context.Background(), gateway.Request{Prompt: "prompt"})
+			if provider.calls != tt.wantCalls || limiter.waits != tt.wantWaits {
+				t.Fatalf("provider called %d times and limiter %d times, want %d and %d", provider.calls, limiter.waits, tt.wantCalls, tt.wantWaits)
+			}
+			if res.Model != testModel.ID {
+				t.Fatalf("Model = %q, want %q", res.Model, testModel.ID)
+			}
+			if tt.wantCode == "" {
+				if err != nil || res.Text != "answer" {
+					t.Fatalf("Generate = %v, %v, want the answer", res, err)
+				}
+				return
+			}
+			if code := gateway.ErrorCodeOf(err); code != tt.wantCode {
+				t.Fatalf("Generate error = %v (%s), want %s", err, code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func newTestRouter(t *testing.T, provider gateway.AIProvider, limiter Limiter) *Router {
+	t.Helper()
+
+	l := slog.New(slog.DiscardHandler)
+	breaker, err := manager.NewCircuitBreaker(l, &shared.CircuitBreakerConfig{
+		Window:           time.Minute,
+		MinRequests:      100,
+		FailureRate:      0.5,
+		OpenTimeout:      time.Minute,
+		HalfOpenRequests: 1,
+	})
+	if err != nil {
+		t.Fatalf("NewCircuitBreaker unexpected error: %v", err)
+	}
+	backoff, err := manager.NewBackoff(l, &shared.BackoffConfig{Min: time.Millisecond, Max: time.Millisecond, Factor: 2, Jitter: manager.JitterFull, MaxRetries: 3})
+	if err != nil {
+		t.Fatalf("NewBackoff unexpected error: %v", err)
+	}
+
+	router, err := NewRouter(l, stubCatalogue{}, map[string]gateway.AIProvider{"gemini": provider}, breaker, limiter, backoff)
+	if err != nil {
+		t.Fatalf("NewRouter unexpected error: %v", err)
+	}
+
+	return router
+}
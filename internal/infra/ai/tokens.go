@@ -0,0 +1,34 @@
+package ai
+
+import (
+	"ai-orchestrator/internal/domain/gateway"
+	"unicode/utf8"
+)
+
+const (
+	// charsPerToken is the usual ratio of English text, a rough estimate for the others.
+	charsPerToken = 4
+	// attachmentTokens is what Gemini bills for an image, files are estimated alike.
+	attachmentTokens = 258
+	// defaultOutputTokens is assumed when the request does not bound the output.
+	defaultOutputTokens = 1024
+)
+
+// EstimateTokens estimates the tokens a request consumes from the quota: the
+// prompt with everything replayed along with it, and the expected output.
+func EstimateTokens(request gateway.Request) int {
+	chars := utf8.RuneCountInString(request.Prompt) + utf8.RuneCountInString(request.Options.SystemInstruction)
+	for _, call := range request.ToolCalls {
+		chars += len(call.Arguments) + utf8.RuneCountInString(call.Output) + utf8.RuneCountInString(call.Error)
+	}
+	for _, tool := range request.Tools {
+		chars += len(tool.Description) + len(tool.Parameters)
+	}
+
+	tokens := (chars+charsPerToken-1)/charsPerToken + len(request.Attachments)*attachmentTokens
+	if request.Options.MaxOutputTokens > 0 {
+		return tokens + int(request.Options.MaxOutputTokens)
+	}
+
+	return tokens + defaultOutputTokens
+}
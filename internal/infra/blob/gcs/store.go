@@ -0,0 +1,88 @@
+package gcs
+
+import (
+	"ai-orchestrator/internal/common/logger"
+	"ai-orchestrator/internal/domain/gateway"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"google.golang.org/api/googleapi"
+	storage "google.golang.org/api/storage/v1"
+	"io"
+	"net/http"
+)
+
+var ErrInvalidKey = errors.New("invalid blob key")
+
+// Store keeps blobs as objects of a Cloud Storage bucket, shared by the API
+// and the workers running as separate services. Credentials come from the
+// environment, i.e. the service account of the Cloud Run service.
+type Store struct {
+	logger  logger.Logger
+	objects *storage.ObjectsService
+	bucket  string
+}
+
+func NewStore(ctx context.Context, l logger.Logger, bucket string) (*Store, error) {
+	if l == nil {
+		return nil, logger.ErrNilLogger
+	}
+	if bucket == "" {
+		return nil, errors.New("blob store bucket is empty")
+	}
+
+	service, err := storage.NewService(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("create storage client: %w", err)
+	}
+
+	return &Store{
+		logger:  l,
+		objects: service.Objects,
+		bucket:  bucket,
+	}, nil
+}
+
+func (s *Store) Put(ctx context.Context, key string, data []byte) error {
+	if key == "" {
+		return ErrInvalidKey
+	}
+
+	_, err := s.objects.Insert(s.bucket, &storage.Object{Name: key}).
+		Media(bytes.NewReader(data)).
+		Context(ctx).
+		Do()
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to write blob", "error", err, "bucket", s.bucket, "key", key)
+		return err
+	}
+
+	s.logger.DebugContext(ctx, "blob stored", "bucket", s.bucket, "key", key, "size", len(data))
+	return nil
+}
+
+func (s *Store) Get(ctx context.Context, key string) ([]byte, error) {
+	if key == "" {
+		return nil, ErrInvalidKey
+	}
+
+	res, err := s.objects.Get(s.bucket, key).Context(ctx).Download()
+	if err != nil {
+		var apiErr *googleapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+			return nil, gateway.ErrBlobNotFound
+		}
+		s.logger.ErrorContext(ctx, "failed to read blob", "error", err, "bucket", s.bucket, "key", key)
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to read blob", "error", err, "bucket", s.bucket, "key", key)
+		return nil, err
+	}
+
+	return data, nil
+}
@@ -0,0 +1,90 @@
+package local
+
+import (
+	"ai-orchestrator/internal/common/logger"
+	"ai-orchestrator/internal/domain/gateway"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var ErrInvalidKey = errors.New("invalid blob key")
+
+// Store keeps blobs as files under a root directory. It is meant for local
+// development and tests, where the API and the worker share a volume.
+type Store struct {
+	logger logger.Logger
+	root   string
+}
+
+func NewStore(l logger.Logger, root string) (*Store, error) {
+	if l == nil {
+		return nil, logger.ErrNilLogger
+	}
+	if root == "" {
+		return nil, errors.New("blob store root is empty")
+	}
+
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, fmt.Errorf("create blob store root: %w", err)
+	}
+
+	return &Store{
+		logger: l,
+		root:   root,
+	}, nil
+}
+
+func (s *Store) Put(ctx context.Context, key string, data []byte) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		s.logger.ErrorContext(ctx, "failed to create blob directory", "error", err, "key", key)
+		return err
+	}
+	if err = os.WriteFile(path, data, 0o640); err != nil {
+		s.logger.ErrorContext(ctx, "failed to write blob", "error", err, "key", key)
+		return err
+	}
+
+	s.logger.DebugContext(ctx, "blob stored", "key", key, "size", len(data))
+	return nil
+}
+
+func (s *Store) Get(ctx context.Context, key string) ([]byte, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, gateway.ErrBlobNotFound
+		}
+		s.logger.ErrorContext(ctx, "failed to read blob", "error", err, "key", key)
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// path maps the key into the root directory, rejecting keys that escape it.
+func (s *Store) path(key string) (string, error) {
+	if key == "" || filepath.IsAbs(key) {
+		return "", ErrInvalidKey
+	}
+
+	path := filepath.Join(s.root, filepath.FromSlash(key))
+	if !strings.HasPrefix(path, filepath.Clean(s.root)+string(filepath.Separator)) {
+		return "", ErrInvalidKey
+	}
+
+	return path, nil
+}
@@ -0,0 +1,140 @@
+package broker
+
+import (
+	"ai-orchestrator/internal/common/logger"
+	"ai-orchestrator/internal/infra/telemetry/metrics"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"strings"
+	"time"
+)
+
+// collectTimeout bounds the XINFO calls made on a scrape.
+const collectTimeout = 2 * time.Second
+
+// StreamBacklog is what a consumer group still has to do on a stream: the
+// messages not delivered yet (Lag) and the ones delivered but not acknowledged
+// (Pending).
+type StreamBacklog struct {
+	Lag     int64 `json:"lag"`
+	Pending int64 `json:"pending"`
+}
+
+// Backlog reports the backlog of a consumer group over its streams, as a
+// scaling signal for the worker pool and for the platform.
+type Backlog struct {
+	logger  logger.Logger
+	client  *redis.Client
+	streams []string
+	group   string
+}
+
+func NewBacklog(l logger.Logger, client *redis.Client, streams []string, group string) (*Backlog, error) {
+	if l == nil {
+		return nil, logger.ErrNilLogger
+	}
+	if client == nil {
+		return nil, errors.New("redis client is nil")
+	}
+	if len(streams) == 0 {
+		return nil, errors.New("no stream to watch")
+	}
+
+	return &Backlog{
+		logger:  l,
+		client:  client,
+		streams: streams,
+		group:   group,
+	}, nil
+}
+
+// Streams returns the backlog of the group on every stream. A stream the
+// group does not read yet has no backlog. When Redis cannot tell the lag,
+// e.g. after messages were deleted, the length of the stream bounds it.
+func (b *Backlog) Streams(ctx context.Context) (map[string]StreamBacklog, error) {
+	backlogs := make(map[string]StreamBacklog, len(b.streams))
+	for _, stream := range b.streams {
+		groups, err := b.client.XInfoGroups(ctx, stream).Result()
+		if err != nil {
+			if isMissingStream(err) {
+				backlogs[stream] = StreamBacklog{}
+				continue
+			}
+			return nil, fmt.Errorf("stream %s: %w", stream, err)
+		}
+
+		var backlog StreamBacklog
+		for _, g := range groups {
+			if g.Name != b.group {
+				continue
+			}
+			backlog.Pending = g.Pending
+			backlog.Lag = g.Lag
+			if g.Lag < 0 {
+				if backlog.Lag, err = b.client.XLen(ctx, stream).Result(); err != nil {
+					return nil, fmt.Errorf("stream %s: %w", stream, err)
+				}
+			}
+		}
+		backlogs[stream] = backlog
+	}
+
+	return backlogs, nil
+}
+
+// Size sums the lag of all the streams. The pending messages are left out:
+// the ones whose processing failed stay pending, and would keep the pool at
+// its maximum.
+func (b *Backlog) Size(ctx context.Context) (int64, error) {
+	backlogs, err := b.Streams(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var size int64
+	for _, backlog := range backlogs {
+		size += backlog.Lag
+	}
+
+	return size, nil
+}
+
+func (b *Backlog) Collect() []metrics.Sample {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	backlogs, err := b.Streams(ctx)
+	if err != nil {
+		b.logger.Warn("Failed to collect the stream backlog", "error", err)
+		return nil
+	}
+
+	samples := make([]metrics.Sample, 0, 2*len(backlogs))
+	for stream, backlog := range backlogs {
+		labels := map[string]string{"stream": stream, "group": b.group}
+		samples = append(samples,
+			metrics.Sample{
+				Name:   "stream_group_lag",
+				Help:   "Messages of the stream not delivered to the consumer group yet.",
+				Type:   metrics.Gauge,
+				Labels: labels,
+				Value:  float64(backlog.Lag),
+			},
+			metrics.Sample{
+				Name:   "stream_group_pending",
+				Help:   "Messages delivered to the consumer group and not acknowledged yet.",
+				Type:   metrics.Gauge,
+				Labels: labels,
+				Value:  float64(backlog.Pending),
+			},
+		)
+	}
+
+	return samples
+}
+
+func isMissingStream(err error) bool {
+	return strings.Contains(err.Error(), "no such key")
+}
@@ -0,0 +1,99 @@
+package broker
+
+import (
+	"ai-orchestrator/internal/common/logger"
+	"ai-orchestrator/internal/config/shared"
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const cancelledKeyPrefix = "prompt:cancelled:"
+
+// CancelSignal broadcasts prompt cancellations to every worker.
+// The signal is delivered twice: a marker key lets workers skip tasks that are
+// still queued, and a Pub/Sub message interrupts the worker that is currently
+// processing the prompt. Pub/Sub is used instead of a stream on purpose -
+// consumer groups deliver a message to one worker only, while the worker
+// holding the prompt is unknown to the publisher.
+type CancelSignal struct {
+	logger logger.Logger
+	client *redis.Client
+	config *shared.CancellationConfig
+}
+
+func NewCancelSignal(l logger.Logger, client *redis.Client, cfg *shared.CancellationConfig) (*CancelSignal, error) {
+	if l == nil {
+		return nil, logger.ErrNilLogger
+	}
+	if client == nil {
+		return nil, errors.New("redis client is nil")
+	}
+	if cfg == nil {
+		return nil, errors.New("cancellation config is nil")
+	}
+
+	return &CancelSignal{
+		logger: l,
+		client: client,
+		config: cfg,
+	}, nil
+}
+
+func (cs *CancelSignal) PublishCancel(ctx context.Context, promptID uuid.UUID) error {
+	_, err := cs.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, cancelledKeyPrefix+promptID.String(), 1, cs.config.TTL)
+		pipe.Publish(ctx, cs.config.Channel, promptID.String())
+		return nil
+	})
+	if err != nil {
+		cs.logger.ErrorContext(ctx, "Failed to publish cancellation.", "error", err, "prompt_id", promptID, "channel", cs.config.Channel)
+		return err
+	}
+
+	cs.logger.InfoContext(ctx, "Published cancellation", "prompt_id", promptID, "channel", cs.config.Channel)
+	return nil
+}
+
+func (cs *CancelSignal) IsCancelled(ctx context.Context, promptID uuid.UUID) (bool, error) {
+	n, err := cs.client.Exists(ctx, cancelledKeyPrefix+promptID.String()).Result()
+	if err != nil {
+		cs.logger.WarnContext(ctx, "Failed to check cancellation.", "error", err, "prompt_id", promptID)
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+// Listen calls onCancel for every cancellation published until ctx is done.
+func (cs *CancelSignal) Listen(ctx context.Context, onCancel func(promptID uuid.UUID)) error {
+	sub := cs.client.Subscribe(ctx, cs.config.Channel)
+	defer sub.Close()
+
+	if _, err := sub.Receive(ctx); err != nil {
+		cs.logger.Error("Failed to subscribe to cancellations.", "error", err, "channel", cs.config.Channel)
+		return err
+	}
+	cs.logger.Info("Listening for cancellations", "channel", cs.config.Channel)
+
+	messages := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg, ok := <-messages:
+			if !ok {
+				return errors.New("cancellation subscription closed")
+			}
+
+			promptID, err := uuid.Parse(msg.Payload)
+			if err != nil {
+				cs.logger.Warn("Received malformed cancellation", "payload", msg.Payload, "error", err)
+				continue
+			}
+
+			onCancel(promptID)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package broker
+
+import (
+	"ai-orchestrator/internal/config/shared"
+	"ai-orchestrator/internal/domain/model"
+)
+
+// LaneConfig derives the stream of a priority lane from the base stream config.
+// Normal prompts keep using the base stream, the other classes get a suffixed one,
+// e.g. "tasks:high" and "tasks:low".
+func LaneConfig(base shared.StreamConfig, priority model.Priority) *shared.StreamConfig {
+	lane := base
+	if priority != model.PriorityNormal {
+		lane.ID = base.ID + ":" + string(priority)
+	}
+
+	return &lane
+}
@@ -5,14 +5,40 @@ import (
 	"ai-orchestrator/internal/config/shared"
 	"context"
 	"errors"
+	"fmt"
 	"math/rand"
 	"time"
 )
 
+var (
+	ErrMaxRetries    = errors.New("reached max retries")
+	ErrUnknownJitter = errors.New("unknown backoff jitter")
+)
+
+// decorrelatedGrowth bounds how much a decorrelated delay grows from the previous one.
+const decorrelatedGrowth = 3
+
+const (
+	// JitterProportional adds up to a fifth of the exponential delay.
+	JitterProportional = "proportional"
+	// JitterFull draws the delay between zero and the exponential delay.
+	JitterFull = "full"
+	// JitterDecorrelated draws the delay between the minimum and three times
+	// the previous delay, so concurrent callers drift apart.
+	JitterDecorrelated = "decorrelated"
+)
+
+// RetryAfter is implemented by errors telling how long to wait before the next
+// attempt, such as the Retry-After of a rate limited HTTP call.
+type RetryAfter interface {
+	RetryAfter() time.Duration
+}
+
+// Backoff is a retry policy. It holds no state and is safe for concurrent use:
+// every invocation keeps its own Retry.
 type Backoff struct {
-	logger         logger.Logger
-	cfg            *shared.BackoffConfig
-	currentBackoff time.Duration
+	logger logger.Logger
+	cfg    *shared.BackoffConfig
 }
 
 func NewBackoff(l logger.Logger, cfg *shared.BackoffConfig) (*Backoff, error) {
@@ -22,14 +48,105 @@ func NewBackoff(l logger.Logger, cfg *shared.BackoffConfig) (*Backoff, error) {
 	if cfg == nil {
 		return nil, errors.New("backoff config is nil")
 	}
+	switch cfg.Jitter {
+	case JitterProportional, JitterFull, JitterDecorrelated:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownJitter, cfg.Jitter)
+	}
 
 	return &Backoff{
-		logger:         l,
-		cfg:            cfg,
-		currentBackoff: cfg.Min,
+		logger: l,
+		cfg:    cfg,
 	}, nil
 }
 
+// Retry is the state of one invocation retried under a Backoff.
+type Retry struct {
+	backoff  *Backoff
+	attempts int
+	previous time.Duration
+}
+
+func (b *Backoff) NewRetry() *Retry {
+	return &Retry{backoff: b}
+}
+
+// Reset starts over from the minimum delay, after a success.
+func (r *Retry) Reset() {
+	r.attempts = 0
+	r.previous = 0
+}
+
+// Next returns the delay before the next attempt after err. A Retry-After
+// hint of the error is honoured when it is longer than the computed delay;
+// when it is longer than the maximum delay, Next reports that retrying is
+// pointless.
+func (r *Retry) Next(err error) (time.Duration, bool) {
+	cfg := r.backoff.cfg
+	delay := r.delay()
+	r.attempts++
+	r.previous = delay
+
+	var hint RetryAfter
+	if errors.As(err, &hint) && hint.RetryAfter() > delay {
+		if hint.RetryAfter() > cfg.Max {
+			return hint.RetryAfter(), false
+		}
+		delay = hint.RetryAfter()
+		r.previous = delay
+	}
+
+	return delay, true
+}
+
+// Wait sleeps for the delay Next returns, unless the context ends first.
+func (r *Retry) Wait(ctx context.Context, err error) error {
+	delay, ok := r.Next(err)
+	if !ok {
+		return fmt.Errorf("%w: asked to retry after %s", ErrMaxRetries, delay)
+	}
+
+	r.backoff.logger.InfoContext(ctx, "Backoff active", "sleep_time", delay.String(), "attempt", r.attempts, "err", err)
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(delay):
+		return nil
+	}
+}
+
+func (r *Retry) delay() time.Duration {
+	cfg := r.backoff.cfg
+
+	exponential := float64(cfg.Min)
+	for i := 0; i < r.attempts && exponential < float64(cfg.Max); i++ {
+		exponential *= cfg.Factor
+	}
+	ceiling := time.Duration(min(exponential, float64(cfg.Max)))
+
+	switch cfg.Jitter {
+	case JitterFull:
+		return randomBetween(0, ceiling)
+	case JitterDecorrelated:
+		upper := time.Duration(min(float64(max(r.previous, cfg.Min))*decorrelatedGrowth, float64(cfg.Max)))
+		return randomBetween(cfg.Min, upper)
+	default:
+		return min(ceiling+randomBetween(0, ceiling/5), cfg.Max)
+	}
+}
+
+// randomBetween draws a duration in [low, high).
+func randomBetween(low, high time.Duration) time.Duration {
+	if high <= low {
+		return low
+	}
+
+	return low + time.Duration(rand.Int63n(int64(high-low)))
+}
+
+// WithBackoff runs operation until it succeeds, fails with an error that is
+// not retryable, or has run MaxRetries times.
 func WithBackoff[T any](
 	ctx context.Context,
 	backoff *Backoff,
@@ -38,12 +155,13 @@ func WithBackoff[T any](
 ) (T, error) {
 
 	var zero T
+	var lastErr error
 
+	retry := backoff.NewRetry()
 	for i := 0; i < backoff.cfg.MaxRetries; i++ {
 		result, err := operation(ctx)
+		lastErr = err
 		if err == nil {
-			backoff.currentBackoff = backoff.cfg.Min
-
 			return result, err
 		}
 
@@ -56,23 +174,16 @@ func WithBackoff[T any](
 			return zero, err
 		}
 
-		jitter := time.Duration(rand.Int63n(int64(backoff.currentBackoff) / 5))
-		sleepTime := backoff.currentBackoff + jitter
-
-		backoff.logger.InfoContext(ctx, "Backoff active", "sleep_time", sleepTime.String(), "err", err)
-
-		select {
-		case <-ctx.Done():
-			return zero, ctx.Err()
-		case <-time.After(sleepTime):
-
+		if i == backoff.cfg.MaxRetries-1 {
+			break
 		}
-
-		backoff.currentBackoff *= time.Duration(backoff.cfg.Factor)
-		if backoff.currentBackoff > backoff.cfg.Max {
-			backoff.currentBackoff = backoff.cfg.Max
+		if waitErr := retry.Wait(ctx, err); waitErr != nil {
+			if ctx.Err() != nil {
+				return zero, ctx.Err()
+			}
+			return zero, fmt.Errorf("%w: %w", waitErr, err)
 		}
 	}
 
-	return zero, errors.New("reached max retries")
+	return zero, fmt.Errorf("%w: %w", ErrMaxRetries, lastErr)
 }
@@ -0,0 +1,219 @@
+package manager
+
+import (
+	"ai-orchestrator/internal/config/shared"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// retryAfterError is a rate limited call asking to wait.
+type retryAfterError time.Duration
+
+func (e retryAfterError) Error() string {
+	return "rate limited"
+}
+
+func (e retryAfterError) RetryAfter() time.Duration {
+	return time.Duration(e)
+}
+
+func newTestBackoff(t *testing.T, cfg shared.BackoffConfig) *Backoff {
+	t.Helper()
+
+	backoff, err := NewBackoff(slog.New(slog.DiscardHandler), &cfg)
+	if err != nil {
+		t.Fatalf("NewBackoff unexpected error: %v", err)
+	}
+
+	return backoff
+}
+
+func TestNewBackoffJitter(t *testing.T) {
+	for _, jitter := range []string{JitterProportional, JitterFull, JitterDecorrelated} {
+		if _, err := NewBackoff(slog.New(slog.DiscardHandler), &shared.BackoffConfig{Jitter: jitter}); err != nil {
+			t.Fatalf("NewBackoff(%q) unexpected error: %v", jitter, err)
+		}
+	}
+
+	_, err := NewBackoff(slog.New(slog.DiscardHandler), &shared.BackoffConfig{Jitter: "none"})
+	if !errors.Is(err, ErrUnknownJitter) {
+		t.Fatalf("NewBackoff error = %v, want %v", err, ErrUnknownJitter)
+	}
+}
+
+func TestRetryJitterBounds(t *testing.T) {
+	const (
+		minDelay = 100 * time.Millisecond
+		maxDelay = time.Second
+	)
+	// The exponential delays of the attempts, capped by the maximum.
+	ceilings := []time.Duration{100, 200, 400, 800, 1000, 1000}
+
+	tests := []struct {
+		jitter string
+		// bounds returns the range of the delay of the attempt, given the previous delay.
+		bounds func(ceiling, previous time.Duration) (time.Duration, time.Duration)
+	}{
+		{
+			jitter: JitterFull,
+			bounds: func(ceiling, _ time.Duration) (time.Duration, time.Duration) {
+				return 0, ceiling
+			},
+		},
+		{
+			jitter: JitterProportional,
+			bounds: func(ceiling, _ time.Duration) (time.Duration, time.Duration) {
+				return ceiling, min(ceiling+ceiling/5, maxDelay)
+			},
+		},
+		{
+			jitter: JitterDecorrelated,
+			bounds: func(_, previous time.Duration) (time.Duration, time.Duration) {
+				return minDelay, min(max(previous, minDelay)*decorrelatedGrowth, maxDelay)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.jitter, func(t *testing.T) {
+			backoff := newTestBackoff(t, shared.BackoffConfig{Min: minDelay, Max: maxDelay, Factor: 2, Jitter: tt.jitter})
+
+			for range 200 {
+				retry := backoff.NewRetry()
+				var previous time.Duration
+				for attempt, ceiling := range ceilings {
+					low, high := tt.bounds(ceiling*time.Millisecond, previous)
+
+					delay, ok := retry.Next(errors.New("failure"))
+					if !ok {
+						t.Fatalf("attempt %d: Next gave up", attempt)
+					}
+					if delay < low || delay > high {
+						t.Fatalf("attempt %d: delay %s out of [%s, %s]", attempt, delay, low, high)
+					}
+					previous = delay
+				}
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	// The first delay is the minimum plus up to a fifth of it.
+	const low, high = 100 * time.Millisecond, 120 * time.Millisecond
+
+	tests := []struct {
+		name     string
+		err      error
+		wantLow  time.Duration
+		wantHigh time.Duration
+		wantOK   bool
+	}{
+		{name: "no hint", err: errors.New("failure"), wantLow: low, wantHigh: high, wantOK: true},
+		{name: "shorter hint", err: retryAfterError(10 * time.Millisecond), wantLow: low, wantHigh: high, wantOK: true},
+		{name: "longer hint", err: retryAfterError(500 * time.Millisecond), wantLow: 500 * time.Millisecond, wantHigh: 500 * time.Millisecond, wantOK: true},
+		{name: "wrapped hint", err: errors.Join(errors.New("call"), retryAfterError(time.Second)), wantLow: time.Second, wantHigh: time.Second, wantOK: true},
+		{name: "hint beyond the maximum", err: retryAfterError(2 * time.Second), wantLow: 2 * time.Second, wantHigh: 2 * time.Second, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backoff := newTestBackoff(t, shared.BackoffConfig{Min: low, Max: time.Second, Factor: 2, Jitter: JitterProportional})
+
+			delay, ok := backoff.NewRetry().Next(tt.err)
+			if ok != tt.wantOK {
+				t.Fatalf("Next ok = %v, want %v", ok, tt.wantOK)
+			}
+			if delay < tt.wantLow || delay > tt.wantHigh {
+				t.Fatalf("Next = %s, want in [%s, %s]", delay, tt.wantLow, tt.wantHigh)
+			}
+		})
+	}
+}
+
+// A Retry-After hint is the previous delay the decorrelated jitter grows from.
+func TestRetryAfterDecorrelated(t *testing.T) {
+	backoff := newTestBackoff(t, shared.BackoffConfig{Min: 10 * time.Millisecond, Max: time.Second, Factor: 2, Jitter: JitterDecorrelated})
+	retry := backoff.NewRetry()
+
+	if delay, _ := retry.Next(retryAfterError(200 * time.Millisecond)); delay != 200*time.Millisecond {
+		t.Fatalf("Next = %s, want the hint", delay)
+	}
+	for range 100 {
+		retry.previous = 200 * time.Millisecond
+		if delay := retry.delay(); delay < 10*time.Millisecond || delay >= 600*time.Millisecond {
+			t.Fatalf("delay %s out of [10ms, 600ms)", delay)
+		}
+	}
+}
+
+func TestRetryReset(t *testing.T) {
+	backoff := newTestBackoff(t, shared.BackoffConfig{Min: 100 * time.Millisecond, Max: time.Second, Factor: 2, Jitter: JitterProportional})
+	retry := backoff.NewRetry()
+	for range 4 {
+		retry.Next(nil)
+	}
+
+	retry.Reset()
+	if delay, _ := retry.Next(nil); delay > 120*time.Millisecond {
+		t.Fatalf("Next after Reset = %s, want the minimum delay", delay)
+	}
+}
+
+func TestWithBackoff(t *testing.T) {
+	fatal := errors.New("fatal")
+	transient := errors.New("transient")
+
+	tests := []struct {
+		name      string
+		errs      []error
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "success", errs: []error{nil}, wantCalls: 1},
+		{name: "success after retries", errs: []error{transient, transient, nil}, wantCalls: 3},
+		{name: "not retryable", errs: []error{transient, fatal}, wantCalls: 2, wantErr: fatal},
+		{name: "max retries", errs: []error{transient, transient, transient, transient}, wantCalls: 3, wantErr: ErrMaxRetries},
+		{name: "retry after beyond the maximum", errs: []error{retryAfterError(time.Hour)}, wantCalls: 1, wantErr: ErrMaxRetries},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backoff := newTestBackoff(t, shared.BackoffConfig{Min: time.Millisecond, Max: 5 * time.Millisecond, Factor: 2, Jitter: JitterFull, MaxRetries: 3})
+
+			calls := 0
+			_, err := WithBackoff(context.Background(), backoff, func(context.Context) (int, error) {
+				err := tt.errs[calls]
+				calls++
+				return calls, err
+			}, func(err error) bool { return !errors.Is(err, fatal) })
+
+			if calls != tt.wantCalls {
+				t.Fatalf("operation called %d times, want %d", calls, tt.wantCalls)
+			}
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWithBackoffContext(t *testing.T) {
+	backoff := newTestBackoff(t, shared.BackoffConfig{Min: time.Hour, Max: time.Hour, Factor: 2, Jitter: JitterProportional, MaxRetries: 3})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := WithBackoff(ctx, backoff, func(context.Context) (int, error) {
+		return 0, errors.New("transient")
+	}, func(error) bool { return true })
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,217 @@
+package manager
+
+import (
+	"ai-orchestrator/internal/common/logger"
+	"ai-orchestrator/internal/config/shared"
+	"ai-orchestrator/internal/infra/telemetry/metrics"
+	"errors"
+	"sync"
+	"time"
+)
+
+type CircuitState string
+
+const (
+	CircuitClosed   CircuitState = "closed"
+	CircuitOpen     CircuitState = "open"
+	CircuitHalfOpen CircuitState = "half_open"
+)
+
+// Outcome classifies a call for the circuit. Calls failing for reasons
+// unrelated to the health of the provider are Ignored.
+type Outcome int
+
+const (
+	Success Outcome = iota
+	Failure
+	Ignored
+)
+
+// CircuitBreaker keeps a circuit per key, shared by all the workers of the
+// process. A closed circuit counts the calls of the current window and trips
+// on too many failures. An open one rejects the calls until the open timeout
+// elapses, then half-opens to let a few probe calls through: the circuit
+// closes when they all succeed and opens again on the first failure.
+type CircuitBreaker struct {
+	logger logger.Logger
+	cfg    *shared.CircuitBreakerConfig
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+type circuit struct {
+	state CircuitState
+
+	windowStart time.Time
+	requests    int
+	failures    int
+
+	openedAt  time.Time
+	probes    int
+	successes int
+
+	trips int
+}
+
+func NewCircuitBreaker(l logger.Logger, cfg *shared.CircuitBreakerConfig) (*CircuitBreaker, error) {
+	if l == nil {
+		return nil, logger.ErrNilLogger
+	}
+	if cfg == nil {
+		return nil, errors.New("circuit breaker config is nil")
+	}
+
+	return &CircuitBreaker{
+		logger:   l,
+		cfg:      cfg,
+		circuits: make(map[string]*circuit),
+	}, nil
+}
+
+// Allow reports whether a call may go through. When it may not, it returns
+// the time left before the circuit lets probe calls through.
+func (b *CircuitBreaker) Allow(key string) (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuit(key)
+	now := time.Now()
+
+	if c.state == CircuitOpen {
+		if wait := c.openedAt.Add(b.cfg.OpenTimeout).Sub(now); wait > 0 {
+			return wait, false
+		}
+		b.transition(key, c, CircuitHalfOpen)
+	}
+
+	if c.state == CircuitHalfOpen {
+		if c.probes+c.successes >= b.cfg.HalfOpenRequests {
+			// The probes are in flight, the others wait for their outcome.
+			return b.cfg.OpenTimeout, false
+		}
+		c.probes++
+	}
+
+	return 0, true
+}
+
+// Record reports the outcome of a call allowed through.
+func (b *CircuitBreaker) Record(key string, outcome Outcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuit(key)
+	now := time.Now()
+
+	switch c.state {
+	case CircuitHalfOpen:
+		if c.probes > 0 {
+			c.probes--
+		}
+		switch outcome {
+		case Failure:
+			b.trip(key, c, now)
+		case Success:
+			c.successes++
+			if c.successes >= b.cfg.HalfOpenRequests {
+				b.transition(key, c, CircuitClosed)
+				c.windowStart, c.requests, c.failures = now, 0, 0
+			}
+		}
+
+	case CircuitClosed:
+		if outcome == Ignored {
+			return
+		}
+		if now.Sub(c.windowStart) >= b.cfg.Window {
+			c.windowStart, c.requests, c.failures = now, 0, 0
+		}
+		c.requests++
+		if outcome == Failure {
+			c.failures++
+		}
+		if c.requests >= b.cfg.MinRequests && float64(c.failures) >= b.cfg.FailureRate*float64(c.requests) {
+			b.trip(key, c, now)
+		}
+	}
+}
+
+// States returns the state of every circuit.
+func (b *CircuitBreaker) States() map[string]CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	states := make(map[string]CircuitState, len(b.circuits))
+	for key, c := range b.circuits {
+		states[key] = c.state
+	}
+
+	return states
+}
+
+func (b *CircuitBreaker) Collect() []metrics.Sample {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	samples := make([]metrics.Sample, 0, 2*len(b.circuits))
+	for key, c := range b.circuits {
+		labels := map[string]string{"circuit": key}
+		samples = append(samples,
+			metrics.Sample{
+				Name:   "ai_circuit_breaker_state",
+				Help:   "State of the circuit: 0 closed, 1 half-open, 2 open.",
+				Type:   metrics.Gauge,
+				Labels: labels,
+				Value:  stateValue(c.state),
+			},
+			metrics.Sample{
+				Name:   "ai_circuit_breaker_trips_total",
+				Help:   "Number of times the circuit opened.",
+				Type:   metrics.Counter,
+				Labels: labels,
+				Value:  float64(c.trips),
+			},
+		)
+	}
+
+	return samples
+}
+
+func (b *CircuitBreaker) circuit(key string) *circuit {
+	c, ok := b.circuits[key]
+	if !ok {
+		c = &circuit{state: CircuitClosed, windowStart: time.Now()}
+		b.circuits[key] = c
+	}
+
+	return c
+}
+
+func (b *CircuitBreaker) trip(key string, c *circuit, now time.Time) {
+	b.logger.Warn("Circuit opened", "circuit", key, "requests", c.requests, "failures", c.failures, "open_timeout", b.cfg.OpenTimeout)
+
+	c.trips++
+	c.openedAt = now
+	b.transition(key, c, CircuitOpen)
+}
+
+func (b *CircuitBreaker) transition(key string, c *circuit, state CircuitState) {
+	if state != CircuitOpen {
+		b.logger.Info("Circuit state changed", "circuit", key, "from", c.state, "to", state)
+	}
+
+	c.state = state
+	c.probes, c.successes = 0, 0
+}
+
+func stateValue(state CircuitState) float64 {
+	switch state {
+	case CircuitHalfOpen:
+		return 1
+	case CircuitOpen:
+		return 2
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,235 @@
+package manager
+
+import (
+	"ai-orchestrator/internal/config/shared"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+const testCircuit = "gemini-2.5-flash"
+
+// circuitStep is a call of a scenario: a call allowed through or rejected, then
+// its outcome if allowed, or the time passing.
+type circuitStep struct {
+	call    bool
+	allowed bool
+	outcome Outcome
+
+	// elapse moves the clocks of the circuit past the window or the open timeout.
+	elapse bool
+
+	want CircuitState
+}
+
+func allowed(outcome Outcome, want CircuitState) circuitStep {
+	return circuitStep{call: true, allowed: true, outcome: outcome, want: want}
+}
+
+func rejected(want CircuitState) circuitStep {
+	return circuitStep{call: true, want: want}
+}
+
+func elapse(want CircuitState) circuitStep {
+	return circuitStep{elapse: true, want: want}
+}
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []circuitStep
+	}{
+		{
+			name: "stays closed below the minimum requests",
+			steps: []circuitStep{
+				allowed(Failure, CircuitClosed),
+				allowed(Failure, CircuitClosed),
+				allowed(Failure, CircuitClosed),
+			},
+		},
+		{
+			name: "stays closed below the failure rate",
+			steps: []circuitStep{
+				allowed(Success, CircuitClosed),
+				allowed(Success, CircuitClosed),
+				allowed(Success, CircuitClosed),
+				allowed(Failure, CircuitClosed),
+			},
+		},
+		{
+			name: "opens at the failure rate",
+			steps: []circuitStep{
+				allowed(Success, CircuitClosed),
+				allowed(Failure, CircuitClosed),
+				allowed(Success, CircuitClosed),
+				allowed(Failure, CircuitOpen),
+				rejected(CircuitOpen),
+			},
+		},
+		{
+			name: "ignores the outcomes unrelated to the provider",
+			steps: []circuitStep{
+				allowed(Failure, CircuitClosed),
+				allowed(Ignored, CircuitClosed),
+				allowed(Ignored, CircuitClosed),
+				allowed(Ignored, CircuitClosed),
+				allowed(Failure, CircuitClosed),
+				allowed(Success, CircuitClosed),
+			},
+		},
+		{
+			name: "counts anew in the next window",
+			steps: []circuitStep{
+				allowed(Failure, CircuitClosed),
+				allowed(Failure, CircuitClosed),
+				allowed(Failure, CircuitClosed),
+				elapse(CircuitClosed),
+				allowed(Failure, CircuitClosed),
+				allowed(Success, CircuitClosed),
+				allowed(Success, CircuitClosed),
+				allowed(Success, CircuitClosed),
+			},
+		},
+		{
+			name: "half-opens after the open timeout",
+			steps: []circuitStep{
+				allowed(Failure, CircuitClosed),
+				allowed(Failure, CircuitClosed),
+				allowed(Failure, CircuitClosed),
+				allowed(Failure, CircuitOpen),
+				rejected(CircuitOpen),
+				elapse(CircuitOpen),
+				allowed(Success, CircuitHalfOpen),
+			},
+		},
+		{
+			name: "closes when all the probes succeed",
+			steps: []circuitStep{
+				allowed(Failure, CircuitClosed),
+				allowed(Failure, CircuitClosed),
+				allowed(Failure, CircuitClosed),
+				allowed(Failure, CircuitOpen),
+				elapse(CircuitOpen),
+				allowed(Success, CircuitHalfOpen),
+				allowed(Success, CircuitClosed),
+				// The failures before the trip are forgotten.
+				allowed(Failure, CircuitClosed),
+			},
+		},
+		{
+			name: "opens again when a probe fails",
+			steps: []circuitStep{
+				allowed(Failure, CircuitClosed),
+				allowed(Failure, CircuitClosed),
+				allowed(Failure, CircuitClosed),
+				allowed(Failure, CircuitOpen),
+				elapse(CircuitOpen),
+				allowed(Success, CircuitHalfOpen),
+				allowed(Failure, CircuitOpen),
+				rejected(CircuitOpen),
+			},
+		},
+		{
+			name: "ignored probes do not close the circuit",
+			steps: []circuitStep{
+				allowed(Failure, CircuitClosed),
+				allowed(Failure, CircuitClosed),
+				allowed(Failure, CircuitClosed),
+				allowed(Failure, CircuitOpen),
+				elapse(CircuitOpen),
+				allowed(Ignored, CircuitHalfOpen),
+				allowed(Ignored, CircuitHalfOpen),
+				allowed(Success, CircuitHalfOpen),
+				allowed(Success, CircuitClosed),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breaker := newTestCircuitBreaker(t)
+
+			for i, step := range tt.steps {
+				switch {
+				case step.elapse:
+					c := breaker.circuits[testCircuit]
+					c.windowStart = c.windowStart.Add(-breaker.cfg.Window)
+					c.openedAt = c.openedAt.Add(-breaker.cfg.OpenTimeout)
+				case step.call:
+					wait, ok := breaker.Allow(testCircuit)
+					if ok != step.allowed {
+						t.Fatalf("step %d: Allow = %v, want %v", i, ok, step.allowed)
+					}
+					if !ok && wait <= 0 {
+						t.Fatalf("step %d: Allow rejected the call without a wait", i)
+					}
+					if ok {
+						breaker.Record(testCircuit, step.outcome)
+					}
+				}
+
+				if state := breaker.States()[testCircuit]; state != step.want {
+					t.Fatalf("step %d: state = %s, want %s", i, state, step.want)
+				}
+			}
+		})
+	}
+}
+
+// A half-open circuit lets only the configured number of probes through at once.
+func TestCircuitBreakerHalfOpenProbes(t *testing.T) {
+	breaker := newTestCircuitBreaker(t)
+	for range 4 {
+		breaker.Allow(testCircuit)
+		breaker.Record(testCircuit, Failure)
+	}
+	breaker.circuits[testCircuit].openedAt = time.Now().Add(-breaker.cfg.OpenTimeout)
+
+	for i := range breaker.cfg.HalfOpenRequests {
+		if _, ok := breaker.Allow(testCircuit); !ok {
+			t.Fatalf("probe %d rejected", i)
+		}
+	}
+	if wait, ok := breaker.Allow(testCircuit); ok || wait != breaker.cfg.OpenTimeout {
+		t.Fatalf("Allow = %s, %v, want the call rejected for the open timeout", wait, ok)
+	}
+
+	// A successful probe keeps its slot, it counts toward closing the circuit.
+	breaker.Record(testCircuit, Success)
+	if _, ok := breaker.Allow(testCircuit); ok {
+		t.Fatal("Allow let a probe through beyond the successes still needed")
+	}
+}
+
+func TestCircuitBreakerKeys(t *testing.T) {
+	breaker := newTestCircuitBreaker(t)
+	for range 4 {
+		breaker.Allow(testCircuit)
+		breaker.Record(testCircuit, Failure)
+	}
+
+	if _, ok := breaker.Allow("other"); !ok {
+		t.Fatal("Allow rejected a call on another circuit")
+	}
+	states := breaker.States()
+	if states[testCircuit] != CircuitOpen || states["other"] != CircuitClosed {
+		t.Fatalf("States = %v, want only %s open", states, testCircuit)
+	}
+}
+
+func newTestCircuitBreaker(t *testing.T) *CircuitBreaker {
+	t.Helper()
+
+	breaker, err := NewCircuitBreaker(slog.New(slog.DiscardHandler), &shared.CircuitBreakerConfig{
+		Window:           time.Minute,
+		MinRequests:      4,
+		FailureRate:      0.5,
+		OpenTimeout:      30 * time.Second,
+		HalfOpenRequests: 2,
+	})
+	if err != nil {
+		t.Fatalf("NewCircuitBreaker unexpected error: %v", err)
+	}
+
+	return breaker
+}
@@ -0,0 +1,195 @@
+package manager
+
+import (
+	"ai-orchestrator/internal/common/logger"
+	"ai-orchestrator/internal/config/shared"
+	"ai-orchestrator/internal/infra/telemetry/metrics"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	ErrNilBacklog         = errors.New("backlog is nil")
+	ErrNilAutoscaleConfig = errors.New("autoscale config is nil")
+)
+
+// BacklogSizer tells how many messages wait for the workers.
+type BacklogSizer interface {
+	Size(ctx context.Context) (int64, error)
+}
+
+// Pool runs the workers of the process. Without autoscaling all of them run;
+// with it, the pool is sized by the backlog every interval. A worker removed
+// from the pool has its context cancelled and drains before its slot may be
+// used again.
+type Pool struct {
+	logger  logger.Logger
+	cfg     *shared.AutoscaleConfig
+	backlog BacklogSizer
+
+	size   atomic.Int64
+	target atomic.Int64
+}
+
+type poolSlot struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewPool(l logger.Logger, cfg *shared.AutoscaleConfig, backlog BacklogSizer) (*Pool, error) {
+	if l == nil {
+		return nil, logger.ErrNilLogger
+	}
+	if cfg == nil {
+		return nil, ErrNilAutoscaleConfig
+	}
+	if backlog == nil {
+		return nil, ErrNilBacklog
+	}
+
+	return &Pool{
+		logger:  l,
+		cfg:     cfg,
+		backlog: backlog,
+	}, nil
+}
+
+// Run runs up to workers workers until the context ends, and waits for them
+// to stop. run runs the worker of the given index until its context ends.
+func (p *Pool) Run(ctx context.Context, workers int, run func(ctx context.Context, i int)) {
+	slots := make([]*poolSlot, workers)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	resize := func(n int) {
+		for i := 0; i < n; i++ {
+			if slots[i] != nil {
+				continue
+			}
+			workerCtx, cancel := context.WithCancel(ctx)
+			slot := &poolSlot{cancel: cancel, done: make(chan struct{})}
+			slots[i] = slot
+
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				defer close(slot.done)
+				run(workerCtx, i)
+			}(i)
+		}
+		for i := n; i < workers; i++ {
+			if slots[i] != nil {
+				slots[i].cancel()
+			}
+		}
+		p.size.Store(int64(n))
+	}
+
+	if !p.cfg.Enabled {
+		p.target.Store(int64(workers))
+		resize(workers)
+		return
+	}
+
+	minWorkers := min(max(p.cfg.MinWorkers, 1), workers)
+	size := minWorkers
+	p.target.Store(int64(size))
+	resize(size)
+	p.logger.Info("Worker pool autoscaling", "min_workers", minWorkers, "max_workers", workers, "target_backlog", p.cfg.TargetBacklog)
+
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+
+	var shrinkSince time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// Stopped workers free their slot once they drained.
+		for i := size; i < workers; i++ {
+			if slots[i] == nil {
+				continue
+			}
+			select {
+			case <-slots[i].done:
+				slots[i] = nil
+			default:
+			}
+		}
+
+		target, err := p.targetSize(ctx, minWorkers, workers)
+		if err != nil {
+			p.logger.Warn("Failed to read the backlog, keeping the pool size", "error", err, "size", size)
+			continue
+		}
+		p.target.Store(int64(target))
+
+		previous := size
+		switch {
+		case target > size:
+			shrinkSince = time.Time{}
+			// A slot still draining is reused on a next tick.
+			for size < target && slots[size] == nil {
+				size++
+			}
+		case target < size:
+			if shrinkSince.IsZero() {
+				shrinkSince = time.Now()
+			}
+			if time.Since(shrinkSince) < p.cfg.ScaleDownDelay {
+				continue
+			}
+			shrinkSince = time.Now()
+			size--
+		default:
+			shrinkSince = time.Time{}
+		}
+		if size == previous {
+			continue
+		}
+
+		p.logger.Info("Worker pool resized", "size", size, "target", target)
+		resize(size)
+	}
+}
+
+// targetSize is a worker per TargetBacklog messages, within the bounds.
+func (p *Pool) targetSize(ctx context.Context, minWorkers, maxWorkers int) (int, error) {
+	size, err := p.backlog.Size(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	perWorker := max(p.cfg.TargetBacklog, 1)
+	target := int((size + perWorker - 1) / perWorker)
+
+	return min(max(target, minWorkers), maxWorkers), nil
+}
+
+// Size returns the number of workers in the pool.
+func (p *Pool) Size() int {
+	return int(p.size.Load())
+}
+
+func (p *Pool) Collect() []metrics.Sample {
+	return []metrics.Sample{
+		{
+			Name:  "worker_pool_size",
+			Help:  "Number of workers in the pool.",
+			Type:  metrics.Gauge,
+			Value: float64(p.size.Load()),
+		},
+		{
+			Name:  "worker_pool_target_size",
+			Help:  "Number of workers the backlog calls for.",
+			Type:  metrics.Gauge,
+			Value: float64(p.target.Load()),
+		},
+	}
+}
@@ -0,0 +1,187 @@
+package manager
+
+import (
+	"ai-orchestrator/internal/config/shared"
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeBacklog is a backlog of a set size, or failing to be read.
+type fakeBacklog struct {
+	mu   sync.Mutex
+	size int64
+	err  error
+}
+
+func (b *fakeBacklog) Size(context.Context) (int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.size, b.err
+}
+
+func (b *fakeBacklog) set(size int64, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.size, b.err = size, err
+}
+
+func newTestPool(t *testing.T, cfg shared.AutoscaleConfig, backlog BacklogSizer) *Pool {
+	t.Helper()
+
+	pool, err := NewPool(slog.New(slog.DiscardHandler), &cfg, backlog)
+	if err != nil {
+		t.Fatalf("NewPool unexpected error: %v", err)
+	}
+
+	return pool
+}
+
+// runPool runs the pool in the background and counts its running workers.
+func runPool(t *testing.T, pool *Pool, workers int) *atomic.Int32 {
+	t.Helper()
+
+	var running atomic.Int32
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pool.Run(ctx, workers, func(ctx context.Context, _ int) {
+			running.Add(1)
+			defer running.Add(-1)
+			<-ctx.Done()
+		})
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		if n := running.Load(); n != 0 {
+			t.Errorf("%d workers still running after Run returned", n)
+		}
+	})
+
+	return &running
+}
+
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestPoolTargetSize(t *testing.T) {
+	failure := errors.New("failure")
+
+	tests := []struct {
+		name          string
+		targetBacklog int64
+		backlog       int64
+		err           error
+		want          int
+	}{
+		{name: "empty backlog", targetBacklog: 10, backlog: 0, want: 2},
+		{name: "below the minimum", targetBacklog: 10, backlog: 15, want: 2},
+		{name: "exact multiple", targetBacklog: 10, backlog: 30, want: 3},
+		{name: "rounded up", targetBacklog: 10, backlog: 31, want: 4},
+		{name: "capped at the maximum", targetBacklog: 10, backlog: 1000, want: 5},
+		{name: "unset target backlog", targetBacklog: 0, backlog: 4, want: 4},
+		{name: "backlog unavailable", targetBacklog: 10, err: failure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := newTestPool(t, shared.AutoscaleConfig{TargetBacklog: tt.targetBacklog}, &fakeBacklog{size: tt.backlog, err: tt.err})
+
+			got, err := pool.targetSize(context.Background(), 2, 5)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("targetSize error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("targetSize unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("targetSize = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPoolWithoutAutoscaling(t *testing.T) {
+	pool := newTestPool(t, shared.AutoscaleConfig{}, &fakeBacklog{})
+	running := runPool(t, pool, 3)
+
+	waitFor(t, "all the workers", func() bool { return running.Load() == 3 })
+	if size := pool.Size(); size != 3 {
+		t.Fatalf("Size = %d, want 3", size)
+	}
+}
+
+func TestPoolScaling(t *testing.T) {
+	const delay = 50 * time.Millisecond
+
+	backlog := &fakeBacklog{}
+	pool := newTestPool(t, shared.AutoscaleConfig{
+		Enabled:        true,
+		MinWorkers:     1,
+		TargetBacklog:  10,
+		Interval:       5 * time.Millisecond,
+		ScaleDownDelay: delay,
+	}, backlog)
+	running := runPool(t, pool, 4)
+
+	waitFor(t, "the minimum workers", func() bool { return running.Load() == 1 })
+
+	// The pool grows to the backlog at once, up to the maximum.
+	backlog.set(100, nil)
+	waitFor(t, "the pool to grow", func() bool { return pool.Size() == 4 && running.Load() == 4 })
+
+	// A backlog that can't be read keeps the size.
+	backlog.set(0, errors.New("failure"))
+	time.Sleep(2 * delay)
+	if size := pool.Size(); size != 4 {
+		t.Fatalf("Size = %d with the backlog unavailable, want 4", size)
+	}
+
+	// The pool shrinks a worker per delay, down to the minimum.
+	start := time.Now()
+	backlog.set(0, nil)
+	waitFor(t, "the pool to shrink", func() bool { return pool.Size() == 1 && running.Load() == 1 })
+	if elapsed := time.Since(start); elapsed < 3*delay {
+		t.Fatalf("pool shrank by 3 workers in %s, want at least %s", elapsed, 3*delay)
+	}
+}
+
+// A pool too large keeps its size until the scale down delay passed.
+func TestPoolScaleDownDelay(t *testing.T) {
+	backlog := &fakeBacklog{size: 20}
+	pool := newTestPool(t, shared.AutoscaleConfig{
+		Enabled:        true,
+		MinWorkers:     1,
+		TargetBacklog:  10,
+		Interval:       5 * time.Millisecond,
+		ScaleDownDelay: time.Hour,
+	}, backlog)
+	runPool(t, pool, 4)
+
+	waitFor(t, "the pool to grow", func() bool { return pool.Size() == 2 })
+	backlog.set(0, nil)
+	time.Sleep(50 * time.Millisecond)
+	if size := pool.Size(); size != 2 {
+		t.Fatalf("Size = %d before the scale down delay, want 2", size)
+	}
+}
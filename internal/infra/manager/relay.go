@@ -3,7 +3,6 @@ package manager
 import (
 	"ai-orchestrator/internal/common"
 	"ai-orchestrator/internal/common/logger"
-	"ai-orchestrator/internal/config/shared"
 	"ai-orchestrator/internal/infra/persistence/repository/outbox"
 	"context"
 	"encoding/json"
@@ -12,7 +11,7 @@ import (
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
-	"math/rand"
+	"sync/atomic"
 	"time"
 )
 
@@ -24,23 +23,30 @@ type Outbox interface {
 	IncrementRetryCount(ctx context.Context, eventID uuid.UUID, errorMessage string) error
 }
 
-var ErrNilProducer = errors.New("producer is nil")
+var (
+	ErrNilProducer     = errors.New("producer is nil")
+	ErrUnknownPriority = errors.New("no producer for event priority")
+)
 
 type Producer interface {
 	Publish(ctx context.Context, data json.RawMessage) error
 }
 
-var ErrNilConfig = errors.New("backoff config is nil")
+var ErrNilBackoff = errors.New("backoff is nil")
 
 type Relay struct {
-	logger     logger.Logger
-	tx         common.TransactionManager
-	repo       Outbox
-	producer   Producer
-	backoffCfg *shared.BackoffConfig
+	logger    logger.Logger
+	tx        common.TransactionManager
+	repo      Outbox
+	producers map[string]Producer
+	backoff   *Backoff
+
+	// heartbeat is the unix time in nanoseconds of the last loop iteration.
+	heartbeat atomic.Int64
 }
 
-func NewRelayService(l logger.Logger, tx common.TransactionManager, repo Outbox, producer Producer, backoffCfg *shared.BackoffConfig) (*Relay, error) {
+// NewRelayService creates the relay publishing outbox events to the producer of their priority.
+func NewRelayService(l logger.Logger, tx common.TransactionManager, repo Outbox, producers map[string]Producer, backoff *Backoff) (*Relay, error) {
 	if l == nil {
 		return nil, logger.ErrNilLogger
 	}
@@ -50,32 +56,39 @@ func NewRelayService(l logger.Logger, tx common.TransactionManager, repo Outbox,
 	if repo == nil {
 		return nil, ErrNilOutbox
 	}
-	if producer == nil {
+	if len(producers) == 0 {
 		return nil, ErrNilProducer
 	}
-	if backoffCfg == nil {
-		return nil, ErrNilConfig
+	for _, producer := range producers {
+		if producer == nil {
+			return nil, ErrNilProducer
+		}
+	}
+	if backoff == nil {
+		return nil, ErrNilBackoff
 	}
 
 	return &Relay{
-		logger:     l,
-		tx:         tx,
-		repo:       repo,
-		producer:   producer,
-		backoffCfg: backoffCfg,
+		logger:    l,
+		tx:        tx,
+		repo:      repo,
+		producers: producers,
+		backoff:   backoff,
 	}, nil
 }
 
 func (r *Relay) Start(ctx context.Context) error {
 	r.logger.Info("Publisher started")
 
-	currentBackoff := r.backoffCfg.Min
+	retry := r.backoff.NewRetry()
 	maxEvents := 10
 
-	ticker := time.NewTicker(r.backoffCfg.PollInterval)
+	ticker := time.NewTicker(r.backoff.cfg.PollInterval)
 	defer ticker.Stop()
 
 	for {
+		r.heartbeat.Store(time.Now().UnixNano())
+
 		select {
 		case <-ctx.Done():
 			r.logger.Info("Stopping producer")
@@ -83,17 +96,14 @@ func (r *Relay) Start(ctx context.Context) error {
 		case <-ticker.C:
 			events, err := r.repo.GetAllPendingEvents(ctx, maxEvents)
 			if err != nil {
-				newBackOff, backOffErr := r.backOff(ctx, currentBackoff)
-				currentBackoff = newBackOff
-
-				if backOffErr != nil {
+				if backOffErr := retry.Wait(ctx, err); backOffErr != nil {
 					return errors.Join(err, backOffErr)
 				}
 
 				continue
 			}
 
-			currentBackoff = r.backoffCfg.Min
+			retry.Reset()
 			if len(events) == 0 {
 				continue
 			}
@@ -109,9 +119,17 @@ func (r *Relay) processSingleEvent(ctx context.Context, event outbox.Event) {
 	ctx, span := r.restoreTraceContext(ctx, &event)
 	defer span.End()
 
-	r.logger.InfoContext(ctx, "Sending message to stream", "message_id", event.ID)
+	r.logger.InfoContext(ctx, "Sending message to stream", "message_id", event.ID, "priority", event.Priority)
+
+	producer, ok := r.producers[event.Priority]
+	if !ok {
+		r.logger.ErrorContext(ctx, "Event has an unknown priority", "message_id", event.ID, "priority", event.Priority)
 
-	err := r.producer.Publish(ctx, event.Payload)
+		_ = r.saveProcessingError(ctx, event, ErrUnknownPriority)
+		return
+	}
+
+	err := producer.Publish(ctx, event.Payload)
 	if err != nil {
 		r.logger.ErrorContext(ctx, "Failed to publish message", "message_id", event.ID, "error", err)
 
@@ -161,23 +179,18 @@ func (r *Relay) restoreTraceContext(ctx context.Context, event *outbox.Event) (c
 	)
 }
 
-func (r *Relay) backOff(ctx context.Context, currentBackoff time.Duration) (time.Duration, error) {
-	jitter := time.Duration(rand.Int63n(int64(currentBackoff) / 5))
-	sleepTime := currentBackoff + jitter
-
-	r.logger.Info("Backoff active", "sleep_time", sleepTime.String())
-
-	select {
-	case <-ctx.Done():
-		return 0, ctx.Err()
-	case <-time.After(sleepTime):
-		// Time's up - continuing work
+// LastHeartbeat returns when the relay loop last ran, zero before it started.
+func (r *Relay) LastHeartbeat() time.Time {
+	beat := r.heartbeat.Load()
+	if beat == 0 {
+		return time.Time{}
 	}
 
-	currentBackoff *= time.Duration(r.backoffCfg.Factor)
-	if currentBackoff > r.backoffCfg.Max {
-		currentBackoff = r.backoffCfg.Max
-	}
+	return time.Unix(0, beat)
+}
 
-	return currentBackoff, nil
+// HeartbeatTimeout is how long the relay loop may go without running: a poll
+// interval, a backoff at its longest and the events of the poll being sent.
+func (r *Relay) HeartbeatTimeout() time.Duration {
+	return r.backoff.cfg.PollInterval + r.backoff.cfg.Max + 30*time.Second
 }
@@ -0,0 +1,71 @@
+package manager
+
+import (
+	"ai-orchestrator/internal/common/logger"
+	"ai-orchestrator/internal/config/shared"
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrNilScheduleRunner  = errors.New("schedule runner is nil")
+	ErrNilSchedulerConfig = errors.New("scheduler config is nil")
+)
+
+type ScheduleRunner interface {
+	FireDue(ctx context.Context, count int) (int, error)
+}
+
+// Scheduler periodically fires the due schedules. Several instances may run
+// at once, every firing is claimed by a single one of them.
+type Scheduler struct {
+	logger logger.Logger
+	runner ScheduleRunner
+	cfg    *shared.SchedulerConfig
+}
+
+func NewScheduler(l logger.Logger, runner ScheduleRunner, cfg *shared.SchedulerConfig) (*Scheduler, error) {
+	if l == nil {
+		return nil, logger.ErrNilLogger
+	}
+	if runner == nil {
+		return nil, ErrNilScheduleRunner
+	}
+	if cfg == nil {
+		return nil, ErrNilSchedulerConfig
+	}
+
+	return &Scheduler{
+		logger: l,
+		runner: runner,
+		cfg:    cfg,
+	}, nil
+}
+
+func (s *Scheduler) Start(ctx context.Context) error {
+	s.logger.Info("Scheduler started", "poll_interval", s.cfg.PollInterval)
+
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Stopping scheduler")
+			return ctx.Err()
+		case <-ticker.C:
+			// A full batch means more schedules may be due, they are fired right away.
+			for {
+				fired, err := s.runner.FireDue(ctx, s.cfg.BatchSize)
+				if err != nil {
+					s.logger.Error("Failed to fire due schedules", "error", err)
+					break
+				}
+				if fired < s.cfg.BatchSize || ctx.Err() != nil {
+					break
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package manager
+
+import (
+	"ai-orchestrator/internal/common/logger"
+	"ai-orchestrator/internal/infra/telemetry/metrics"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Supervisor keeps long-running tasks, such as the consumers of the streams,
+// running: a task that fails is restarted under the backoff until the context
+// ends. A task that ran longer than the maximum delay before failing starts
+// over from the minimum one.
+type Supervisor struct {
+	logger  logger.Logger
+	backoff *Backoff
+
+	mu    sync.Mutex
+	tasks map[string]*supervisedTask
+}
+
+type supervisedTask struct {
+	alive    bool
+	restarts int
+	lastErr  string
+}
+
+// TaskStatus is the state of a supervised task.
+type TaskStatus struct {
+	Alive     bool   `json:"alive"`
+	Restarts  int    `json:"restarts"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+func NewSupervisor(l logger.Logger, backoff *Backoff) (*Supervisor, error) {
+	if l == nil {
+		return nil, logger.ErrNilLogger
+	}
+	if backoff == nil {
+		return nil, ErrNilBackoff
+	}
+
+	return &Supervisor{
+		logger:  l,
+		backoff: backoff,
+		tasks:   make(map[string]*supervisedTask),
+	}, nil
+}
+
+// Run runs the task until the context ends, restarting it whenever it fails
+// or returns early. It blocks until then, and forgets the task when it returns.
+func (s *Supervisor) Run(ctx context.Context, id string, task func(ctx context.Context) error) {
+	retry := s.backoff.NewRetry()
+
+	for {
+		s.update(id, func(t *supervisedTask) { t.alive = true })
+
+		started := time.Now()
+		err := task(ctx)
+		if ctx.Err() != nil {
+			s.remove(id)
+			return
+		}
+		if err == nil {
+			err = errors.New("task returned before the end of its context")
+		}
+
+		s.update(id, func(t *supervisedTask) {
+			t.alive = false
+			t.lastErr = err.Error()
+		})
+
+		if time.Since(started) > s.backoff.cfg.Max {
+			retry.Reset()
+		}
+		delay, _ := retry.Next(err)
+		delay = min(delay, s.backoff.cfg.Max)
+		s.logger.ErrorContext(ctx, "Supervised task failed, restarting", "id", id, "error", err, "restart_in", delay.String())
+
+		select {
+		case <-ctx.Done():
+			s.remove(id)
+			return
+		case <-time.After(delay):
+		}
+
+		s.update(id, func(t *supervisedTask) { t.restarts++ })
+	}
+}
+
+// Alive returns how many of the supervised tasks are running, out of all of them.
+func (s *Supervisor) Alive() (int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	alive := 0
+	for _, t := range s.tasks {
+		if t.alive {
+			alive++
+		}
+	}
+
+	return alive, len(s.tasks)
+}
+
+// Statuses returns the status of every supervised task.
+func (s *Supervisor) Statuses() map[string]TaskStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make(map[string]TaskStatus, len(s.tasks))
+	for id, t := range s.tasks {
+		statuses[id] = TaskStatus{Alive: t.alive, Restarts: t.restarts, LastError: t.lastErr}
+	}
+
+	return statuses
+}
+
+func (s *Supervisor) Collect() []metrics.Sample {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	samples := make([]metrics.Sample, 0, 2*len(s.tasks))
+	for id, t := range s.tasks {
+		labels := map[string]string{"task": id}
+		alive := 0.0
+		if t.alive {
+			alive = 1
+		}
+		samples = append(samples,
+			metrics.Sample{
+				Name:   "supervised_task_alive",
+				Help:   "Whether the task is running: 1 running, 0 waiting to restart.",
+				Type:   metrics.Gauge,
+				Labels: labels,
+				Value:  alive,
+			},
+			metrics.Sample{
+				Name:   "supervised_task_restarts_total",
+				Help:   "Number of times the task was restarted after a failure.",
+				Type:   metrics.Counter,
+				Labels: labels,
+				Value:  float64(t.restarts),
+			},
+		)
+	}
+
+	return samples
+}
+
+func (s *Supervisor) update(id string, change func(t *supervisedTask)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tasks[id]
+	if !ok {
+		t = &supervisedTask{}
+		s.tasks[id] = t
+	}
+	change(t)
+}
+
+func (s *Supervisor) remove(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.tasks, id)
+}
@@ -0,0 +1,48 @@
+package batch
+
+import (
+	"ai-orchestrator/internal/domain/model"
+	"github.com/google/uuid"
+	"time"
+)
+
+// Batch is a row of batches. The counters and the update time are not stored,
+// they are read from the prompts of the batch.
+type Batch struct {
+	ID        uuid.UUID `db:"id"`
+	UserID    uuid.UUID `db:"user_id"`
+	Total     int       `db:"total"`
+	Completed int       `db:"completed"`
+	Failed    int       `db:"failed"`
+	Discarded int       `db:"discarded"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+func FromDomain(d model.Batch) Batch {
+	return Batch{
+		ID:        d.ID,
+		UserID:    d.UserID,
+		Total:     d.Total,
+		CreatedAt: d.CreatedAt,
+	}
+}
+
+func (b *Batch) ToDomain() model.Batch {
+	batch := model.Batch{
+		ID:        b.ID,
+		UserID:    b.UserID,
+		Total:     b.Total,
+		Completed: b.Completed,
+		Failed:    b.Failed,
+		Discarded: b.Discarded,
+		CreatedAt: b.CreatedAt,
+		UpdatedAt: b.UpdatedAt,
+	}
+	batch.Status = model.BatchProcessing
+	if batch.Pending() == 0 {
+		batch.Status = model.BatchCompleted
+	}
+
+	return batch
+}
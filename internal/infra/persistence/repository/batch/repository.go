@@ -0,0 +1,80 @@
+package batch
+
+import (
+	"ai-orchestrator/internal/common/logger"
+	"ai-orchestrator/internal/domain/model"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type Repository struct {
+	logger logger.Logger
+	db     *sqlx.DB
+}
+
+func NewRepository(l logger.Logger, db *sqlx.DB) (*Repository, error) {
+	if l == nil {
+		return nil, logger.ErrNilLogger
+	}
+	if db == nil {
+		return nil, errors.New("db is nil")
+	}
+
+	return &Repository{
+		logger: l,
+		db:     db,
+	}, nil
+}
+
+func (r *Repository) InsertBatch(ctx context.Context, batch model.Batch) error {
+	dbBatch := FromDomain(batch)
+	dbBatch.CreatedAt = time.Now().UTC()
+
+	query := `
+		INSERT INTO batches (id, user_id, total, created_at)
+		VALUES (:id, :user_id, :total, :created_at)
+	`
+
+	r.logger.InfoContext(ctx, "executing query to insert new batch", "batch_id", dbBatch.ID, "total", dbBatch.Total, "repository", "batchRepository")
+
+	_, err := r.db.NamedExecContext(ctx, query, dbBatch)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed to insert new batch", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetBatchByID returns the batch with its progress, counted from the statuses
+// of its prompts so that it can't drift from them.
+func (r *Repository) GetBatchByID(ctx context.Context, id uuid.UUID) (*model.Batch, error) {
+	var dbBatch Batch
+	query := `
+		SELECT b.id, b.user_id, b.total, b.created_at,
+		       COUNT(p.id) FILTER (WHERE p.status = $2) AS completed,
+		       COUNT(p.id) FILTER (WHERE p.status = $3) AS failed,
+		       COUNT(p.id) FILTER (WHERE p.status = $4) AS discarded,
+		       GREATEST(b.created_at, MAX(p.updated_at)) AS updated_at
+		FROM batches b
+		LEFT JOIN prompts p ON p.batch_id = b.id
+		WHERE b.id = $1
+		GROUP BY b.id
+	`
+
+	err := r.db.GetContext(ctx, &dbBatch, query, id, model.Completed, model.Failed, model.Discarded)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrBatchNotFound
+		}
+		r.logger.ErrorContext(ctx, "failed to get batch", "error", err, "batch_id", id)
+		return nil, err
+	}
+
+	domainBatch := dbBatch.ToDomain()
+	return &domainBatch, nil
+}
@@ -13,6 +13,7 @@ var (
 	Pending   Status = "pending"
 	Failed    Status = "failed"
 	Processed Status = "processed"
+	Discarded Status = "discarded"
 )
 
 type Event struct {
@@ -27,12 +28,18 @@ type Event struct {
 
 	Status Status `db:"status"`
 
+	// Priority selects the stream the event is published to.
+	Priority string `db:"priority"`
+
 	TraceID    string `db:"trace_id"`
 	RetryCount int    `db:"retry_count"`
 
 	// Use pointers for Nullable columns
 	ErrorMessage *string `db:"error_message"`
 
-	CreatedAt   time.Time  `db:"created_at"`
+	CreatedAt time.Time `db:"created_at"`
+
+	// AvailableAt delays the publication of the event, it defaults to the creation time.
+	AvailableAt time.Time  `db:"available_at"`
 	ProcessedAt *time.Time `db:"processed_at"`
 }
@@ -31,8 +31,8 @@ func NewRepository(l logger.Logger, db *sqlx.DB) (*Repository, error) {
 func (r *Repository) GetAllPendingEvents(ctx context.Context, count int) ([]Event, error) {
 	query := `
         SELECT * FROM outbox 
-        WHERE status = 'pending' 
-        ORDER BY created_at ASC 
+        WHERE status = 'pending' AND available_at <= NOW() 
+        ORDER BY CASE priority WHEN 'high' THEN 0 WHEN 'normal' THEN 1 ELSE 2 END, created_at ASC 
         LIMIT $1 
         FOR UPDATE SKIP LOCKED
     `
@@ -49,35 +49,56 @@ func (r *Repository) GetAllPendingEvents(ctx context.Context, count int) ([]Even
 }
 
 func (r *Repository) CreateEvent(ctx context.Context, event Event) error {
-	if event.CreatedAt.IsZero() {
-		event.CreatedAt = time.Now().UTC()
-	}
+	return r.CreateEvents(ctx, []Event{event})
+}
 
-	if event.Status == "" {
-		event.Status = "pending"
+// CreateEvents saves the events with a single statement.
+func (r *Repository) CreateEvents(ctx context.Context, events []Event) error {
+	if len(events) == 0 {
+		return nil
 	}
 
 	span := trace.SpanFromContext(ctx)
 	traceID := span.SpanContext().TraceID().String()
+	now := time.Now().UTC()
+
+	for i := range events {
+		event := &events[i]
+		if event.CreatedAt.IsZero() {
+			event.CreatedAt = now
+		}
+
+		if event.AvailableAt.IsZero() {
+			event.AvailableAt = event.CreatedAt
+		}
+
+		if event.Status == "" {
+			event.Status = "pending"
+		}
 
-	event.TraceID = traceID
+		if event.Priority == "" {
+			event.Priority = "normal"
+		}
+
+		event.TraceID = traceID
+	}
 
 	query := `
        INSERT INTO outbox (
            id, aggregate_type, aggregate_id, event_type, 
-           payload, status, trace_id, retry_count, error_message, 
-           created_at, processed_at
+           payload, status, priority, trace_id, retry_count, error_message, 
+           created_at, available_at, processed_at
        )
        VALUES (
            :id, :aggregate_type, :aggregate_id, :event_type, 
-           :payload, :status, :trace_id, :retry_count, :error_message, 
-           :created_at, :processed_at
+           :payload, :status, :priority, :trace_id, :retry_count, :error_message, 
+           :created_at, :available_at, :processed_at
        )
     `
 
-	_, err := r.db.NamedExecContext(ctx, query, event)
+	_, err := r.db.NamedExecContext(ctx, query, events)
 	if err != nil {
-		r.logger.ErrorContext(ctx, "failed to insert outbox event", "error", err, "event_id", event.ID)
+		r.logger.ErrorContext(ctx, "failed to insert outbox events", "error", err, "count", len(events))
 		return err
 	}
 
@@ -108,6 +129,25 @@ func (r *Repository) ChangeEventStatus(ctx context.Context, eventID uuid.UUID, e
 	return nil
 }
 
+// DiscardEvents drops the pending events of the aggregate, so a delayed task
+// that is no longer wanted is never published.
+func (r *Repository) DiscardEvents(ctx context.Context, aggregateID uuid.UUID) error {
+	query := `
+        UPDATE outbox 
+        SET status = $2, 
+            processed_at = NOW() 
+        WHERE aggregate_id = $1 AND status = 'pending'
+    `
+
+	_, err := r.db.ExecContext(ctx, query, aggregateID, Discarded)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed to discard events", "error", err, "aggregate_id", aggregateID)
+		return err
+	}
+
+	return nil
+}
+
 func (r *Repository) IncrementRetryCount(ctx context.Context, eventID uuid.UUID, errorMessage string) error {
 	query := `
         UPDATE outbox 
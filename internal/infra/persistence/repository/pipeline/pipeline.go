@@ -0,0 +1,134 @@
+package pipeline
+
+import (
+	"ai-orchestrator/internal/domain/model"
+	"encoding/json"
+	"github.com/google/uuid"
+	"time"
+)
+
+type Pipeline struct {
+	ID        uuid.UUID            `db:"id"`
+	UserID    uuid.UUID            `db:"user_id"`
+	Status    model.PipelineStatus `db:"status"`
+	Priority  model.Priority       `db:"priority"`
+	Variables json.RawMessage      `db:"variables"`
+	CreatedAt time.Time            `db:"created_at"`
+	UpdatedAt time.Time            `db:"updated_at"`
+}
+
+type Step struct {
+	PipelineID      uuid.UUID        `db:"pipeline_id"`
+	Name            string           `db:"name"`
+	Position        int              `db:"position"`
+	ModelID         string           `db:"model_id"`
+	Body            string           `db:"body"`
+	TemplateID      *uuid.UUID       `db:"template_id"`
+	TemplateVersion *int             `db:"template_version"`
+	Options         json.RawMessage  `db:"options"`
+	DependsOn       json.RawMessage  `db:"depends_on"`
+	Status          model.StepStatus `db:"status"`
+	PromptID        *uuid.UUID       `db:"prompt_id"`
+	Error           string           `db:"error"`
+
+	// Read from the prompt of the step.
+	Response        *string          `db:"response"`
+	PromptError     *string          `db:"prompt_error"`
+	PromptErrorCode *model.ErrorCode `db:"error_code"`
+}
+
+func FromDomain(d model.Pipeline) (Pipeline, []Step) {
+	p := Pipeline{
+		ID:        d.ID,
+		UserID:    d.UserID,
+		Status:    d.Status,
+		Priority:  d.Priority,
+		CreatedAt: d.CreatedAt,
+		UpdatedAt: d.UpdatedAt,
+	}
+	p.Variables, _ = json.Marshal(d.Variables)
+	if d.Variables == nil {
+		p.Variables = json.RawMessage("{}")
+	}
+
+	steps := make([]Step, 0, len(d.Steps))
+	for i, s := range d.Steps {
+		step := Step{
+			PipelineID: d.ID,
+			Name:       s.Name,
+			Position:   i,
+			ModelID:    s.ModelID,
+			Body:       s.Body,
+			Status:     s.Status,
+			PromptID:   s.PromptID,
+			Error:      s.Error,
+		}
+		if s.Template != nil {
+			step.TemplateID = &s.Template.ID
+			step.TemplateVersion = &s.Template.Version
+		}
+		step.Options, _ = json.Marshal(s.Options)
+		step.DependsOn = json.RawMessage("[]")
+		if len(s.DependsOn) > 0 {
+			step.DependsOn, _ = json.Marshal(s.DependsOn)
+		}
+		steps = append(steps, step)
+	}
+
+	return p, steps
+}
+
+func (p *Pipeline) ToDomain(steps []Step) model.Pipeline {
+	d := model.Pipeline{
+		ID:        p.ID,
+		UserID:    p.UserID,
+		Status:    p.Status,
+		Priority:  p.Priority,
+		CreatedAt: p.CreatedAt,
+		UpdatedAt: p.UpdatedAt,
+		Steps:     make([]model.PipelineStep, 0, len(steps)),
+	}
+	if len(p.Variables) > 0 {
+		_ = json.Unmarshal(p.Variables, &d.Variables)
+	}
+
+	for _, s := range steps {
+		d.Steps = append(d.Steps, s.ToDomain())
+	}
+
+	return d
+}
+
+func (s *Step) ToDomain() model.PipelineStep {
+	d := model.PipelineStep{
+		Name:     s.Name,
+		ModelID:  s.ModelID,
+		Body:     s.Body,
+		Status:   s.Status,
+		PromptID: s.PromptID,
+		Error:    s.Error,
+	}
+	if s.TemplateID != nil && s.TemplateVersion != nil {
+		d.Template = &model.TemplateRef{
+			ID:      *s.TemplateID,
+			Version: *s.TemplateVersion,
+		}
+	}
+	if len(s.Options) > 0 {
+		_ = json.Unmarshal(s.Options, &d.Options)
+	}
+	if len(s.DependsOn) > 0 {
+		_ = json.Unmarshal(s.DependsOn, &d.DependsOn)
+	}
+	if s.Response != nil {
+		d.Response = *s.Response
+	}
+	if s.PromptError != nil && *s.PromptError != "" {
+		d.Error = *s.PromptError
+	}
+	if s.PromptErrorCode != nil {
+		d.ErrorCode = *s.PromptErrorCode
+	}
+
+	return d
+}
@@ -0,0 +1,187 @@
+package pipeline
+
+import (
+	"ai-orchestrator/internal/common/logger"
+	"ai-orchestrator/internal/domain/model"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+type Repository struct {
+	logger logger.Logger
+	db     *sqlx.DB
+}
+
+func NewRepository(l logger.Logger, db *sqlx.DB) (*Repository, error) {
+	if l == nil {
+		return nil, logger.ErrNilLogger
+	}
+	if db == nil {
+		return nil, errors.New("db is nil")
+	}
+
+	return &Repository{
+		logger: l,
+		db:     db,
+	}, nil
+}
+
+// InsertPipeline saves the pipeline along with its steps, in one transaction.
+func (r *Repository) InsertPipeline(ctx context.Context, pipeline model.Pipeline) error {
+	dbPipeline, dbSteps := FromDomain(pipeline)
+	dbPipeline.CreatedAt = time.Now().UTC()
+	dbPipeline.UpdatedAt = dbPipeline.CreatedAt
+
+	r.logger.InfoContext(ctx, "executing query to insert new pipeline", "pipeline_id", dbPipeline.ID, "steps", len(dbSteps), "repository", "pipelineRepository")
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed to begin pipeline transaction", "error", err)
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query := `
+		INSERT INTO pipelines (id, user_id, status, priority, variables, created_at, updated_at)
+		VALUES (:id, :user_id, :status, :priority, :variables, :created_at, :updated_at)
+	`
+
+	_, err = tx.NamedExecContext(ctx, query, dbPipeline)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed to insert new pipeline", "error", err)
+		return err
+	}
+
+	query = `
+		INSERT INTO pipeline_steps (pipeline_id, name, position, model_id, body, template_id, template_version, options, depends_on, status, prompt_id, error)
+		VALUES (:pipeline_id, :name, :position, :model_id, :body, :template_id, :template_version, :options, :depends_on, :status, :prompt_id, :error)
+	`
+
+	_, err = tx.NamedExecContext(ctx, query, dbSteps)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed to insert pipeline steps", "error", err, "pipeline_id", dbPipeline.ID)
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed to commit pipeline", "error", err, "pipeline_id", dbPipeline.ID)
+		return err
+	}
+
+	return nil
+}
+
+// GetPipelineByID returns the pipeline with its steps in the submitted order,
+// along with the results of their prompts.
+func (r *Repository) GetPipelineByID(ctx context.Context, id uuid.UUID) (*model.Pipeline, error) {
+	var dbPipeline Pipeline
+	query := `
+		SELECT id, user_id, status, priority, variables, created_at, updated_at
+		FROM pipelines
+		WHERE id = $1
+	`
+
+	err := r.db.GetContext(ctx, &dbPipeline, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrPipelineNotFound
+		}
+		r.logger.ErrorContext(ctx, "failed to get pipeline", "error", err, "pipeline_id", id)
+		return nil, err
+	}
+
+	var dbSteps []Step
+	query = `
+		SELECT s.pipeline_id, s.name, s.position, s.model_id, s.body, s.template_id, s.template_version, s.options,
+		       s.depends_on, s.status, s.prompt_id, s.error, p.response, p.error AS prompt_error, p.error_code
+		FROM pipeline_steps s
+		LEFT JOIN prompts p ON p.id = s.prompt_id
+		WHERE s.pipeline_id = $1
+		ORDER BY s.position
+	`
+
+	err = r.db.SelectContext(ctx, &dbSteps, query, id)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed to get pipeline steps", "error", err, "pipeline_id", id)
+		return nil, err
+	}
+
+	domainPipeline := dbPipeline.ToDomain(dbSteps)
+	return &domainPipeline, nil
+}
+
+// StartStep moves a pending step to running with the prompt about to be
+// submitted. It reports false when the step was already started, so a step
+// whose dependencies complete concurrently is submitted once.
+func (r *Repository) StartStep(ctx context.Context, id uuid.UUID, name string, promptID uuid.UUID) (bool, error) {
+	query := `
+		UPDATE pipeline_steps
+		SET status = $4,
+		    prompt_id = $3
+		WHERE pipeline_id = $1 AND name = $2 AND status = $5
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, name, promptID, model.StepRunning, model.StepPending)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed to start pipeline step", "error", err, "pipeline_id", id, "step", name)
+		return false, err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0, nil
+}
+
+// FinishStep records the final status of a running step. It reports false
+// when the step was not running, for a result delivered twice.
+func (r *Repository) FinishStep(ctx context.Context, id uuid.UUID, name string, status model.StepStatus, message string) (bool, error) {
+	query := `
+		UPDATE pipeline_steps
+		SET status = $3,
+		    error = $4
+		WHERE pipeline_id = $1 AND name = $2 AND status = $5
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, name, status, message, model.StepRunning)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed to finish pipeline step", "error", err, "pipeline_id", id, "step", name)
+		return false, err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0, nil
+}
+
+// FinishPipeline sets the final status of the pipeline, and skips the steps that will never run.
+func (r *Repository) FinishPipeline(ctx context.Context, id uuid.UUID, status model.PipelineStatus) error {
+	query := `
+		UPDATE pipelines
+		SET status = $2,
+		    updated_at = $3
+		WHERE id = $1 AND status = $4
+	`
+
+	_, err := r.db.ExecContext(ctx, query, id, status, time.Now().UTC(), model.PipelineProcessing)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed to finish pipeline", "error", err, "pipeline_id", id, "status", status)
+		return err
+	}
+
+	query = `
+		UPDATE pipeline_steps
+		SET status = $2
+		WHERE pipeline_id = $1 AND status = $3
+	`
+
+	_, err = r.db.ExecContext(ctx, query, id, model.StepSkipped, model.StepPending)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed to skip pipeline steps", "error", err, "pipeline_id", id)
+		return err
+	}
+
+	return nil
+}
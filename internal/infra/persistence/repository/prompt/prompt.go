@@ -2,42 +2,157 @@ package prompt
 
 import (
 	"ai-orchestrator/internal/domain/model"
+	"encoding/json"
 	"github.com/google/uuid"
 	"time"
 )
 
 type Prompt struct {
-	ID        uuid.UUID    `db:"id"`
-	UserID    uuid.UUID    `db:"user_id"`
-	ModelID   string       `db:"model_id"`
-	Text      string       `db:"text"`
-	Response  string       `db:"response"`
-	Status    model.Status `db:"status"`
-	Error     string       `db:"error"`
-	CreatedAt time.Time    `db:"created_at"`
-	UpdatedAt time.Time    `db:"updated_at"`
+	ID          uuid.UUID       `db:"id"`
+	UserID      uuid.UUID       `db:"user_id"`
+	ModelID     string          `db:"model_id"`
+	Text        string          `db:"text"`
+	Response    string          `db:"response"`
+	Status      model.Status    `db:"status"`
+	Error       string          `db:"error"`
+	ErrorCode   model.ErrorCode `db:"error_code"`
+	Options     json.RawMessage `db:"options"`
+	Attachments json.RawMessage `db:"attachments"`
+	Tools       json.RawMessage `db:"tools"`
+	ToolCalls   json.RawMessage `db:"tool_calls"`
+	Deadline    *time.Time      `db:"deadline"`
+	Cached      bool            `db:"cached"`
+	Priority    model.Priority  `db:"priority"`
+
+	ServedModelID string `db:"served_model_id"`
+
+	StructuredOutput *json.RawMessage `db:"structured_output"`
+
+	RunAt      *time.Time `db:"run_at"`
+	ScheduleID *uuid.UUID `db:"schedule_id"`
+
+	BatchID    *uuid.UUID `db:"batch_id"`
+	BatchIndex *int       `db:"batch_index"`
+
+	PipelineID   *uuid.UUID `db:"pipeline_id"`
+	PipelineStep *string    `db:"pipeline_step"`
+
+	TemplateID      *uuid.UUID `db:"template_id"`
+	TemplateVersion *int       `db:"template_version"`
+
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
 }
 
 func FromDomain(d model.Prompt) Prompt {
-	return Prompt{
-		ID:       d.ID,
-		UserID:   d.UserID,
-		ModelID:  d.ModelID,
-		Text:     d.Text,
-		Response: d.Response,
-		Status:   d.Status,
-		Error:    d.Error,
+	p := Prompt{
+		ID:        d.ID,
+		UserID:    d.UserID,
+		ModelID:   d.ModelID,
+		Text:      d.Text,
+		Response:  d.Response,
+		Status:    d.Status,
+		Error:     d.Error,
+		ErrorCode: d.ErrorCode,
+		Cached:    d.Cached,
+		Priority:  d.Priority,
+
+		ServedModelID: d.ServedModelID,
+	}
+	if !d.Deadline.IsZero() {
+		p.Deadline = &d.Deadline
+	}
+	p.Options, _ = json.Marshal(d.Options)
+	if !d.RunAt.IsZero() {
+		p.RunAt = &d.RunAt
+	}
+	p.ScheduleID = d.ScheduleID
+	if d.Batch != nil {
+		p.BatchID = &d.Batch.ID
+		p.BatchIndex = &d.Batch.Index
+	}
+	if d.Pipeline != nil {
+		p.PipelineID = &d.Pipeline.ID
+		p.PipelineStep = &d.Pipeline.Step
+	}
+	if d.Template != nil {
+		p.TemplateID = &d.Template.ID
+		p.TemplateVersion = &d.Template.Version
+	}
+	p.Attachments = json.RawMessage("[]")
+	if len(d.Attachments) > 0 {
+		p.Attachments, _ = json.Marshal(d.Attachments)
+	}
+	p.Tools = json.RawMessage("[]")
+	if len(d.Tools) > 0 {
+		p.Tools, _ = json.Marshal(d.Tools)
 	}
+	p.ToolCalls = json.RawMessage("[]")
+	if len(d.ToolCalls) > 0 {
+		p.ToolCalls, _ = json.Marshal(d.ToolCalls)
+	}
+	if len(d.StructuredOutput) > 0 {
+		p.StructuredOutput = &d.StructuredOutput
+	}
+
+	return p
 }
 
 func (p *Prompt) ToDomain() model.Prompt {
-	return model.Prompt{
-		ID:       p.ID,
-		UserID:   p.UserID,
-		ModelID:  p.ModelID,
-		Text:     p.Text,
-		Response: p.Response,
-		Status:   p.Status,
-		Error:    p.Error,
+	d := model.Prompt{
+		ID:        p.ID,
+		UserID:    p.UserID,
+		ModelID:   p.ModelID,
+		Text:      p.Text,
+		Response:  p.Response,
+		Status:    p.Status,
+		Error:     p.Error,
+		ErrorCode: p.ErrorCode,
+		Cached:    p.Cached,
+		Priority:  p.Priority,
+
+		ServedModelID: p.ServedModelID,
+	}
+	if p.Deadline != nil {
+		d.Deadline = *p.Deadline
+	}
+	if p.RunAt != nil {
+		d.RunAt = *p.RunAt
+	}
+	d.ScheduleID = p.ScheduleID
+	if len(p.Options) > 0 {
+		_ = json.Unmarshal(p.Options, &d.Options)
+	}
+	if len(p.Attachments) > 0 {
+		_ = json.Unmarshal(p.Attachments, &d.Attachments)
 	}
+	if len(p.Tools) > 0 {
+		_ = json.Unmarshal(p.Tools, &d.Tools)
+	}
+	if len(p.ToolCalls) > 0 {
+		_ = json.Unmarshal(p.ToolCalls, &d.ToolCalls)
+	}
+	if p.StructuredOutput != nil {
+		d.StructuredOutput = *p.StructuredOutput
+	}
+	if p.BatchID != nil && p.BatchIndex != nil {
+		d.Batch = &model.BatchRef{
+			ID:    *p.BatchID,
+			Index: *p.BatchIndex,
+		}
+	}
+	if p.PipelineID != nil && p.PipelineStep != nil {
+		d.Pipeline = &model.PipelineRef{
+			ID:   *p.PipelineID,
+			Step: *p.PipelineStep,
+		}
+	}
+	if p.TemplateID != nil && p.TemplateVersion != nil {
+		d.Template = &model.TemplateRef{
+			ID:      *p.TemplateID,
+			Version: *p.TemplateVersion,
+		}
+	}
+
+	return d
 }
@@ -4,13 +4,14 @@ import (
 	"ai-orchestrator/internal/common/logger"
 	"ai-orchestrator/internal/domain/model"
 	"context"
+	"database/sql"
 	"errors"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"time"
 )
 
-var ErrPromptNotFound = errors.New("prompt not found")
+var ErrPromptNotFound = model.ErrPromptNotFound
 
 type Repository struct {
 	logger logger.Logger
@@ -34,13 +35,16 @@ func NewRepository(l logger.Logger, db *sqlx.DB) (*Repository, error) {
 func (r *Repository) GetPromptByID(ctx context.Context, id uuid.UUID) (*model.Prompt, error) {
 	var prompt Prompt
 	query := `
-		SELECT id, user_id, model_id, text, response, status, error, created_at, updated_at 
+		SELECT id, user_id, model_id, text, response, served_model_id, status, error, error_code, options, attachments, tools, tool_calls, structured_output, batch_id, batch_index, run_at, schedule_id, pipeline_id, pipeline_step, template_id, template_version, deadline, cached, priority, created_at, updated_at 
 		FROM prompts 
 		WHERE id = $1
 	`
 
 	err := r.db.GetContext(ctx, &prompt, query, id)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPromptNotFound
+		}
 		return nil, err
 	}
 
@@ -54,8 +58,8 @@ func (r *Repository) InsertPrompt(ctx context.Context, prompt model.Prompt) erro
 	dbPrompt.UpdatedAt = dbPrompt.CreatedAt
 
 	query := `
-		INSERT INTO prompts (id, user_id, model_id, text, response, status, error, created_at, updated_at)
-		VALUES (:id, :user_id, :model_id, :text, :response, :status, :error, :created_at, :updated_at)
+		INSERT INTO prompts (id, user_id, model_id, text, response, served_model_id, status, error, error_code, options, attachments, tools, tool_calls, structured_output, batch_id, batch_index, run_at, schedule_id, pipeline_id, pipeline_step, template_id, template_version, deadline, cached, priority, created_at, updated_at)
+		VALUES (:id, :user_id, :model_id, :text, :response, :served_model_id, :status, :error, :error_code, :options, :attachments, :tools, :tool_calls, :structured_output, :batch_id, :batch_index, :run_at, :schedule_id, :pipeline_id, :pipeline_step, :template_id, :template_version, :deadline, :cached, :priority, :created_at, :updated_at)
 	`
 
 	r.logger.InfoContext(ctx, "executing query to insert new prompt", "query", query, "repository", "promptRepository")
@@ -69,31 +73,117 @@ func (r *Repository) InsertPrompt(ctx context.Context, prompt model.Prompt) erro
 	return nil
 }
 
-func (r *Repository) UpdatePrompt(ctx context.Context, prompt model.Prompt) error {
-	dbPrompt := FromDomain(prompt)
+// InsertPrompts saves the prompts with a single statement.
+func (r *Repository) InsertPrompts(ctx context.Context, prompts []model.Prompt) error {
+	if len(prompts) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	dbPrompts := make([]Prompt, 0, len(prompts))
+	for _, p := range prompts {
+		dbPrompt := FromDomain(p)
+		dbPrompt.CreatedAt = now
+		dbPrompt.UpdatedAt = now
+		dbPrompts = append(dbPrompts, dbPrompt)
+	}
+
+	query := `
+		INSERT INTO prompts (id, user_id, model_id, text, response, served_model_id, status, error, error_code, options, attachments, tools, tool_calls, structured_output, batch_id, batch_index, run_at, schedule_id, pipeline_id, pipeline_step, template_id, template_version, deadline, cached, priority, created_at, updated_at)
+		VALUES (:id, :user_id, :model_id, :text, :response, :served_model_id, :status, :error, :error_code, :options, :attachments, :tools, :tool_calls, :structured_output, :batch_id, :batch_index, :run_at, :schedule_id, :pipeline_id, :pipeline_step, :template_id, :template_version, :deadline, :cached, :priority, :created_at, :updated_at)
+	`
+
+	r.logger.InfoContext(ctx, "executing query to insert prompts", "count", len(dbPrompts), "repository", "promptRepository")
+
+	_, err := r.db.NamedExecContext(ctx, query, dbPrompts)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed to insert prompts", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetPromptsByBatch returns the prompts of the batch in the submitted order.
+func (r *Repository) GetPromptsByBatch(ctx context.Context, batchID uuid.UUID) ([]model.Prompt, error) {
+	var prompts []Prompt
+	query := `
+		SELECT id, user_id, model_id, text, response, served_model_id, status, error, error_code, options, attachments, tools, tool_calls, structured_output, batch_id, batch_index, run_at, schedule_id, pipeline_id, pipeline_step, template_id, template_version, deadline, cached, priority, created_at, updated_at 
+		FROM prompts 
+		WHERE batch_id = $1
+		ORDER BY batch_index
+	`
+
+	err := r.db.SelectContext(ctx, &prompts, query, batchID)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed to get prompts by batch", "error", err, "batch_id", batchID)
+		return nil, err
+	}
+
+	domainPrompts := make([]model.Prompt, 0, len(prompts))
+	for _, p := range prompts {
+		domainPrompts = append(domainPrompts, p.ToDomain())
+	}
+
+	return domainPrompts, nil
+}
+
+// UpdatePrompt saves the result of the prompt only if it is still in the
+// expected status. Returns false when the prompt has been changed concurrently,
+// e.g. discarded while it was generated.
+func (r *Repository) UpdatePrompt(ctx context.Context, prompt model.Prompt, expected model.Status) (bool, error) {
+	dbPrompt := struct {
+		Prompt
+		ExpectedStatus model.Status `db:"expected_status"`
+	}{
+		Prompt:         FromDomain(prompt),
+		ExpectedStatus: expected,
+	}
 	dbPrompt.UpdatedAt = time.Now().UTC()
 
 	query := `
         UPDATE prompts 
         SET response = :response, 
+            served_model_id = :served_model_id,
             status = :status,
             error = :error,
+            error_code = :error_code,
+            tool_calls = :tool_calls,
+            structured_output = :structured_output,
             updated_at = :updated_at
-        WHERE id = :id
+        WHERE id = :id AND status = :expected_status
     `
 
-	r.logger.InfoContext(ctx, "executing query to update prompt", "query", query, "prompt_id", dbPrompt.ID)
+	r.logger.InfoContext(ctx, "executing query to update prompt", "query", query, "prompt_id", dbPrompt.ID, "expected", expected)
 
 	result, err := r.db.NamedExecContext(ctx, query, dbPrompt)
 	if err != nil {
 		r.logger.ErrorContext(ctx, "failed to update prompt", "error", err, "id", dbPrompt.ID)
-		return err
+		return false, err
 	}
 
 	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return ErrPromptNotFound
+	return rowsAffected > 0, nil
+}
+
+// UpdatePromptStatus moves the prompt to the new status only if it is still in
+// the expected one. Returns false when the prompt has been changed concurrently.
+func (r *Repository) UpdatePromptStatus(ctx context.Context, id uuid.UUID, expected, status model.Status) (bool, error) {
+	query := `
+        UPDATE prompts 
+        SET status = $3,
+            updated_at = $4
+        WHERE id = $1 AND status = $2
+    `
+
+	r.logger.InfoContext(ctx, "executing query to update prompt status", "prompt_id", id, "expected", expected, "status", status)
+
+	result, err := r.db.ExecContext(ctx, query, id, expected, status, time.Now().UTC())
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed to update prompt status", "error", err, "id", id)
+		return false, err
 	}
 
-	return nil
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0, nil
 }
@@ -0,0 +1,160 @@
+package schedule
+
+import (
+	"ai-orchestrator/internal/common/logger"
+	"ai-orchestrator/internal/domain/model"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+const columns = `id, user_id, cron, model_id, text, options, priority, timeout_ms, template_id, template_version, variables, next_run_at, last_run_at, created_at, deleted_at`
+
+type Repository struct {
+	logger logger.Logger
+	db     *sqlx.DB
+}
+
+func NewRepository(l logger.Logger, db *sqlx.DB) (*Repository, error) {
+	if l == nil {
+		return nil, logger.ErrNilLogger
+	}
+	if db == nil {
+		return nil, errors.New("db is nil")
+	}
+
+	return &Repository{
+		logger: l,
+		db:     db,
+	}, nil
+}
+
+func (r *Repository) InsertSchedule(ctx context.Context, schedule model.Schedule) error {
+	dbSchedule := FromDomain(schedule)
+	dbSchedule.CreatedAt = time.Now().UTC()
+
+	query := `
+		INSERT INTO schedules (id, user_id, cron, model_id, text, options, priority, timeout_ms, template_id, template_version, variables, next_run_at, created_at)
+		VALUES (:id, :user_id, :cron, :model_id, :text, :options, :priority, :timeout_ms, :template_id, :template_version, :variables, :next_run_at, :created_at)
+	`
+
+	r.logger.InfoContext(ctx, "executing query to insert new schedule", "schedule_id", dbSchedule.ID, "repository", "scheduleRepository")
+
+	_, err := r.db.NamedExecContext(ctx, query, dbSchedule)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed to insert new schedule", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *Repository) GetScheduleByID(ctx context.Context, id uuid.UUID) (*model.Schedule, error) {
+	var dbSchedule Schedule
+	query := `
+		SELECT ` + columns + `
+		FROM schedules
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+
+	err := r.db.GetContext(ctx, &dbSchedule, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrScheduleNotFound
+		}
+		r.logger.ErrorContext(ctx, "failed to get schedule", "error", err, "schedule_id", id)
+		return nil, err
+	}
+
+	domainSchedule := dbSchedule.ToDomain()
+	return &domainSchedule, nil
+}
+
+func (r *Repository) ListSchedules(ctx context.Context, userID uuid.UUID) ([]model.Schedule, error) {
+	query := `
+		SELECT ` + columns + `
+		FROM schedules
+		WHERE user_id = $1 AND deleted_at IS NULL
+		ORDER BY created_at
+	`
+
+	return r.selectSchedules(ctx, query, userID)
+}
+
+// GetDueSchedules returns the schedules whose next firing time has passed, the most late first.
+func (r *Repository) GetDueSchedules(ctx context.Context, now time.Time, count int) ([]model.Schedule, error) {
+	query := `
+		SELECT ` + columns + `
+		FROM schedules
+		WHERE next_run_at <= $1 AND deleted_at IS NULL
+		ORDER BY next_run_at
+		LIMIT $2
+	`
+
+	return r.selectSchedules(ctx, query, now, count)
+}
+
+func (r *Repository) selectSchedules(ctx context.Context, query string, args ...any) ([]model.Schedule, error) {
+	var dbSchedules []Schedule
+
+	err := r.db.SelectContext(ctx, &dbSchedules, query, args...)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed to select schedules", "error", err)
+		return nil, err
+	}
+
+	schedules := make([]model.Schedule, 0, len(dbSchedules))
+	for _, s := range dbSchedules {
+		schedules = append(schedules, s.ToDomain())
+	}
+
+	return schedules, nil
+}
+
+// AdvanceSchedule moves the schedule from the firing planned at `from` to the
+// next one. It reports false when the firing was already claimed, so a firing
+// happens once even with several instances polling the schedules.
+func (r *Repository) AdvanceSchedule(ctx context.Context, id uuid.UUID, from, next time.Time) (bool, error) {
+	query := `
+		UPDATE schedules
+		SET next_run_at = $3,
+		    last_run_at = $4
+		WHERE id = $1 AND next_run_at = $2 AND deleted_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, from, next, time.Now().UTC())
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed to advance schedule", "error", err, "schedule_id", id)
+		return false, err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0, nil
+}
+
+// DeleteSchedule stops the schedule. The row is kept, since prompts reference it.
+func (r *Repository) DeleteSchedule(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE schedules
+		SET deleted_at = $2
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+
+	r.logger.InfoContext(ctx, "executing query to delete schedule", "schedule_id", id, "repository", "scheduleRepository")
+
+	result, err := r.db.ExecContext(ctx, query, id, time.Now().UTC())
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed to delete schedule", "error", err, "schedule_id", id)
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return model.ErrScheduleNotFound
+	}
+
+	return nil
+}
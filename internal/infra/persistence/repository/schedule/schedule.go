@@ -0,0 +1,83 @@
+package schedule
+
+import (
+	"ai-orchestrator/internal/domain/model"
+	"encoding/json"
+	"github.com/google/uuid"
+	"time"
+)
+
+type Schedule struct {
+	ID       uuid.UUID       `db:"id"`
+	UserID   uuid.UUID       `db:"user_id"`
+	Cron     string          `db:"cron"`
+	ModelID  string          `db:"model_id"`
+	Text     string          `db:"text"`
+	Options  json.RawMessage `db:"options"`
+	Priority model.Priority  `db:"priority"`
+	Timeout  int64           `db:"timeout_ms"`
+
+	TemplateID      *uuid.UUID      `db:"template_id"`
+	TemplateVersion *int            `db:"template_version"`
+	Variables       json.RawMessage `db:"variables"`
+
+	NextRunAt time.Time  `db:"next_run_at"`
+	LastRunAt *time.Time `db:"last_run_at"`
+	CreatedAt time.Time  `db:"created_at"`
+	DeletedAt *time.Time `db:"deleted_at"`
+}
+
+func FromDomain(d model.Schedule) Schedule {
+	s := Schedule{
+		ID:        d.ID,
+		UserID:    d.UserID,
+		Cron:      d.Cron,
+		ModelID:   d.Prompt.ModelID,
+		Text:      d.Prompt.Text,
+		Priority:  d.Prompt.Priority,
+		Timeout:   d.Prompt.Timeout.Milliseconds(),
+		NextRunAt: d.NextRunAt,
+		LastRunAt: d.LastRunAt,
+		CreatedAt: d.CreatedAt,
+	}
+	s.Options, _ = json.Marshal(d.Prompt.Options)
+	if ref := d.Prompt.Template; ref != nil {
+		s.TemplateID = &ref.ID
+		s.TemplateVersion = &ref.Version
+		s.Variables, _ = json.Marshal(ref.Variables)
+	}
+
+	return s
+}
+
+func (s *Schedule) ToDomain() model.Schedule {
+	d := model.Schedule{
+		ID:     s.ID,
+		UserID: s.UserID,
+		Cron:   s.Cron,
+		Prompt: model.Prompt{
+			UserID:   s.UserID,
+			ModelID:  s.ModelID,
+			Text:     s.Text,
+			Priority: s.Priority,
+			Timeout:  time.Duration(s.Timeout) * time.Millisecond,
+		},
+		NextRunAt: s.NextRunAt,
+		LastRunAt: s.LastRunAt,
+		CreatedAt: s.CreatedAt,
+	}
+	if len(s.Options) > 0 {
+		_ = json.Unmarshal(s.Options, &d.Prompt.Options)
+	}
+	if s.TemplateID != nil && s.TemplateVersion != nil {
+		d.Prompt.Template = &model.TemplateRef{
+			ID:      *s.TemplateID,
+			Version: *s.TemplateVersion,
+		}
+		if len(s.Variables) > 0 {
+			_ = json.Unmarshal(s.Variables, &d.Prompt.Template.Variables)
+		}
+	}
+
+	return d
+}
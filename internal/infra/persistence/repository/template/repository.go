@@ -0,0 +1,159 @@
+package template
+
+import (
+	"ai-orchestrator/internal/common/logger"
+	"ai-orchestrator/internal/domain/model"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"time"
+)
+
+const uniqueViolation = "23505"
+
+type Repository struct {
+	logger logger.Logger
+	db     *sqlx.DB
+}
+
+func NewRepository(l logger.Logger, db *sqlx.DB) (*Repository, error) {
+	if l == nil {
+		return nil, logger.ErrNilLogger
+	}
+	if db == nil {
+		return nil, errors.New("db is nil")
+	}
+
+	return &Repository{
+		logger: l,
+		db:     db,
+	}, nil
+}
+
+func (r *Repository) InsertTemplate(ctx context.Context, template model.Template) error {
+	dbTemplate := FromDomain(template)
+
+	query := `
+		INSERT INTO prompt_templates (id, user_id, version, name, body, created_at)
+		VALUES (:id, :user_id, :version, :name, :body, :created_at)
+	`
+
+	r.logger.InfoContext(ctx, "executing query to insert new template", "template_id", dbTemplate.ID, "repository", "templateRepository")
+
+	_, err := r.db.NamedExecContext(ctx, query, dbTemplate)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed to insert new template", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// InsertVersion stores the template as the version following the latest one.
+// A template of another user is not found. Two concurrent updates compete for
+// the same version, the loser gets model.ErrTemplateConflict.
+func (r *Repository) InsertVersion(ctx context.Context, template model.Template) (*model.Template, error) {
+	var dbTemplate Template
+	query := `
+		INSERT INTO prompt_templates (id, user_id, version, name, body, created_at)
+		SELECT id, user_id, MAX(version) + 1, $2, $3, $4
+		FROM prompt_templates
+		WHERE id = $1 AND user_id = $5 AND deleted_at IS NULL
+		GROUP BY id, user_id
+		RETURNING id, user_id, version, name, body, created_at, deleted_at
+	`
+
+	r.logger.InfoContext(ctx, "executing query to insert template version", "template_id", template.ID, "repository", "templateRepository")
+
+	err := r.db.GetContext(ctx, &dbTemplate, query, template.ID, template.Name, template.Body, time.Now().UTC(), template.UserID)
+	if err != nil {
+		var pqErr *pq.Error
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, model.ErrTemplateNotFound
+		case errors.As(err, &pqErr) && pqErr.Code == uniqueViolation:
+			return nil, model.ErrTemplateConflict
+		}
+		r.logger.ErrorContext(ctx, "failed to insert template version", "error", err, "template_id", template.ID)
+		return nil, err
+	}
+
+	domainTemplate := dbTemplate.ToDomain()
+	return &domainTemplate, nil
+}
+
+// GetTemplate returns the given version of the template, or the latest one when version is zero.
+func (r *Repository) GetTemplate(ctx context.Context, id uuid.UUID, version int) (*model.Template, error) {
+	var dbTemplate Template
+	query := `
+		SELECT id, user_id, version, name, body, created_at, deleted_at
+		FROM prompt_templates
+		WHERE id = $1 AND ($2 = 0 OR version = $2) AND deleted_at IS NULL
+		ORDER BY version DESC
+		LIMIT 1
+	`
+
+	err := r.db.GetContext(ctx, &dbTemplate, query, id, version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrTemplateNotFound
+		}
+		r.logger.ErrorContext(ctx, "failed to get template", "error", err, "template_id", id, "version", version)
+		return nil, err
+	}
+
+	domainTemplate := dbTemplate.ToDomain()
+	return &domainTemplate, nil
+}
+
+// ListTemplates returns the latest version of every template.
+func (r *Repository) ListTemplates(ctx context.Context) ([]model.Template, error) {
+	var dbTemplates []Template
+	query := `
+		SELECT DISTINCT ON (id) id, user_id, version, name, body, created_at, deleted_at
+		FROM prompt_templates
+		WHERE deleted_at IS NULL
+		ORDER BY id, version DESC
+	`
+
+	err := r.db.SelectContext(ctx, &dbTemplates, query)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed to list templates", "error", err)
+		return nil, err
+	}
+
+	templates := make([]model.Template, 0, len(dbTemplates))
+	for _, t := range dbTemplates {
+		templates = append(templates, t.ToDomain())
+	}
+
+	return templates, nil
+}
+
+// DeleteTemplate hides every version of the template. The rows are kept, since prompts reference them.
+// A template of another user is not found.
+func (r *Repository) DeleteTemplate(ctx context.Context, id, userID uuid.UUID) error {
+	query := `
+		UPDATE prompt_templates
+		SET deleted_at = $2
+		WHERE id = $1 AND user_id = $3 AND deleted_at IS NULL
+	`
+
+	r.logger.InfoContext(ctx, "executing query to delete template", "template_id", id, "repository", "templateRepository")
+
+	result, err := r.db.ExecContext(ctx, query, id, time.Now().UTC(), userID)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "failed to delete template", "error", err, "template_id", id)
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return model.ErrTemplateNotFound
+	}
+
+	return nil
+}
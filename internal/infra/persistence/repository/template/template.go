@@ -0,0 +1,43 @@
+package template
+
+import (
+	"ai-orchestrator/internal/domain/model"
+	"github.com/google/uuid"
+	"time"
+)
+
+type Template struct {
+	ID        uuid.UUID  `db:"id"`
+	UserID    *uuid.UUID `db:"user_id"`
+	Version   int        `db:"version"`
+	Name      string     `db:"name"`
+	Body      string     `db:"body"`
+	CreatedAt time.Time  `db:"created_at"`
+	DeletedAt *time.Time `db:"deleted_at"`
+}
+
+func FromDomain(d model.Template) Template {
+	return Template{
+		ID:        d.ID,
+		UserID:    &d.UserID,
+		Version:   d.Version,
+		Name:      d.Name,
+		Body:      d.Body,
+		CreatedAt: d.CreatedAt,
+	}
+}
+
+func (t *Template) ToDomain() model.Template {
+	template := model.Template{
+		ID:        t.ID,
+		Version:   t.Version,
+		Name:      t.Name,
+		Body:      t.Body,
+		CreatedAt: t.CreatedAt,
+	}
+	if t.UserID != nil {
+		template.UserID = *t.UserID
+	}
+
+	return template
+}
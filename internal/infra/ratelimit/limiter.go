@@ -0,0 +1,165 @@
+package ratelimit
+
+import (
+	"ai-orchestrator/internal/common/logger"
+	"ai-orchestrator/internal/config/shared"
+	"ai-orchestrator/internal/domain/model"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+var ErrQuotaExhausted = errors.New("quota exhausted")
+
+const keyPrefix = "ratelimit:"
+
+// takeScript takes the cost of the call from every bucket at once, or from
+// none of them. Buckets refill continuously up to a minute of quota. It
+// returns zero when the call is granted, the milliseconds to wait otherwise.
+//
+// KEYS are the buckets, ARGV holds the capacity, the refill per millisecond
+// and the cost of every bucket in turn.
+var takeScript = redis.NewScript(`
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local wait = 0
+local levels = {}
+for i, key in ipairs(KEYS) do
+	local capacity = tonumber(ARGV[i * 3 - 2])
+	local rate = tonumber(ARGV[i * 3 - 1])
+	local cost = tonumber(ARGV[i * 3])
+
+	local bucket = redis.call('HMGET', key, 'tokens', 'ts')
+	local tokens = tonumber(bucket[1]) or capacity
+	local ts = tonumber(bucket[2]) or now
+	tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+	levels[i] = tokens
+
+	if tokens < cost then
+		wait = math.max(wait, math.ceil((cost - tokens) / rate))
+	end
+end
+if wait > 0 then
+	return wait
+end
+
+for i, key in ipairs(KEYS) do
+	local capacity = tonumber(ARGV[i * 3 - 2])
+	local rate = tonumber(ARGV[i * 3 - 1])
+	local cost = tonumber(ARGV[i * 3])
+
+	redis.call('HSET', key, 'tokens', tostring(levels[i] - cost), 'ts', now)
+	redis.call('PEXPIRE', key, math.ceil(capacity / rate))
+end
+return 0
+`)
+
+// Limiter paces the calls to the providers with token buckets kept in Redis,
+// so the quotas are shared by all the workers: a bucket of requests and one of
+// tokens per provider, and the same per model.
+type Limiter struct {
+	logger logger.Logger
+	client *redis.Client
+	cfg    *shared.RateLimitConfig
+}
+
+func NewLimiter(l logger.Logger, client *redis.Client, cfg *shared.RateLimitConfig) (*Limiter, error) {
+	if l == nil {
+		return nil, logger.ErrNilLogger
+	}
+	if client == nil {
+		return nil, errors.New("redis client is nil")
+	}
+	if cfg == nil {
+		return nil, errors.New("rate limit config is nil")
+	}
+
+	return &Limiter{
+		logger: l,
+		client: client,
+		cfg:    cfg,
+	}, nil
+}
+
+type bucket struct {
+	key      string
+	capacity int
+	cost     int
+}
+
+// Wait blocks until the quotas of the provider and the model grant a call of
+// the estimated tokens. It fails when the wait would exceed the configured
+// maximum or the deadline of the call. Redis failures let the call through:
+// the provider enforces the quotas anyway.
+func (l *Limiter) Wait(ctx context.Context, m model.Model, tokens int) error {
+	if !l.cfg.Enabled {
+		return nil
+	}
+
+	buckets := l.buckets(m, tokens)
+	if len(buckets) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(buckets))
+	args := make([]any, 0, 3*len(buckets))
+	for _, b := range buckets {
+		keys = append(keys, b.key)
+		args = append(args, b.capacity, float64(b.capacity)/float64(time.Minute.Milliseconds()), b.cost)
+	}
+
+	var waited time.Duration
+	for {
+		ms, err := takeScript.Run(ctx, l.client, keys, args...).Int64()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			l.logger.WarnContext(ctx, "Failed to take from the rate limit buckets, letting the call through.", "error", err, "model", m.ID)
+			return nil
+		}
+		if ms == 0 {
+			if waited > 0 {
+				l.logger.InfoContext(ctx, "Call paced by the rate limits", "model", m.ID, "waited", waited)
+			}
+			return nil
+		}
+
+		wait := time.Duration(ms) * time.Millisecond
+		if waited+wait > l.cfg.MaxWait {
+			return fmt.Errorf("%w: %s would wait %s", ErrQuotaExhausted, m.ID, wait)
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return fmt.Errorf("%w: %s would wait %s, past the deadline", ErrQuotaExhausted, m.ID, wait)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+			waited += wait
+		}
+	}
+}
+
+func (l *Limiter) buckets(m model.Model, tokens int) []bucket {
+	var buckets []bucket
+	add := func(key string, capacity, cost int) {
+		if capacity <= 0 {
+			return
+		}
+		// A call larger than the bucket would never be granted, it takes it whole instead.
+		buckets = append(buckets, bucket{key: keyPrefix + key, capacity: capacity, cost: min(cost, capacity)})
+	}
+
+	provider := l.cfg.Providers[m.Provider]
+	add("provider:"+m.Provider+":requests", provider.RequestsPerMinute, 1)
+	add("provider:"+m.Provider+":tokens", provider.TokensPerMinute, tokens)
+	add("model:"+m.ID+":requests", m.Quota.RequestsPerMinute, 1)
+	add("model:"+m.ID+":tokens", m.Quota.TokensPerMinute, tokens)
+
+	return buckets
+}
@@ -0,0 +1,152 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOK          = "ok"
+	StatusDegraded    = "degraded"
+	StatusUnavailable = "unavailable"
+)
+
+// checkTimeout bounds every check, so a hanging dependency fails the probe
+// instead of the probe timing out.
+const checkTimeout = 2 * time.Second
+
+// Check reports whether a component works.
+type Check func(ctx context.Context) error
+
+// Result is the outcome of a check.
+type Result struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Report is the breakdown returned by the probes.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+type check struct {
+	name     string
+	run      Check
+	critical bool
+}
+
+// Probe serves the liveness and readiness of a process. Liveness checks
+// only what restarting the process would fix, such as a loop that stopped;
+// readiness also checks the dependencies. A failed critical check fails the
+// probe with a 503, the others only degrade it.
+type Probe struct {
+	mu        sync.Mutex
+	liveness  []check
+	readiness []check
+}
+
+func NewProbe() *Probe {
+	return &Probe{}
+}
+
+// AddLiveness adds a critical check to both probes.
+func (p *Probe) AddLiveness(name string, run Check) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.liveness = append(p.liveness, check{name: name, run: run, critical: true})
+}
+
+// AddReadiness adds a check to the readiness probe.
+func (p *Probe) AddReadiness(name string, run Check, critical bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.readiness = append(p.readiness, check{name: name, run: run, critical: critical})
+}
+
+func (p *Probe) Livez(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	checks := append([]check(nil), p.liveness...)
+	p.mu.Unlock()
+
+	write(w, run(r.Context(), checks))
+}
+
+func (p *Probe) Readyz(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	checks := append(append([]check(nil), p.liveness...), p.readiness...)
+	p.mu.Unlock()
+
+	write(w, run(r.Context(), checks))
+}
+
+// run runs the checks concurrently.
+func run(ctx context.Context, checks []check) Report {
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c check) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+			err := c.run(checkCtx)
+			cancel()
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err == nil {
+				report.Checks[c.name] = Result{Status: StatusOK}
+				return
+			}
+			status := StatusDegraded
+			if c.critical {
+				status = StatusUnavailable
+			}
+			report.Checks[c.name] = Result{Status: status, Error: err.Error()}
+			if status == StatusUnavailable || report.Status == StatusOK {
+				report.Status = status
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	return report
+}
+
+func write(w http.ResponseWriter, report Report) {
+	code := http.StatusOK
+	if report.Status == StatusUnavailable {
+		code = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(report)
+}
+
+// Heartbeat fails when the last beat is older than maxAge, telling that the
+// loop reporting it stopped or hangs.
+func Heartbeat(last func() time.Time, maxAge time.Duration) Check {
+	return func(context.Context) error {
+		beat := last()
+		if beat.IsZero() {
+			return errors.New("no heartbeat yet")
+		}
+		if age := time.Since(beat); age > maxAge {
+			return errors.New("last heartbeat " + age.Round(time.Second).String() + " ago")
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Metric types of the Prometheus text format.
+const (
+	Gauge   = "gauge"
+	Counter = "counter"
+)
+
+// Sample is a value of a metric at collection time.
+type Sample struct {
+	Name   string
+	Help   string
+	Type   string
+	Labels map[string]string
+	Value  float64
+}
+
+// Collector reports the current samples of a component.
+type Collector interface {
+	Collect() []Sample
+}
+
+// Registry serves the samples of its collectors in the Prometheus text format.
+// Collection happens on scrape, so the components keep no metric state apart
+// from their own.
+type Registry struct {
+	mu         sync.Mutex
+	collectors []Collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) Register(c Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.collectors = append(r.collectors, c)
+}
+
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	r.mu.Lock()
+	collectors := slices.Clone(r.collectors)
+	r.mu.Unlock()
+
+	var samples []Sample
+	for _, c := range collectors {
+		samples = append(samples, c.Collect()...)
+	}
+	// The format requires the samples of a metric to be grouped under its header.
+	slices.SortStableFunc(samples, func(a, b Sample) int { return strings.Compare(a.Name, b.Name) })
+
+	var b strings.Builder
+	for i, s := range samples {
+		if i == 0 || samples[i-1].Name != s.Name {
+			fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", s.Name, s.Help, s.Name, s.Type)
+		}
+		b.WriteString(s.Name)
+		writeLabels(&b, s.Labels)
+		b.WriteByte(' ')
+		b.WriteString(strconv.FormatFloat(s.Value, 'g', -1, 64))
+		b.WriteByte('\n')
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write([]byte(b.String()))
+}
+
+func writeLabels(b *strings.Builder, labels map[string]string) {
+	if len(labels) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(b, "%s=%q", name, labels[name])
+	}
+	b.WriteByte('}')
+}
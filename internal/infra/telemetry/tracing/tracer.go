@@ -31,3 +31,9 @@ func InitContext(ctx context.Context, traceId, operationId string) (trace.Span,
 
 	return span, newCtx
 }
+
+func InitSpan(ctx context.Context, operationName string) (trace.Span, context.Context) {
+	ctx, span := getTracer().Start(ctx, operationName)
+
+	return span, ctx
+}
@@ -0,0 +1,346 @@
+package tools
+
+import (
+	"ai-orchestrator/internal/domain/gateway"
+	"ai-orchestrator/internal/domain/model"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var ErrInvalidExpression = errors.New("invalid expression")
+
+// Calculator evaluates arithmetic expressions, since models are unreliable at computing.
+type Calculator struct{}
+
+func NewCalculator() *Calculator {
+	return &Calculator{}
+}
+
+func (c *Calculator) Declaration() gateway.Tool {
+	return gateway.Tool{
+		Name: model.ToolCalculator,
+		Description: "Evaluates an arithmetic expression and returns the result. Supports + - * / % ^, parentheses, " +
+			"the constants pi and e, and the functions sqrt, abs, exp, ln, log10, sin, cos, tan, floor, ceil, round, min, max and pow.",
+		Parameters: json.RawMessage(`{
+			"type": "object",
+			"properties": {"expression": {"type": "string", "description": "The expression, such as (2 + 3) * sqrt(16)."}},
+			"required": ["expression"]
+		}`),
+	}
+}
+
+func (c *Calculator) Call(_ context.Context, arguments json.RawMessage) (string, error) {
+	var args struct {
+		Expression string `json:"expression"`
+	}
+	if err := decodeArguments(arguments, &args); err != nil {
+		return "", err
+	}
+
+	result, err := Evaluate(args.Expression)
+	if err != nil {
+		return "", err
+	}
+
+	return strconv.FormatFloat(result, 'g', -1, 64), nil
+}
+
+// Evaluate computes the value of an arithmetic expression.
+func Evaluate(expression string) (float64, error) {
+	p := &parser{input: expression}
+	p.next()
+
+	value, err := p.expression()
+	if err != nil {
+		return 0, err
+	}
+	if p.token != "" {
+		return 0, fmt.Errorf("%w: unexpected %q", ErrInvalidExpression, p.token)
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("%w: the result is not a finite number", ErrInvalidExpression)
+	}
+
+	return value, nil
+}
+
+// parser is a recursive descent parser evaluating while it parses:
+//
+//	expression = term { ("+" | "-") term }
+//	term       = unary { ("*" | "/" | "%") unary }
+//	unary      = ("+" | "-") unary | power
+//	power      = primary [ "^" unary ]
+//	primary    = number | constant | function "(" expression { "," expression } ")" | "(" expression ")"
+type parser struct {
+	input string
+	pos   int
+	token string
+	depth int
+}
+
+const maxDepth = 100
+
+func (p *parser) next() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+	if p.pos >= len(p.input) {
+		p.token = ""
+		return
+	}
+
+	start := p.pos
+	ch := rune(p.input[p.pos])
+	switch {
+	case unicode.IsDigit(ch) || ch == '.':
+		for p.pos < len(p.input) && (unicode.IsDigit(rune(p.input[p.pos])) || p.input[p.pos] == '.') {
+			p.pos++
+		}
+		// Exponents, as in 1.5e3.
+		if p.pos < len(p.input) && (p.input[p.pos] == 'e' || p.input[p.pos] == 'E') {
+			end := p.pos + 1
+			if end < len(p.input) && (p.input[end] == '+' || p.input[end] == '-') {
+				end++
+			}
+			if end < len(p.input) && unicode.IsDigit(rune(p.input[end])) {
+				p.pos = end
+				for p.pos < len(p.input) && unicode.IsDigit(rune(p.input[p.pos])) {
+					p.pos++
+				}
+			}
+		}
+	case unicode.IsLetter(ch):
+		for p.pos < len(p.input) && (unicode.IsLetter(rune(p.input[p.pos])) || unicode.IsDigit(rune(p.input[p.pos]))) {
+			p.pos++
+		}
+	default:
+		p.pos++
+	}
+	p.token = p.input[start:p.pos]
+}
+
+func (p *parser) expression() (float64, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxDepth {
+		return 0, fmt.Errorf("%w: too deeply nested", ErrInvalidExpression)
+	}
+
+	left, err := p.term()
+	if err != nil {
+		return 0, err
+	}
+
+	for p.token == "+" || p.token == "-" {
+		op := p.token
+		p.next()
+		right, err := p.term()
+		if err != nil {
+			return 0, err
+		}
+		if op == "+" {
+			left += right
+		} else {
+			left -= right
+		}
+	}
+
+	return left, nil
+}
+
+func (p *parser) term() (float64, error) {
+	left, err := p.unary()
+	if err != nil {
+		return 0, err
+	}
+
+	for p.token == "*" || p.token == "/" || p.token == "%" {
+		op := p.token
+		p.next()
+		right, err := p.unary()
+		if err != nil {
+			return 0, err
+		}
+
+		switch op {
+		case "*":
+			left *= right
+		case "/":
+			if right == 0 {
+				return 0, fmt.Errorf("%w: division by zero", ErrInvalidExpression)
+			}
+			left /= right
+		case "%":
+			if right == 0 {
+				return 0, fmt.Errorf("%w: division by zero", ErrInvalidExpression)
+			}
+			left = math.Mod(left, right)
+		}
+	}
+
+	return left, nil
+}
+
+func (p *parser) unary() (float64, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxDepth {
+		return 0, fmt.Errorf("%w: too deeply nested", ErrInvalidExpression)
+	}
+
+	switch p.token {
+	case "-":
+		p.next()
+		value, err := p.unary()
+		return -value, err
+	case "+":
+		p.next()
+		return p.unary()
+	}
+
+	return p.power()
+}
+
+func (p *parser) power() (float64, error) {
+	base, err := p.primary()
+	if err != nil {
+		return 0, err
+	}
+
+	if p.token != "^" {
+		return base, nil
+	}
+	p.next()
+
+	// Right associative: 2^3^2 is 2^(3^2).
+	exponent, err := p.unary()
+	if err != nil {
+		return 0, err
+	}
+
+	return math.Pow(base, exponent), nil
+}
+
+var constants = map[string]float64{
+	"pi": math.Pi,
+	"e":  math.E,
+}
+
+var functions = map[string]func(args []float64) (float64, error){
+	"sqrt":  unaryFunction(math.Sqrt),
+	"abs":   unaryFunction(math.Abs),
+	"exp":   unaryFunction(math.Exp),
+	"ln":    unaryFunction(math.Log),
+	"log10": unaryFunction(math.Log10),
+	"sin":   unaryFunction(math.Sin),
+	"cos":   unaryFunction(math.Cos),
+	"tan":   unaryFunction(math.Tan),
+	"floor": unaryFunction(math.Floor),
+	"ceil":  unaryFunction(math.Ceil),
+	"round": unaryFunction(math.Round),
+	"pow": func(args []float64) (float64, error) {
+		if len(args) != 2 {
+			return 0, fmt.Errorf("%w: pow takes 2 arguments", ErrInvalidExpression)
+		}
+		return math.Pow(args[0], args[1]), nil
+	},
+	"min": variadicFunction(math.Min),
+	"max": variadicFunction(math.Max),
+}
+
+func unaryFunction(f func(float64) float64) func([]float64) (float64, error) {
+	return func(args []float64) (float64, error) {
+		if len(args) != 1 {
+			return 0, fmt.Errorf("%w: function takes 1 argument", ErrInvalidExpression)
+		}
+		return f(args[0]), nil
+	}
+}
+
+func variadicFunction(f func(float64, float64) float64) func([]float64) (float64, error) {
+	return func(args []float64) (float64, error) {
+		if len(args) == 0 {
+			return 0, fmt.Errorf("%w: function takes at least 1 argument", ErrInvalidExpression)
+		}
+		result := args[0]
+		for _, arg := range args[1:] {
+			result = f(result, arg)
+		}
+		return result, nil
+	}
+}
+
+func (p *parser) primary() (float64, error) {
+	token := p.token
+	switch {
+	case token == "":
+		return 0, fmt.Errorf("%w: unexpected end", ErrInvalidExpression)
+	case token == "(":
+		p.next()
+		value, err := p.expression()
+		if err != nil {
+			return 0, err
+		}
+		if p.token != ")" {
+			return 0, fmt.Errorf("%w: missing )", ErrInvalidExpression)
+		}
+		p.next()
+		return value, nil
+	case unicode.IsDigit(rune(token[0])) || token[0] == '.':
+		value, err := strconv.ParseFloat(token, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: invalid number %q", ErrInvalidExpression, token)
+		}
+		p.next()
+		return value, nil
+	case unicode.IsLetter(rune(token[0])):
+		name := strings.ToLower(token)
+		p.next()
+		if value, ok := constants[name]; ok {
+			return value, nil
+		}
+		f, ok := functions[name]
+		if !ok {
+			return 0, fmt.Errorf("%w: unknown name %q", ErrInvalidExpression, token)
+		}
+		args, err := p.arguments()
+		if err != nil {
+			return 0, err
+		}
+		return f(args)
+	}
+
+	return 0, fmt.Errorf("%w: unexpected %q", ErrInvalidExpression, token)
+}
+
+func (p *parser) arguments() ([]float64, error) {
+	if p.token != "(" {
+		return nil, fmt.Errorf("%w: missing ( after function", ErrInvalidExpression)
+	}
+	p.next()
+
+	var args []float64
+	for {
+		value, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, value)
+
+		switch p.token {
+		case ",":
+			p.next()
+		case ")":
+			p.next()
+			return args, nil
+		default:
+			return nil, fmt.Errorf("%w: missing ) after arguments", ErrInvalidExpression)
+		}
+	}
+}
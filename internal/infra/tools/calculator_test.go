@@ -0,0 +1,119 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       float64
+	}{
+		{expression: "42", want: 42},
+		{expression: "1.5e3", want: 1500},
+		{expression: ".5", want: 0.5},
+		{expression: "1 + 2 * 3", want: 7},
+		{expression: "(1 + 2) * 3", want: 9},
+		{expression: "10 - 4 - 3", want: 3},
+		{expression: "2 * 3 / 4", want: 1.5},
+		{expression: "7 % 3", want: 1},
+		{expression: "2 ^ 3 ^ 2", want: 512},
+		{expression: "-2 ^ 2", want: -4},
+		{expression: "2 ^ -1", want: 0.5},
+		{expression: "--3", want: 3},
+		{expression: "+3", want: 3},
+		{expression: "PI", want: math.Pi},
+		{expression: "sqrt(16) + abs(-2)", want: 6},
+		{expression: "round(2.5) + floor(1.9) + ceil(1.1)", want: 6},
+		{expression: "ln(e) + log10(1000)", want: 4},
+		{expression: "pow(2, 10)", want: 1024},
+		{expression: "min(3, 1, 2) + max(3, 1, 2)", want: 4},
+		{expression: "cos(0) + sin(0) + tan(0) + exp(0)", want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := Evaluate(tt.expression)
+			if err != nil {
+				t.Fatalf("Evaluate(%q) unexpected error: %v", tt.expression, err)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Fatalf("Evaluate(%q) = %v, want %v", tt.expression, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+	}{
+		{name: "empty", expression: ""},
+		{name: "dangling operator", expression: "1 +"},
+		{name: "missing parenthesis", expression: "(1 + 2"},
+		{name: "extra parenthesis", expression: "1 + 2)"},
+		{name: "adjacent numbers", expression: "1 2"},
+		{name: "invalid number", expression: "1.2.3"},
+		{name: "exponent without digits", expression: "2e"},
+		{name: "unknown name", expression: "foo(1)"},
+		{name: "function without call", expression: "sqrt 4"},
+		{name: "missing argument", expression: "pow(2)"},
+		{name: "unexpected comma", expression: "sqrt(4, 9)"},
+		{name: "no argument", expression: "min()"},
+		{name: "division by zero", expression: "1 / 0"},
+		{name: "modulo by zero", expression: "1 % 0"},
+		{name: "not a number", expression: "sqrt(-1)"},
+		{name: "infinite", expression: "10 ^ 400"},
+		{name: "too deeply nested", expression: strings.Repeat("(", 200) + "1" + strings.Repeat(")", 200)},
+		{name: "too many signs", expression: strings.Repeat("-", 200) + "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Evaluate(tt.expression); !errors.Is(err, ErrInvalidExpression) {
+				t.Fatalf("Evaluate(%q) error = %v, want %v", tt.expression, err, ErrInvalidExpression)
+			}
+		})
+	}
+}
+
+func TestCalculatorCall(t *testing.T) {
+	tests := []struct {
+		name      string
+		arguments string
+		want      string
+		wantErr   bool
+	}{
+		{name: "integer result", arguments: `{"expression": "6 * 7"}`, want: "42"},
+		{name: "decimal result", arguments: `{"expression": "1 / 8"}`, want: "0.125"},
+		{name: "invalid expression", arguments: `{"expression": "6 *"}`, wantErr: true},
+		{name: "no arguments", arguments: ``, wantErr: true},
+		{name: "unknown argument", arguments: `{"expression": "1", "precision": 2}`, wantErr: true},
+		{name: "malformed arguments", arguments: `{"expression": 1}`, wantErr: true},
+	}
+
+	calculator := NewCalculator()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := calculator.Call(context.Background(), json.RawMessage(tt.arguments))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Call(%s) = %q, want an error", tt.arguments, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Call(%s) unexpected error: %v", tt.arguments, err)
+			}
+			if got != tt.want {
+				t.Fatalf("Call(%s) = %q, want %q", tt.arguments, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"ai-orchestrator/internal/domain/gateway"
+	"ai-orchestrator/internal/domain/model"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var ErrUnknownSource = errors.New("unknown lookup source")
+
+// LookupHook answers the lookups of a source by key. Hooks decide what the
+// models may read: each one exposes a single read-only query.
+type LookupHook struct {
+	Description string
+	Lookup      func(ctx context.Context, key string) (any, error)
+}
+
+// DBLookup lets the models read the data exposed by the registered hooks.
+type DBLookup struct {
+	hooks map[string]LookupHook
+}
+
+func NewDBLookup(hooks map[string]LookupHook) (*DBLookup, error) {
+	if len(hooks) == 0 {
+		return nil, errors.New("no lookup hooks")
+	}
+	for source, hook := range hooks {
+		if hook.Lookup == nil {
+			return nil, fmt.Errorf("lookup hook %q is nil", source)
+		}
+	}
+
+	return &DBLookup{hooks: hooks}, nil
+}
+
+func (d *DBLookup) Declaration() gateway.Tool {
+	sources := make([]string, 0, len(d.hooks))
+	for source := range d.hooks {
+		sources = append(sources, source)
+	}
+	slices.Sort(sources)
+
+	descriptions := make([]string, 0, len(sources))
+	for _, source := range sources {
+		descriptions = append(descriptions, fmt.Sprintf("%s: %s", source, d.hooks[source].Description))
+	}
+	enum, _ := json.Marshal(sources)
+
+	return gateway.Tool{
+		Name:        model.ToolDBLookup,
+		Description: "Looks up a record by key in one of these sources. " + strings.Join(descriptions, "; ") + ".",
+		Parameters: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"source": {"type": "string", "enum": ` + string(enum) + `},
+				"key": {"type": "string"}
+			},
+			"required": ["source", "key"]
+		}`),
+	}
+}
+
+func (d *DBLookup) Call(ctx context.Context, arguments json.RawMessage) (string, error) {
+	var args struct {
+		Source string `json:"source"`
+		Key    string `json:"key"`
+	}
+	if err := decodeArguments(arguments, &args); err != nil {
+		return "", err
+	}
+
+	hook, ok := d.hooks[args.Source]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownSource, args.Source)
+	}
+
+	record, err := hook.Lookup(ctx, args.Key)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := json.Marshal(record)
+	if err != nil {
+		return "", fmt.Errorf("encode record: %w", err)
+	}
+
+	return string(output), nil
+}
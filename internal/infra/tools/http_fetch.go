@@ -0,0 +1,125 @@
+package tools
+
+import (
+	"ai-orchestrator/internal/config/shared"
+	"ai-orchestrator/internal/domain/gateway"
+	"ai-orchestrator/internal/domain/model"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var (
+	ErrHostNotAllowed   = errors.New("host is not allowed")
+	ErrTooManyRedirects = errors.New("too many redirects")
+)
+
+const maxRedirects = 5
+
+// HTTPFetch fetches pages from the allow-listed hosts only. Redirects are
+// followed only while they stay on allowed hosts.
+type HTTPFetch struct {
+	client       *http.Client
+	allowedHosts []string
+	maxBytes     int64
+}
+
+func NewHTTPFetch(cfg *shared.ToolsConfig) (*HTTPFetch, error) {
+	if cfg == nil {
+		return nil, ErrNilConfig
+	}
+
+	f := &HTTPFetch{
+		allowedHosts: make([]string, 0, len(cfg.HTTPFetch.AllowedHosts)),
+		maxBytes:     int64(cfg.MaxOutputBytes),
+	}
+	for _, host := range cfg.HTTPFetch.AllowedHosts {
+		f.allowedHosts = append(f.allowedHosts, strings.ToLower(strings.TrimSpace(host)))
+	}
+
+	f.client = &http.Client{
+		Timeout: cfg.Timeout,
+		CheckRedirect: func(r *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return ErrTooManyRedirects
+			}
+			return f.check(r.URL)
+		},
+	}
+
+	return f, nil
+}
+
+func (f *HTTPFetch) Declaration() gateway.Tool {
+	return gateway.Tool{
+		Name:        model.ToolHTTPFetch,
+		Description: "Fetches a web page or an API response with an HTTP GET request. Only these hosts are reachable: " + strings.Join(f.allowedHosts, ", ") + ".",
+		Parameters: json.RawMessage(`{
+			"type": "object",
+			"properties": {"url": {"type": "string", "description": "The absolute http or https URL to fetch."}},
+			"required": ["url"]
+		}`),
+	}
+}
+
+func (f *HTTPFetch) Call(ctx context.Context, arguments json.RawMessage) (string, error) {
+	var args struct {
+		URL string `json:"url"`
+	}
+	if err := decodeArguments(arguments, &args); err != nil {
+		return "", err
+	}
+
+	target, err := url.Parse(args.URL)
+	if err != nil {
+		return "", fmt.Errorf("invalid url: %w", err)
+	}
+	if err = f.check(target); err != nil {
+		return "", err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("User-Agent", "ai-orchestrator-tools")
+
+	response, err := f.client.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	// One more byte than the limit lets the registry mark the output as truncated.
+	body, err := io.ReadAll(io.LimitReader(response.Body, f.maxBytes+1))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("status: %d\ncontent-type: %s\n\n%s", response.StatusCode, response.Header.Get("Content-Type"), body), nil
+}
+
+func (f *HTTPFetch) check(target *url.URL) error {
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return fmt.Errorf("unsupported scheme %q", target.Scheme)
+	}
+	if target.User != nil {
+		return errors.New("credentials in urls are not allowed")
+	}
+
+	// ".example.com" allows example.com and its subdomains.
+	host := strings.ToLower(target.Hostname())
+	for _, allowed := range f.allowedHosts {
+		domain, subdomains := strings.CutPrefix(allowed, ".")
+		if host == domain || (subdomains && strings.HasSuffix(host, allowed)) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %q", ErrHostNotAllowed, host)
+}
@@ -0,0 +1,122 @@
+package tools
+
+import (
+	"ai-orchestrator/internal/common/logger"
+	"ai-orchestrator/internal/config/shared"
+	"ai-orchestrator/internal/domain/gateway"
+	"ai-orchestrator/internal/domain/model"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+var (
+	ErrNilConfig       = errors.New("tools config is nil")
+	ErrToolUnavailable = errors.New("tool is not available on this worker")
+	ErrToolPanicked    = errors.New("tool panicked")
+)
+
+// Tool is a built-in function the models may call.
+type Tool interface {
+	Declaration() gateway.Tool
+	Call(ctx context.Context, arguments json.RawMessage) (string, error)
+}
+
+// Registry runs the tools the worker is configured for in a sandbox: every call
+// is bounded in time and output size, and a failing tool only fails its call.
+type Registry struct {
+	logger logger.Logger
+	cfg    *shared.ToolsConfig
+	tools  map[string]Tool
+}
+
+func NewRegistry(l logger.Logger, cfg *shared.ToolsConfig) (*Registry, error) {
+	if l == nil {
+		return nil, logger.ErrNilLogger
+	}
+	if cfg == nil {
+		return nil, ErrNilConfig
+	}
+
+	return &Registry{
+		logger: l,
+		cfg:    cfg,
+		tools:  make(map[string]Tool),
+	}, nil
+}
+
+func (r *Registry) Register(tool Tool) {
+	name := tool.Declaration().Name
+	r.tools[name] = tool
+	r.logger.Info("Tool registered", "tool", name)
+}
+
+// Declarations returns the declarations of the named tools.
+func (r *Registry) Declarations(names []string) ([]gateway.Tool, error) {
+	declarations := make([]gateway.Tool, 0, len(names))
+	for _, name := range names {
+		tool, ok := r.tools[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %w: %q", model.ErrInvalidTools, ErrToolUnavailable, name)
+		}
+		declarations = append(declarations, tool.Declaration())
+	}
+
+	return declarations, nil
+}
+
+// Call runs the tool. The output is truncated to the configured size.
+func (r *Registry) Call(ctx context.Context, name string, arguments json.RawMessage) (output string, err error) {
+	tool, ok := r.tools[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrToolUnavailable, name)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.cfg.Timeout)
+	defer cancel()
+
+	defer func() {
+		if p := recover(); p != nil {
+			r.logger.ErrorContext(ctx, "Tool panicked", "tool", name, "panic", p)
+			output, err = "", ErrToolPanicked
+		}
+	}()
+
+	output, err = tool.Call(ctx, arguments)
+	if err != nil {
+		return "", err
+	}
+
+	return truncate(output, r.cfg.MaxOutputBytes), nil
+}
+
+func truncate(s string, limit int) string {
+	if limit <= 0 || len(s) <= limit {
+		return s
+	}
+
+	s = s[:limit]
+	for !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+
+	return s + "\n[truncated]"
+}
+
+// decodeArguments decodes the arguments of a call, rejecting unknown ones.
+func decodeArguments(arguments json.RawMessage, v any) error {
+	if len(arguments) == 0 {
+		arguments = json.RawMessage("{}")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(arguments))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	return nil
+}
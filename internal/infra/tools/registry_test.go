@@ -0,0 +1,143 @@
+package tools
+
+import (
+	"ai-orchestrator/internal/config/shared"
+	"ai-orchestrator/internal/domain/gateway"
+	"ai-orchestrator/internal/domain/model"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// stubTool runs call, or echoes its arguments.
+type stubTool struct {
+	name string
+	call func(ctx context.Context) (string, error)
+}
+
+func (s *stubTool) Declaration() gateway.Tool {
+	return gateway.Tool{Name: s.name}
+}
+
+func (s *stubTool) Call(ctx context.Context, arguments json.RawMessage) (string, error) {
+	if s.call != nil {
+		return s.call(ctx)
+	}
+
+	return string(arguments), nil
+}
+
+func newTestRegistry(t *testing.T, cfg *shared.ToolsConfig, tools ...Tool) *Registry {
+	t.Helper()
+
+	registry, err := NewRegistry(slog.New(slog.DiscardHandler), cfg)
+	if err != nil {
+		t.Fatalf("NewRegistry unexpected error: %v", err)
+	}
+	for _, tool := range tools {
+		registry.Register(tool)
+	}
+
+	return registry
+}
+
+func TestRegistryDeclarations(t *testing.T) {
+	registry := newTestRegistry(t, &shared.ToolsConfig{}, &stubTool{name: "a"}, &stubTool{name: "b"})
+
+	declarations, err := registry.Declarations([]string{"b", "a"})
+	if err != nil {
+		t.Fatalf("Declarations unexpected error: %v", err)
+	}
+	if len(declarations) != 2 || declarations[0].Name != "b" || declarations[1].Name != "a" {
+		t.Fatalf("Declarations = %v, want b and a in order", declarations)
+	}
+
+	_, err = registry.Declarations([]string{"a", "missing"})
+	if !errors.Is(err, model.ErrInvalidTools) || !errors.Is(err, ErrToolUnavailable) {
+		t.Fatalf("Declarations error = %v, want %v and %v", err, model.ErrInvalidTools, ErrToolUnavailable)
+	}
+}
+
+func TestRegistryCall(t *testing.T) {
+	failure := errors.New("failure")
+
+	tests := []struct {
+		name    string
+		tool    string
+		call    func(ctx context.Context) (string, error)
+		want    string
+		wantErr error
+	}{
+		{
+			name: "output",
+			call: func(context.Context) (string, error) { return "done", nil },
+			want: "done",
+		},
+		{
+			name: "output at the limit",
+			call: func(context.Context) (string, error) { return "12345678", nil },
+			want: "12345678",
+		},
+		{
+			name: "output truncated",
+			call: func(context.Context) (string, error) { return "123456789", nil },
+			want: "12345678\n[truncated]",
+		},
+		{
+			name: "truncated on a character boundary",
+			call: func(context.Context) (string, error) { return "1234567é", nil },
+			want: "1234567\n[truncated]",
+		},
+		{
+			name:    "failure",
+			call:    func(context.Context) (string, error) { return "partial", failure },
+			wantErr: failure,
+		},
+		{
+			name:    "panic",
+			call:    func(context.Context) (string, error) { panic("boom") },
+			wantErr: ErrToolPanicked,
+		},
+		{
+			name: "timeout",
+			call: func(ctx context.Context) (string, error) {
+				<-ctx.Done()
+				return "", ctx.Err()
+			},
+			wantErr: context.DeadlineExceeded,
+		},
+		{
+			name:    "unknown tool",
+			tool:    "missing",
+			wantErr: ErrToolUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &shared.ToolsConfig{Timeout: 10 * time.Millisecond, MaxOutputBytes: 8}
+			registry := newTestRegistry(t, cfg, &stubTool{name: "stub", call: tt.call})
+
+			name := "stub"
+			if tt.tool != "" {
+				name = tt.tool
+			}
+			got, err := registry.Call(context.Background(), name, nil)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) || got != "" {
+					t.Fatalf("Call = %q, %v, want no output and %v", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Call unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("Call = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,30 +1,43 @@
 package websocket
 
 import (
-	"encoding/json"
+	"errors"
 	"github.com/gorilla/websocket"
 	"time"
 )
 
 const (
-	writeWait  = 30 * time.Second
-	pingPeriod = 30 * time.Second
+	writeWait      = 30 * time.Second
+	pongWait       = 60 * time.Second
+	pingPeriod     = 30 * time.Second
+	maxMessageSize = 64 * 1024
 )
 
+var ErrSendBufferFull = errors.New("client send buffer is full")
+
 type Client struct {
-	conn *websocket.Conn
-	send chan []byte
+	userID string
+	conn   *websocket.Conn
+	send   chan []byte
 }
 
-func NewClient(conn *websocket.Conn) *Client {
+func NewClient(userID string, conn *websocket.Conn) *Client {
 	return &Client{
-		conn: conn,
-		send: make(chan []byte, 256),
+		userID: userID,
+		conn:   conn,
+		send:   make(chan []byte, 256),
 	}
 }
 
-func (c *Client) WriteMessage(messageType int, data json.RawMessage) error {
-	return c.conn.WriteMessage(messageType, data)
+// Send queues the message for the write pump. gorilla/websocket supports only
+// one concurrent writer, so every write must go through the pump.
+func (c *Client) Send(data []byte) error {
+	select {
+	case c.send <- data:
+		return nil
+	default:
+		return ErrSendBufferFull
+	}
 }
 
 func (c *Client) writePump() {
@@ -55,3 +68,23 @@ func (c *Client) writePump() {
 		}
 	}
 }
+
+// readPump reads frames until the connection breaks and passes each of them
+// to onMessage. It returns when the client disconnects.
+func (c *Client) readPump(onMessage func(data []byte)) {
+	c.conn.SetReadLimit(maxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		c.conn.SetReadDeadline(time.Now().Add(pongWait))
+
+		onMessage(data)
+	}
+}
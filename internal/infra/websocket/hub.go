@@ -22,14 +22,27 @@ func NewHub() *Hub {
 }
 
 func (hub *Hub) Add(userID string, conn *websocket.Conn) *Client {
-	client := NewClient(conn)
+	client := NewClient(userID, conn)
 	hub.clients[userID] = append(hub.clients[userID], client)
 
 	return client
 }
 
-func (hub *Hub) Remove(userID string) {
-	delete(hub.clients, userID)
+// Remove detaches a single connection of the user, keeping the other ones alive.
+func (hub *Hub) Remove(userID string, client *Client) {
+	clients := hub.clients[userID]
+	for i, c := range clients {
+		if c == client {
+			clients = append(clients[:i], clients[i+1:]...)
+			break
+		}
+	}
+
+	if len(clients) == 0 {
+		delete(hub.clients, userID)
+		return
+	}
+	hub.clients[userID] = clients
 }
 
 func (hub *Hub) GetClientByID(userId string) ([]*Client, error) {
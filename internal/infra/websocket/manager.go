@@ -14,21 +14,30 @@ var ErrNilHub = errors.New("hub is nil")
 
 type ConnectionHub interface {
 	Add(userID string, conn *websocket.Conn) *Client
-	Remove(userID string)
+	Remove(userID string, client *Client)
 	GetClientByID(userID string) ([]*Client, error)
 	GetAllClients() map[string][]*Client
 }
 
 var ErrNilUpgrader = errors.New("websocket upgrader is nil")
 
+var ErrNilMessageHandler = errors.New("message handler is nil")
+
+// MessageHandler processes the domain messages (submit, cancel, status) received
+// from a client and returns the reply that is sent back over the same connection.
+type MessageHandler interface {
+	HandleMessage(ctx context.Context, userID string, msg Message) Message
+}
+
 type Manager struct {
 	logger   logger.Logger
 	upgrader *websocket.Upgrader
 	clients  *Hub
+	handler  MessageHandler
 	mu       sync.RWMutex
 }
 
-func NewManager(l logger.Logger, upgrader *websocket.Upgrader, hub *Hub) (*Manager, error) {
+func NewManager(l logger.Logger, upgrader *websocket.Upgrader, hub *Hub, handler MessageHandler) (*Manager, error) {
 	if l == nil {
 		return nil, logger.ErrNilLogger
 	}
@@ -38,11 +47,15 @@ func NewManager(l logger.Logger, upgrader *websocket.Upgrader, hub *Hub) (*Manag
 	if hub == nil {
 		return nil, ErrNilHub
 	}
+	if handler == nil {
+		return nil, ErrNilMessageHandler
+	}
 
 	return &Manager{
 		logger:   l,
 		upgrader: upgrader,
 		clients:  hub,
+		handler:  handler,
 		mu:       sync.RWMutex{},
 	}, nil
 }
@@ -63,28 +76,89 @@ func (m *Manager) ServeWS(w http.ResponseWriter, r *http.Request) {
 	}
 
 	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	client := m.clients.Add(userID, conn)
+	m.mu.Unlock()
+
 	go client.writePump()
+
+	ctx := r.Context()
+	client.readPump(func(data []byte) {
+		m.handleFrame(ctx, client, data)
+	})
+
+	m.mu.Lock()
+	m.clients.Remove(userID, client)
+	close(client.send)
+	m.mu.Unlock()
+
+	m.logger.Info("Client disconnected", "userID", userID)
+}
+
+func (m *Manager) handleFrame(ctx context.Context, client *Client, data []byte) {
+	var msg Message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		m.logger.WarnContext(ctx, "Failed to decode websocket message", "error", err, "userID", client.userID)
+		m.reply(ctx, client, NewErrorMessage("", ErrCodeInvalidMessage, "message must be a JSON object"))
+		return
+	}
+
+	var reply Message
+	switch msg.Type {
+	case TypePing:
+		reply = NewMessage(TypePong, msg.RequestID, nil)
+	case TypeSubmit, TypeCancel, TypeStatus:
+		reply = m.handler.HandleMessage(ctx, client.userID, msg)
+	default:
+		reply = NewErrorMessage(msg.RequestID, ErrCodeInvalidMessage, ErrUnknownMessageType.Error())
+	}
+
+	m.reply(ctx, client, reply)
+}
+
+func (m *Manager) reply(ctx context.Context, client *Client, msg Message) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		m.logger.ErrorContext(ctx, "Failed to encode websocket reply", "error", err)
+		return
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if err = client.Send(data); err != nil {
+		m.logger.WarnContext(ctx, "Failed to reply to client", "error", err, "userID", client.userID)
+	}
 }
 
+// SendToClient pushes a prompt result to every connection opened by the user.
+// A connection that can't take it does not keep it from the others, the push
+// fails only when no connection received it.
 func (m *Manager) SendToClient(ctx context.Context, userID string, data json.RawMessage) error {
+	frame, err := json.Marshal(Message{Type: TypeResult, Payload: data})
+	if err != nil {
+		m.logger.ErrorContext(ctx, "Failed to encode result", "error", err, "userID", userID)
+		return err
+	}
+
 	m.mu.RLock()
+	defer m.mu.RUnlock()
+
 	clients, err := m.clients.GetClientByID(userID)
-	m.mu.RUnlock()
 	if err != nil {
 		m.logger.ErrorContext(ctx, "No clients found for userID", "userID", userID)
 		return err
 	}
 
+	var errs []error
 	for _, client := range clients {
-		err = client.WriteMessage(websocket.TextMessage, data)
-		if err != nil {
-			m.logger.ErrorContext(ctx, "Error sending to client", "error", err, "userID", userID)
-			return err
+		if err = client.Send(frame); err != nil {
+			m.logger.WarnContext(ctx, "Error sending to client", "error", err, "userID", userID)
+			errs = append(errs, err)
 		}
 	}
+	if len(errs) == len(clients) {
+		return errors.Join(errs...)
+	}
 
 	return nil
 }
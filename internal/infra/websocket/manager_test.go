@@ -0,0 +1,69 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/websocket"
+	"log/slog"
+	"testing"
+)
+
+type nopHandler struct{}
+
+func (nopHandler) HandleMessage(context.Context, string, Message) Message {
+	return Message{}
+}
+
+// fill leaves no room in the send buffer of the client.
+func fill(client *Client) {
+	for client.Send(nil) == nil {
+	}
+}
+
+func TestSendToClient(t *testing.T) {
+	tests := []struct {
+		name     string
+		full     []bool
+		wantSent []bool
+		wantErr  error
+	}{
+		{name: "every connection", full: []bool{false, false}, wantSent: []bool{true, true}},
+		{name: "past a full connection", full: []bool{true, false}, wantSent: []bool{false, true}},
+		{name: "no connection", full: []bool{true, true}, wantSent: []bool{false, false}, wantErr: ErrSendBufferFull},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := NewHub()
+			clients := make([]*Client, len(tt.full))
+			queued := make([]int, len(tt.full))
+			for i, full := range tt.full {
+				clients[i] = hub.Add("user", nil)
+				if full {
+					fill(clients[i])
+				}
+				queued[i] = len(clients[i].send)
+			}
+			manager, err := NewManager(slog.New(slog.DiscardHandler), &websocket.Upgrader{}, hub, nopHandler{})
+			if err != nil {
+				t.Fatalf("NewManager unexpected error: %v", err)
+			}
+
+			err = manager.SendToClient(context.Background(), "user", json.RawMessage(`{}`))
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("SendToClient unexpected error: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SendToClient error = %v, want %v", err, tt.wantErr)
+			}
+
+			for i, client := range clients {
+				sent := len(client.send) > queued[i]
+				if sent != tt.wantSent[i] {
+					t.Fatalf("connection %d received the result = %v, want %v", i, sent, tt.wantSent[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+type MessageType string
+
+// Messages sent by the client.
+const (
+	TypeSubmit MessageType = "submit"
+	TypeCancel MessageType = "cancel"
+	TypeStatus MessageType = "status"
+	TypePing   MessageType = "ping"
+)
+
+// Messages sent by the server.
+const (
+	TypeAck    MessageType = "ack"
+	TypePong   MessageType = "pong"
+	TypeResult MessageType = "result"
+	TypeError  MessageType = "error"
+)
+
+var ErrUnknownMessageType = errors.New("unknown message type")
+
+// Message is the envelope of every frame exchanged over the socket.
+// RequestID is chosen by the client and echoed back in the reply, so the
+// client is able to correlate asynchronous answers with its requests.
+type Message struct {
+	Type      MessageType     `json:"type"`
+	RequestID string          `json:"request_id,omitempty"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+}
+
+type ErrorPayload struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+const (
+	ErrCodeInvalidMessage = "invalid_message"
+	ErrCodeNotFound       = "not_found"
+	ErrCodeConflict       = "conflict"
+	ErrCodeInternal       = "internal"
+)
+
+func NewMessage(messageType MessageType, requestID string, payload any) Message {
+	msg := Message{
+		Type:      messageType,
+		RequestID: requestID,
+	}
+	if payload != nil {
+		data, _ := json.Marshal(payload)
+		msg.Payload = data
+	}
+
+	return msg
+}
+
+func NewErrorMessage(requestID, code, message string) Message {
+	return NewMessage(TypeError, requestID, ErrorPayload{
+		Code:    code,
+		Message: message,
+	})
+}
@@ -0,0 +1,39 @@
+package catalogue
+
+import "ai-orchestrator/internal/domain/model"
+
+type ModelResponse struct {
+	ID              string        `json:"id"`
+	Provider        string        `json:"provider"`
+	DisplayName     string        `json:"display_name"`
+	ContextWindow   int           `json:"context_window"`
+	Price           PriceResponse `json:"price"`
+	Default         bool          `json:"default"`
+	MaxOutputTokens int32         `json:"max_output_tokens,omitempty"`
+	MaxTimeout      int           `json:"max_timeout_seconds,omitempty"`
+}
+
+type PriceResponse struct {
+	Input  float64 `json:"input_per_million_tokens"`
+	Output float64 `json:"output_per_million_tokens"`
+}
+
+type ListResponse struct {
+	Models []ModelResponse `json:"models"`
+}
+
+func FromDomain(m model.Model) ModelResponse {
+	return ModelResponse{
+		ID:            m.ID,
+		Provider:      m.Provider,
+		DisplayName:   m.DisplayName,
+		ContextWindow: m.ContextWindow,
+		Price: PriceResponse{
+			Input:  m.InputPrice,
+			Output: m.OutputPrice,
+		},
+		Default:         m.Default,
+		MaxOutputTokens: m.MaxOutputTokens,
+		MaxTimeout:      int(m.MaxTimeout.Seconds()),
+	}
+}
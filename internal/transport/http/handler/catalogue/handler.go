@@ -0,0 +1,52 @@
+package catalogue
+
+import (
+	"ai-orchestrator/internal/common/logger"
+	"ai-orchestrator/internal/domain/model"
+	"ai-orchestrator/internal/infra/telemetry/tracing"
+	"ai-orchestrator/internal/transport/http/helper"
+	"errors"
+	"net/http"
+)
+
+var ErrNilCatalogue = errors.New("model catalogue is nil")
+
+type Catalogue interface {
+	Enabled() []model.Model
+}
+
+type Handler struct {
+	logger    logger.Logger
+	catalogue Catalogue
+}
+
+func NewHandler(l logger.Logger, c Catalogue) (*Handler, error) {
+	if l == nil {
+		return nil, logger.ErrNilLogger
+	}
+	if c == nil {
+		return nil, ErrNilCatalogue
+	}
+
+	return &Handler{
+		logger:    l,
+		catalogue: c,
+	}, nil
+}
+
+// ListModels returns the models clients are allowed to use.
+func (h *Handler) ListModels(rw http.ResponseWriter, r *http.Request) {
+	span, ctx := tracing.InitContextFromHttp(r, "list_models")
+	defer span.End()
+	h.logger.InfoContext(ctx, "Incoming request:", "path", "catalogueHandler.ListModels")
+
+	enabled := h.catalogue.Enabled()
+	response := ListResponse{
+		Models: make([]ModelResponse, 0, len(enabled)),
+	}
+	for _, m := range enabled {
+		response.Models = append(response.Models, FromDomain(m))
+	}
+
+	helper.WriteJSONResponse(rw, http.StatusOK, response)
+}
@@ -0,0 +1,258 @@
+package prompt
+
+import (
+	"ai-orchestrator/internal/common/logger"
+	"ai-orchestrator/internal/config/api"
+	"ai-orchestrator/internal/domain/model"
+	"ai-orchestrator/internal/infra/telemetry/tracing"
+	"ai-orchestrator/internal/transport/http/helper"
+	usecase "ai-orchestrator/internal/use_case/prompt"
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+var ErrNilBatchConfig = errors.New("batch config is nil")
+
+type BatchService interface {
+	PostBatch(ctx context.Context, batch *model.Batch, prompts []model.Prompt) error
+	GetBatch(ctx context.Context, id, userID uuid.UUID) (*model.Batch, error)
+	GetBatchResults(ctx context.Context, id, userID uuid.UUID) ([]model.Prompt, error)
+}
+
+type BatchHandler struct {
+	logger    logger.Logger
+	service   BatchService
+	validator *Validator
+	cfg       *api.BatchConfig
+}
+
+func NewBatchHandler(l logger.Logger, s BatchService, v *Validator, cfg *api.BatchConfig) (*BatchHandler, error) {
+	if l == nil {
+		return nil, logger.ErrNilLogger
+	}
+	if s == nil {
+		return nil, ErrNilService
+	}
+	if v == nil {
+		return nil, ErrNilValidator
+	}
+	if cfg == nil {
+		return nil, ErrNilBatchConfig
+	}
+
+	return &BatchHandler{
+		logger:    l,
+		service:   s,
+		validator: v,
+		cfg:       cfg,
+	}, nil
+}
+
+// PostBatch accepts either a JSON object with the list of prompts, or a JSONL
+// body with a prompt per line and the owner in the userID query parameter.
+func (h *BatchHandler) PostBatch(rw http.ResponseWriter, r *http.Request) {
+	span, ctx := tracing.InitContextFromHttp(r, "post_batch")
+	defer span.End()
+	h.logger.InfoContext(ctx, "Incoming request:", "path", "batchHandler.PostBatch")
+
+	request, err := h.decodeBatchRequest(rw, r)
+	if err != nil {
+		h.logger.WarnContext(ctx, "failed to decode request body", "error", err, "handler", "batchHandler.PostBatch")
+		helper.WriteDecodeError(rw, err)
+		return
+	}
+
+	if errs := h.validate(request); !errs.Empty() {
+		h.logger.InfoContext(ctx, "request validation failed", "errors", errs, "handler", "batchHandler.PostBatch")
+		helper.WriteValidationErrors(rw, errs)
+		return
+	}
+
+	batch, prompts := request.ToDomain()
+	err = h.service.PostBatch(ctx, &batch, prompts)
+	var itemErr *usecase.BatchItemError
+	if errors.As(err, &itemErr) {
+		if errs := usecaseValidationErrors(itemErr.Err); !errs.Empty() {
+			helper.WriteValidationErrors(rw, prefixed(errs, itemErr.Index))
+			return
+		}
+	}
+	if err != nil {
+		h.logger.WarnContext(ctx, "failed to post batch", "error", err)
+		helper.WriteJSONError(rw, http.StatusInternalServerError, "failed to post batch", err)
+		return
+	}
+
+	helper.WriteJSONResponse(rw, http.StatusAccepted, BatchFromDomain(batch))
+}
+
+func (h *BatchHandler) GetBatch(rw http.ResponseWriter, r *http.Request) {
+	span, ctx := tracing.InitContextFromHttp(r, "get_batch")
+	defer span.End()
+	h.logger.InfoContext(ctx, "Incoming request:", "path", "batchHandler.GetBatch")
+
+	batchID, userID, ok := batchParams(rw, r)
+	if !ok {
+		return
+	}
+
+	batch, err := h.service.GetBatch(ctx, batchID, userID)
+	if errors.Is(err, model.ErrBatchNotFound) {
+		helper.WriteJSONError(rw, http.StatusNotFound, "batch not found", nil)
+		return
+	}
+	if err != nil {
+		h.logger.WarnContext(ctx, "failed to get batch", "error", err, "batch_id", batchID)
+		helper.WriteJSONError(rw, http.StatusInternalServerError, "failed to get batch", err)
+		return
+	}
+
+	helper.WriteJSONResponse(rw, http.StatusOK, BatchFromDomain(*batch))
+}
+
+// GetBatchResults streams the results of a completed batch as JSONL, in the submitted order.
+func (h *BatchHandler) GetBatchResults(rw http.ResponseWriter, r *http.Request) {
+	span, ctx := tracing.InitContextFromHttp(r, "get_batch_results")
+	defer span.End()
+	h.logger.InfoContext(ctx, "Incoming request:", "path", "batchHandler.GetBatchResults")
+
+	batchID, userID, ok := batchParams(rw, r)
+	if !ok {
+		return
+	}
+
+	prompts, err := h.service.GetBatchResults(ctx, batchID, userID)
+	switch {
+	case errors.Is(err, model.ErrBatchNotFound):
+		helper.WriteJSONError(rw, http.StatusNotFound, "batch not found", nil)
+		return
+	case errors.Is(err, model.ErrBatchNotComplete):
+		helper.WriteJSONError(rw, http.StatusConflict, "batch is still processing", nil)
+		return
+	case err != nil:
+		h.logger.WarnContext(ctx, "failed to get batch results", "error", err, "batch_id", batchID)
+		helper.WriteJSONError(rw, http.StatusInternalServerError, "failed to get batch results", err)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/x-ndjson")
+	rw.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="batch-%s.jsonl"`, batchID))
+	rw.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(rw)
+	for _, p := range prompts {
+		if err = encoder.Encode(BatchResultFromDomain(p)); err != nil {
+			h.logger.WarnContext(ctx, "failed to write batch results", "error", err, "batch_id", batchID)
+			return
+		}
+	}
+}
+
+func (h *BatchHandler) decodeBatchRequest(rw http.ResponseWriter, r *http.Request) (*BatchRequest, error) {
+	body := http.MaxBytesReader(rw, r.Body, h.cfg.MaxBodyBytes)
+
+	request := &BatchRequest{}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/x-ndjson", "application/jsonl", "application/jsonlines":
+	default:
+		return request, helper.FromJSON(body, request)
+	}
+
+	request.UserID, _ = uuid.Parse(r.URL.Query().Get("userID"))
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), int(h.validator.cfg.MaxBodyBytes))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var prompt CreateRequest
+		if err := helper.FromJSON(strings.NewReader(line), &prompt); err != nil {
+			return nil, err
+		}
+		request.Prompts = append(request.Prompts, prompt)
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, io.EOF) {
+		return nil, errors.Join(helper.ErrInvalidPayload, err)
+	}
+
+	return request, nil
+}
+
+func (h *BatchHandler) validate(r *BatchRequest) helper.ValidationErrors {
+	errs := helper.ValidationErrors{}
+
+	if r.UserID == uuid.Nil {
+		errs.Add("user_id", "is required")
+	}
+	switch {
+	case len(r.Prompts) == 0:
+		errs.Add("prompts", "must not be empty")
+	case len(r.Prompts) > h.cfg.MaxSize:
+		errs.Add("prompts", fmt.Sprintf("must contain at most %d prompts", h.cfg.MaxSize))
+	}
+	if !errs.Empty() {
+		return errs
+	}
+
+	for i := range r.Prompts {
+		prompt := &r.Prompts[i]
+		if prompt.UserID != uuid.Nil && prompt.UserID != r.UserID {
+			errs.Add(fmt.Sprintf("prompts[%d].user_id", i), "must match the batch owner")
+			continue
+		}
+		prompt.UserID = r.UserID
+
+		if prompt.RunAt != nil || prompt.Cron != "" {
+			errs.Add(fmt.Sprintf("prompts[%d].run_at", i), "is not accepted in batches")
+			continue
+		}
+
+		if len(prompt.Attachments) > 0 {
+			errs.Add(fmt.Sprintf("prompts[%d].attachments", i), "are not accepted in batches")
+			continue
+		}
+
+		for field, message := range prefixed(h.validator.ValidateCreate(prompt), i) {
+			errs.Add(field, message)
+		}
+	}
+
+	return errs
+}
+
+func prefixed(errs helper.ValidationErrors, index int) helper.ValidationErrors {
+	result := helper.ValidationErrors{}
+	for field, message := range errs {
+		result.Add(fmt.Sprintf("prompts[%d].%s", index, field), message)
+	}
+
+	return result
+}
+
+func batchParams(rw http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	batchID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		helper.WriteJSONError(rw, http.StatusBadRequest, "invalid batch id", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	userID, err := uuid.Parse(r.URL.Query().Get("userID"))
+	if err != nil {
+		helper.WriteJSONError(rw, http.StatusBadRequest, "missing or invalid userID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return batchID, userID, true
+}
@@ -0,0 +1,94 @@
+package prompt
+
+import (
+	"ai-orchestrator/internal/domain/model"
+	"encoding/json"
+	"github.com/google/uuid"
+	"time"
+)
+
+type BatchRequest struct {
+	UserID  uuid.UUID       `json:"user_id"`
+	Prompts []CreateRequest `json:"prompts"`
+}
+
+// ToDomain converts the prompts of the batch. Batch prompts are low priority unless specified otherwise.
+func (r *BatchRequest) ToDomain() (model.Batch, []model.Prompt) {
+	prompts := make([]model.Prompt, 0, len(r.Prompts))
+	for _, p := range r.Prompts {
+		prompt := p.ToDomain()
+		if prompt.Priority == "" {
+			prompt.Priority = model.PriorityLow
+		}
+		prompts = append(prompts, prompt)
+	}
+
+	return model.Batch{UserID: r.UserID}, prompts
+}
+
+type BatchResponse struct {
+	BatchID   uuid.UUID         `json:"batch_id"`
+	UserID    uuid.UUID         `json:"user_id"`
+	Status    model.BatchStatus `json:"status"`
+	Total     int               `json:"total"`
+	Completed int               `json:"completed"`
+	Failed    int               `json:"failed"`
+	Discarded int               `json:"discarded"`
+	Pending   int               `json:"pending"`
+	CreatedAt *time.Time        `json:"created_at,omitempty"`
+	UpdatedAt *time.Time        `json:"updated_at,omitempty"`
+}
+
+func BatchFromDomain(d model.Batch) BatchResponse {
+	response := BatchResponse{
+		BatchID:   d.ID,
+		UserID:    d.UserID,
+		Status:    d.Status,
+		Total:     d.Total,
+		Completed: d.Completed,
+		Failed:    d.Failed,
+		Discarded: d.Discarded,
+		Pending:   d.Pending(),
+	}
+	if !d.CreatedAt.IsZero() {
+		response.CreatedAt = &d.CreatedAt
+		response.UpdatedAt = &d.UpdatedAt
+	}
+
+	return response
+}
+
+// BatchResultLine is a line of the JSONL results of a batch.
+type BatchResultLine struct {
+	Index     int             `json:"index"`
+	PromptID  uuid.UUID       `json:"prompt_id"`
+	ModelID   string          `json:"model_id"`
+	Status    model.Status    `json:"status"`
+	Response  string          `json:"response,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	ErrorCode model.ErrorCode `json:"error_code,omitempty"`
+	Cached    bool            `json:"cached,omitempty"`
+
+	ServedModelID    string          `json:"served_model_id,omitempty"`
+	StructuredOutput json.RawMessage `json:"structured_output,omitempty"`
+}
+
+func BatchResultFromDomain(d model.Prompt) BatchResultLine {
+	line := BatchResultLine{
+		PromptID:  d.ID,
+		ModelID:   d.ModelID,
+		Status:    d.Status,
+		Response:  d.Response,
+		Error:     d.Error,
+		ErrorCode: d.ErrorCode,
+		Cached:    d.Cached,
+
+		ServedModelID:    d.ServedModelID,
+		StructuredOutput: d.StructuredOutput,
+	}
+	if d.Batch != nil {
+		line.Index = d.Batch.Index
+	}
+
+	return line
+}
@@ -0,0 +1,67 @@
+package prompt
+
+import (
+	"ai-orchestrator/internal/transport/http/helper"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+const (
+	multipartRequestField    = "request"
+	multipartAttachmentField = "attachments"
+
+	// multipartMemory is the part of a multipart body kept in memory, the rest spills to temporary files.
+	multipartMemory = 8 << 20
+)
+
+var ErrMissingRequestField = errors.New("multipart body has no request field")
+
+// decodeCreateRequest reads the request either from a JSON body, with base64
+// attachments, or from a multipart body carrying the JSON under the "request"
+// field and the files under the "attachments" field.
+func decodeCreateRequest(rw http.ResponseWriter, r *http.Request, maxBytes int64) (*CreateRequest, error) {
+	r.Body = http.MaxBytesReader(rw, r.Body, maxBytes)
+
+	request := &CreateRequest{}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return request, helper.FromJSON(r.Body, request)
+	}
+
+	err := r.ParseMultipartForm(multipartMemory)
+	if err != nil {
+		return nil, err
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	fields := r.MultipartForm.Value[multipartRequestField]
+	if len(fields) == 0 {
+		return nil, errors.Join(helper.ErrInvalidPayload, ErrMissingRequestField)
+	}
+	if err = helper.FromJSON(strings.NewReader(fields[0]), request); err != nil {
+		return nil, err
+	}
+
+	for _, header := range r.MultipartForm.File[multipartAttachmentField] {
+		file, err := header.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(file)
+		_ = file.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		request.Attachments = append(request.Attachments, AttachmentRequest{
+			Name:     header.Filename,
+			MimeType: header.Header.Get("Content-Type"),
+			Data:     data,
+		})
+	}
+
+	return request, nil
+}
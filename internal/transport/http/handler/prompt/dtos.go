@@ -2,34 +2,166 @@ package prompt
 
 import (
 	"ai-orchestrator/internal/domain/model"
+	"encoding/json"
 	"github.com/google/uuid"
+	"time"
 )
 
 type CreateRequest struct {
-	UserID  uuid.UUID `json:"user_id"`
-	ModelID string    `json:"model_id"`
-	Prompt  string    `json:"prompt"`
+	UserID         uuid.UUID `json:"user_id"`
+	ModelID        string    `json:"model_id"`
+	Prompt         string    `json:"prompt"`
+	TimeoutSeconds int       `json:"timeout_seconds,omitempty"`
+	Priority       string    `json:"priority,omitempty"`
+
+	// RunAt delays the prompt, Cron submits it repeatedly instead.
+	RunAt *time.Time `json:"run_at,omitempty"`
+	Cron  string     `json:"cron,omitempty"`
+
+	// The prompt text is rendered from the template when TemplateID is set, the latest version is used by default.
+	TemplateID      *uuid.UUID     `json:"template_id,omitempty"`
+	TemplateVersion int            `json:"template_version,omitempty"`
+	Variables       map[string]any `json:"variables,omitempty"`
+
+	// Attachments are either sent inline as base64 in a JSON body, or as files of a multipart body.
+	Attachments []AttachmentRequest `json:"attachments,omitempty"`
+
+	// Tools the model may call while answering, see model.Tools.
+	Tools []string `json:"tools,omitempty"`
+
+	model.GenerationOptions
+}
+
+type AttachmentRequest struct {
+	Name     string `json:"name"`
+	MimeType string `json:"mime_type"`
+	Data     []byte `json:"data"`
 }
 
 func (r *CreateRequest) ToDomain() model.Prompt {
-	return model.Prompt{
-		ID:      uuid.New(),
-		UserID:  r.UserID,
-		ModelID: r.ModelID,
-		Text:    r.Prompt,
+	prompt := model.Prompt{
+		ID:       uuid.New(),
+		UserID:   r.UserID,
+		ModelID:  r.ModelID,
+		Text:     r.Prompt,
+		Timeout:  time.Duration(r.TimeoutSeconds) * time.Second,
+		Options:  r.GenerationOptions,
+		Priority: model.Priority(r.Priority),
+
+		Attachments: attachmentsToDomain(r.Attachments),
+		Template:    r.templateRef(),
+		Tools:       r.Tools,
+	}
+	if r.RunAt != nil {
+		prompt.RunAt = r.RunAt.UTC()
+	}
+
+	return prompt
+}
+
+func (r *CreateRequest) ToSchedule() model.Schedule {
+	prompt := r.ToDomain()
+
+	return model.Schedule{
+		UserID: r.UserID,
+		Cron:   r.Cron,
+		Prompt: prompt,
+	}
+}
+
+func (r *CreateRequest) templateRef() *model.TemplateRef {
+	if r.TemplateID == nil {
+		return nil
+	}
+
+	return &model.TemplateRef{
+		ID:        *r.TemplateID,
+		Version:   r.TemplateVersion,
+		Variables: r.Variables,
 	}
 }
 
+func attachmentsToDomain(attachments []AttachmentRequest) []model.Attachment {
+	if len(attachments) == 0 {
+		return nil
+	}
+
+	domain := make([]model.Attachment, 0, len(attachments))
+	for _, a := range attachments {
+		domain = append(domain, model.Attachment{
+			Name:     a.Name,
+			MimeType: a.MimeType,
+			Size:     int64(len(a.Data)),
+			Data:     a.Data,
+		})
+	}
+
+	return domain
+}
+
 type ResultResponse struct {
-	PromptID uuid.UUID `json:"prompt_id"`
-	UserID   uuid.UUID `json:"user_id"`
-	Message  string    `json:"message"`
+	PromptID uuid.UUID  `json:"prompt_id"`
+	UserID   uuid.UUID  `json:"user_id"`
+	Message  string     `json:"message"`
+	Deadline *time.Time `json:"deadline,omitempty"`
+	RunAt    *time.Time `json:"run_at,omitempty"`
+	Response string     `json:"response,omitempty"`
+	Cached   bool       `json:"cached,omitempty"`
+
+	StructuredOutput json.RawMessage `json:"structured_output,omitempty"`
 }
 
 func FromDomain(domain model.Prompt, message string) ResultResponse {
-	return ResultResponse{
+	response := ResultResponse{
 		PromptID: domain.ID,
 		UserID:   domain.UserID,
 		Message:  message,
 	}
+	if !domain.Deadline.IsZero() {
+		response.Deadline = &domain.Deadline
+	}
+	if !domain.RunAt.IsZero() {
+		response.RunAt = &domain.RunAt
+	}
+	if domain.Cached {
+		response.Response = domain.Response
+		response.Cached = true
+		response.StructuredOutput = domain.StructuredOutput
+	}
+
+	return response
+}
+
+type ScheduleResponse struct {
+	ScheduleID uuid.UUID  `json:"schedule_id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	Cron       string     `json:"cron"`
+	ModelID    string     `json:"model_id"`
+	Prompt     string     `json:"prompt,omitempty"`
+	TemplateID *uuid.UUID `json:"template_id,omitempty"`
+	NextRunAt  time.Time  `json:"next_run_at"`
+	LastRunAt  *time.Time `json:"last_run_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type ScheduleListResponse struct {
+	Schedules []ScheduleResponse `json:"schedules"`
+}
+
+func ScheduleFromDomain(d model.Schedule) ScheduleResponse {
+	response := ScheduleResponse{
+		ScheduleID: d.ID,
+		UserID:     d.UserID,
+		Cron:       d.Cron,
+		ModelID:    d.Prompt.ModelID,
+		Prompt:     d.Prompt.Text,
+		NextRunAt:  d.NextRunAt,
+		LastRunAt:  d.LastRunAt,
+		CreatedAt:  d.CreatedAt,
+	}
+	if d.Prompt.Template != nil {
+		response.TemplateID = &d.Prompt.Template.ID
+	}
+
+	return response
 }
@@ -7,31 +7,53 @@ import (
 	"ai-orchestrator/internal/transport/http/helper"
 	"context"
 	"errors"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
 	"net/http"
+	"strings"
 )
 
 var ErrNilService = errors.New("service is nil")
 
 type Service interface {
-	PostPrompt(ctx context.Context, prompt model.Prompt) error
+	PostPrompt(ctx context.Context, prompt *model.Prompt) error
 }
 
+type CancelService interface {
+	CancelPrompt(ctx context.Context, id, userID uuid.UUID) error
+}
+
+type ScheduleCreator interface {
+	CreateSchedule(ctx context.Context, schedule *model.Schedule) error
+}
+
+var ErrNilValidator = errors.New("validator is nil")
+
 type Handler struct {
-	logger  logger.Logger
-	service Service
+	logger        logger.Logger
+	service       Service
+	cancelService CancelService
+	schedules     ScheduleCreator
+	validator     *Validator
 }
 
-func NewHandler(l logger.Logger, s Service) (*Handler, error) {
+func NewHandler(l logger.Logger, s Service, cs CancelService, sc ScheduleCreator, v *Validator) (*Handler, error) {
 	if l == nil {
 		return nil, logger.ErrNilLogger
 	}
-	if s == nil {
+	if s == nil || cs == nil || sc == nil {
 		return nil, ErrNilService
 	}
+	if v == nil {
+		return nil, ErrNilValidator
+	}
 
 	return &Handler{
-		logger:  l,
-		service: s,
+		logger:        l,
+		service:       s,
+		cancelService: cs,
+		schedules:     sc,
+		validator:     v,
 	}, nil
 }
 
@@ -40,22 +62,140 @@ func (h *Handler) PostPrompt(rw http.ResponseWriter, r *http.Request) {
 	defer span.End()
 	h.logger.InfoContext(ctx, "Incoming request:", "path", "promptHandler.PostPrompt")
 
-	userPrompt := &CreateRequest{}
-	err := helper.FromJSON(r.Body, userPrompt)
+	userPrompt, err := decodeCreateRequest(rw, r, h.validator.MaxBodyBytes())
 	if err != nil {
-		h.logger.WarnContext(ctx, "failed to decode request body", "error", err, "request_body", r.Body, "handler", "promptHandler.PostPrompt")
-		helper.WriteJSONError(rw, http.StatusBadRequest, "invalid request body", nil)
+		h.logger.WarnContext(ctx, "failed to decode request body", "error", err, "handler", "promptHandler.PostPrompt")
+		helper.WriteDecodeError(rw, err)
+		return
+	}
+
+	if errs := h.validator.ValidateCreate(userPrompt); !errs.Empty() {
+		h.logger.InfoContext(ctx, "request validation failed", "errors", errs, "handler", "promptHandler.PostPrompt")
+		helper.WriteValidationErrors(rw, errs)
+		return
+	}
+
+	if userPrompt.Cron != "" {
+		h.postSchedule(ctx, rw, userPrompt)
 		return
 	}
 
 	domainPrompt := userPrompt.ToDomain()
-	err = h.service.PostPrompt(ctx, domainPrompt)
+	domainPrompt.BypassCache = bypassCache(r)
+	err = h.service.PostPrompt(ctx, &domainPrompt)
+	if errs := usecaseValidationErrors(err); !errs.Empty() {
+		helper.WriteValidationErrors(rw, errs)
+		return
+	}
 	if err != nil {
 		h.logger.WarnContext(ctx, "failed to post prompt", "error", err, "domainPrompt", domainPrompt)
 		helper.WriteJSONError(rw, http.StatusInternalServerError, "failed to post prompt", err)
 		return
 	}
 
-	response := FromDomain(domainPrompt, "Processing started")
+	if domainPrompt.Cached {
+		helper.WriteJSONResponse(rw, http.StatusOK, FromDomain(domainPrompt, "Served from cache"))
+		return
+	}
+
+	message := "Processing started"
+	if !domainPrompt.RunAt.IsZero() {
+		message = "Processing scheduled"
+	}
+	response := FromDomain(domainPrompt, message)
 	helper.WriteJSONResponse(rw, http.StatusAccepted, response)
 }
+
+// postSchedule saves a prompt submitted with a cron expression, no prompt is created until the first firing.
+func (h *Handler) postSchedule(ctx context.Context, rw http.ResponseWriter, userPrompt *CreateRequest) {
+	schedule := userPrompt.ToSchedule()
+	err := h.schedules.CreateSchedule(ctx, &schedule)
+	if errs := usecaseValidationErrors(err); !errs.Empty() {
+		helper.WriteValidationErrors(rw, errs)
+		return
+	}
+	if err != nil {
+		h.logger.WarnContext(ctx, "failed to create schedule", "error", err)
+		helper.WriteJSONError(rw, http.StatusInternalServerError, "failed to create schedule", err)
+		return
+	}
+
+	helper.WriteJSONResponse(rw, http.StatusAccepted, ScheduleFromDomain(schedule))
+}
+
+// bypassCache tells whether the client asked for a fresh response with "Cache-Control: no-cache".
+func bypassCache(r *http.Request) bool {
+	for _, directive := range strings.Split(r.Header.Get("Cache-Control"), ",") {
+		if strings.EqualFold(strings.TrimSpace(directive), "no-cache") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (h *Handler) DeletePrompt(rw http.ResponseWriter, r *http.Request) {
+	span, ctx := tracing.InitContextFromHttp(r, "delete_prompt")
+	defer span.End()
+	h.logger.InfoContext(ctx, "Incoming request:", "path", "promptHandler.DeletePrompt")
+
+	promptID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		helper.WriteJSONError(rw, http.StatusBadRequest, "invalid prompt id", nil)
+		return
+	}
+	userID, err := uuid.Parse(r.URL.Query().Get("userID"))
+	if err != nil {
+		helper.WriteJSONError(rw, http.StatusBadRequest, "missing or invalid userID", nil)
+		return
+	}
+
+	err = h.cancelService.CancelPrompt(ctx, promptID, userID)
+	switch {
+	case errors.Is(err, model.ErrPromptNotFound):
+		helper.WriteJSONError(rw, http.StatusNotFound, "prompt not found", nil)
+		return
+	case errors.Is(err, model.ErrPromptNotCancellable):
+		helper.WriteJSONError(rw, http.StatusConflict, "prompt cannot be cancelled", err)
+		return
+	case err != nil:
+		h.logger.WarnContext(ctx, "failed to cancel prompt", "error", err, "prompt_id", promptID)
+		helper.WriteJSONError(rw, http.StatusInternalServerError, "failed to cancel prompt", err)
+		return
+	}
+
+	response := ResultResponse{
+		PromptID: promptID,
+		UserID:   userID,
+		Message:  "Prompt discarded",
+	}
+	helper.WriteJSONResponse(rw, http.StatusOK, response)
+}
+
+// usecaseValidationErrors converts the per-model checks made by the use case into field errors.
+func usecaseValidationErrors(err error) helper.ValidationErrors {
+	errs := helper.ValidationErrors{}
+
+	switch {
+	case errors.Is(err, model.ErrUnknownModel), errors.Is(err, model.ErrModelDisabled), errors.Is(err, model.ErrNoModel):
+		errs.Add("model_id", err.Error())
+	case errors.Is(err, model.ErrInvalidTimeout):
+		errs.Add("timeout_seconds", err.Error())
+	case errors.Is(err, model.ErrInvalidGenerationOptions):
+		errs.Add("options", err.Error())
+	case errors.Is(err, model.ErrInvalidPriority):
+		errs.Add("priority", err.Error())
+	case errors.Is(err, model.ErrInvalidAttachments):
+		errs.Add("attachments", err.Error())
+	case errors.Is(err, model.ErrInvalidTools):
+		errs.Add("tools", err.Error())
+	case errors.Is(err, model.ErrInvalidCron):
+		errs.Add("cron", err.Error())
+	case errors.Is(err, model.ErrTemplateNotFound):
+		errs.Add("template_id", err.Error())
+	case errors.Is(err, model.ErrInvalidTemplate), errors.Is(err, model.ErrTemplateRender):
+		errs.Add("variables", err.Error())
+	}
+
+	return errs
+}
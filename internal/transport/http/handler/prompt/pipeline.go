@@ -0,0 +1,167 @@
+package prompt
+
+import (
+	"ai-orchestrator/internal/common/logger"
+	"ai-orchestrator/internal/config/api"
+	"ai-orchestrator/internal/domain/model"
+	"ai-orchestrator/internal/infra/telemetry/tracing"
+	"ai-orchestrator/internal/transport/http/helper"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"net/http"
+	"regexp"
+)
+
+var ErrNilPipelineConfig = errors.New("pipeline config is nil")
+
+// Step names are used in templates as {{.steps.name}}, so they must be identifiers.
+var stepName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+type PipelineService interface {
+	StartPipeline(ctx context.Context, pipeline *model.Pipeline) error
+	GetPipeline(ctx context.Context, id, userID uuid.UUID) (*model.Pipeline, error)
+}
+
+type PipelineHandler struct {
+	logger    logger.Logger
+	service   PipelineService
+	validator *Validator
+	cfg       *api.PipelineConfig
+}
+
+func NewPipelineHandler(l logger.Logger, s PipelineService, v *Validator, cfg *api.PipelineConfig) (*PipelineHandler, error) {
+	if l == nil {
+		return nil, logger.ErrNilLogger
+	}
+	if s == nil {
+		return nil, ErrNilService
+	}
+	if v == nil {
+		return nil, ErrNilValidator
+	}
+	if cfg == nil {
+		return nil, ErrNilPipelineConfig
+	}
+
+	return &PipelineHandler{
+		logger:    l,
+		service:   s,
+		validator: v,
+		cfg:       cfg,
+	}, nil
+}
+
+func (h *PipelineHandler) PostPipeline(rw http.ResponseWriter, r *http.Request) {
+	span, ctx := tracing.InitContextFromHttp(r, "post_pipeline")
+	defer span.End()
+	h.logger.InfoContext(ctx, "Incoming request:", "path", "pipelineHandler.PostPipeline")
+
+	request := &PipelineRequest{}
+	err := helper.FromJSON(http.MaxBytesReader(rw, r.Body, h.validator.cfg.MaxBodyBytes), request)
+	if err != nil {
+		h.logger.WarnContext(ctx, "failed to decode request body", "error", err, "handler", "pipelineHandler.PostPipeline")
+		helper.WriteDecodeError(rw, err)
+		return
+	}
+
+	if errs := h.validate(request); !errs.Empty() {
+		h.logger.InfoContext(ctx, "request validation failed", "errors", errs, "handler", "pipelineHandler.PostPipeline")
+		helper.WriteValidationErrors(rw, errs)
+		return
+	}
+
+	pipeline := request.ToDomain()
+	err = h.service.StartPipeline(ctx, &pipeline)
+	if errors.Is(err, model.ErrInvalidPipeline) {
+		errs := helper.ValidationErrors{}
+		errs.Add("steps", err.Error())
+		helper.WriteValidationErrors(rw, errs)
+		return
+	}
+	if errs := usecaseValidationErrors(err); !errs.Empty() {
+		helper.WriteValidationErrors(rw, errs)
+		return
+	}
+	if err != nil {
+		h.logger.WarnContext(ctx, "failed to start pipeline", "error", err)
+		helper.WriteJSONError(rw, http.StatusInternalServerError, "failed to start pipeline", err)
+		return
+	}
+
+	helper.WriteJSONResponse(rw, http.StatusAccepted, PipelineFromDomain(pipeline))
+}
+
+func (h *PipelineHandler) GetPipeline(rw http.ResponseWriter, r *http.Request) {
+	span, ctx := tracing.InitContextFromHttp(r, "get_pipeline")
+	defer span.End()
+	h.logger.InfoContext(ctx, "Incoming request:", "path", "pipelineHandler.GetPipeline")
+
+	pipelineID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		helper.WriteJSONError(rw, http.StatusBadRequest, "invalid pipeline id", nil)
+		return
+	}
+	userID, err := uuid.Parse(r.URL.Query().Get("userID"))
+	if err != nil {
+		helper.WriteJSONError(rw, http.StatusBadRequest, "missing or invalid userID", nil)
+		return
+	}
+
+	pipeline, err := h.service.GetPipeline(ctx, pipelineID, userID)
+	if errors.Is(err, model.ErrPipelineNotFound) {
+		helper.WriteJSONError(rw, http.StatusNotFound, "pipeline not found", nil)
+		return
+	}
+	if err != nil {
+		h.logger.WarnContext(ctx, "failed to get pipeline", "error", err, "pipeline_id", pipelineID)
+		helper.WriteJSONError(rw, http.StatusInternalServerError, "failed to get pipeline", err)
+		return
+	}
+
+	helper.WriteJSONResponse(rw, http.StatusOK, PipelineFromDomain(*pipeline))
+}
+
+// validate checks every step like a submitted prompt. The shape of the graph is checked by the use case.
+func (h *PipelineHandler) validate(r *PipelineRequest) helper.ValidationErrors {
+	errs := helper.ValidationErrors{}
+
+	if r.UserID == uuid.Nil {
+		errs.Add("user_id", "is required")
+	}
+	if _, ok := r.Variables["steps"]; ok {
+		errs.Add("variables", `"steps" is reserved for the responses of the steps`)
+	}
+	switch {
+	case len(r.Steps) == 0:
+		errs.Add("steps", "must not be empty")
+	case len(r.Steps) > h.cfg.MaxSteps:
+		errs.Add("steps", fmt.Sprintf("must contain at most %d steps", h.cfg.MaxSteps))
+	}
+	if !errs.Empty() {
+		return errs
+	}
+
+	for i := range r.Steps {
+		step := &r.Steps[i]
+		prefix := fmt.Sprintf("steps[%d].", i)
+		if !stepName.MatchString(step.Name) {
+			errs.Add(prefix+"name", "must be made of letters, digits and underscores")
+		}
+
+		for field, message := range h.validator.ValidateCreate(step.asCreateRequest(r.UserID, r.Priority)) {
+			switch field {
+			case "user_id":
+				continue
+			case "priority":
+				errs.Add(field, message)
+			default:
+				errs.Add(prefix+field, message)
+			}
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,128 @@
+package prompt
+
+import (
+	"ai-orchestrator/internal/domain/model"
+	"github.com/google/uuid"
+	"time"
+)
+
+type PipelineRequest struct {
+	UserID    uuid.UUID      `json:"user_id"`
+	Priority  string         `json:"priority,omitempty"`
+	Variables map[string]any `json:"variables,omitempty"`
+	Steps     []StepRequest  `json:"steps"`
+}
+
+// StepRequest renders its prompt from either the inline Prompt template or a
+// stored template. Without depends_on a step depends on the previous one, so
+// a plain list of steps runs in order.
+type StepRequest struct {
+	Name            string     `json:"name"`
+	ModelID         string     `json:"model_id"`
+	Prompt          string     `json:"prompt,omitempty"`
+	TemplateID      *uuid.UUID `json:"template_id,omitempty"`
+	TemplateVersion int        `json:"template_version,omitempty"`
+	DependsOn       *[]string  `json:"depends_on,omitempty"`
+
+	model.GenerationOptions
+}
+
+// asCreateRequest lets the step go through the checks of a submitted prompt.
+func (r *StepRequest) asCreateRequest(userID uuid.UUID, priority string) *CreateRequest {
+	return &CreateRequest{
+		UserID:            userID,
+		ModelID:           r.ModelID,
+		Prompt:            r.Prompt,
+		Priority:          priority,
+		TemplateID:        r.TemplateID,
+		TemplateVersion:   r.TemplateVersion,
+		GenerationOptions: r.GenerationOptions,
+	}
+}
+
+func (r *PipelineRequest) ToDomain() model.Pipeline {
+	pipeline := model.Pipeline{
+		UserID:    r.UserID,
+		Priority:  model.Priority(r.Priority),
+		Variables: r.Variables,
+		Steps:     make([]model.PipelineStep, 0, len(r.Steps)),
+	}
+
+	for i, s := range r.Steps {
+		step := model.PipelineStep{
+			Name:    s.Name,
+			ModelID: s.ModelID,
+			Body:    s.Prompt,
+			Options: s.GenerationOptions,
+		}
+		switch {
+		case s.DependsOn != nil:
+			step.DependsOn = *s.DependsOn
+		case i > 0:
+			step.DependsOn = []string{r.Steps[i-1].Name}
+		}
+		if s.TemplateID != nil {
+			step.Template = &model.TemplateRef{
+				ID:      *s.TemplateID,
+				Version: s.TemplateVersion,
+			}
+		}
+		pipeline.Steps = append(pipeline.Steps, step)
+	}
+
+	return pipeline
+}
+
+type PipelineResponse struct {
+	PipelineID uuid.UUID            `json:"pipeline_id"`
+	UserID     uuid.UUID            `json:"user_id"`
+	Status     model.PipelineStatus `json:"status"`
+	Priority   model.Priority       `json:"priority"`
+	Steps      []StepResponse       `json:"steps"`
+	CreatedAt  *time.Time           `json:"created_at,omitempty"`
+	UpdatedAt  *time.Time           `json:"updated_at,omitempty"`
+}
+
+type StepResponse struct {
+	Name      string           `json:"name"`
+	ModelID   string           `json:"model_id"`
+	DependsOn []string         `json:"depends_on"`
+	Status    model.StepStatus `json:"status"`
+	PromptID  *uuid.UUID       `json:"prompt_id,omitempty"`
+	Response  string           `json:"response,omitempty"`
+	Error     string           `json:"error,omitempty"`
+	ErrorCode model.ErrorCode  `json:"error_code,omitempty"`
+}
+
+func PipelineFromDomain(d model.Pipeline) PipelineResponse {
+	response := PipelineResponse{
+		PipelineID: d.ID,
+		UserID:     d.UserID,
+		Status:     d.Status,
+		Priority:   d.Priority,
+		Steps:      make([]StepResponse, 0, len(d.Steps)),
+	}
+	if !d.CreatedAt.IsZero() {
+		response.CreatedAt = &d.CreatedAt
+		response.UpdatedAt = &d.UpdatedAt
+	}
+
+	for _, s := range d.Steps {
+		step := StepResponse{
+			Name:      s.Name,
+			ModelID:   s.ModelID,
+			DependsOn: s.DependsOn,
+			Status:    s.Status,
+			PromptID:  s.PromptID,
+			Response:  s.Response,
+			Error:     s.Error,
+			ErrorCode: s.ErrorCode,
+		}
+		if step.DependsOn == nil {
+			step.DependsOn = []string{}
+		}
+		response.Steps = append(response.Steps, step)
+	}
+
+	return response
+}
@@ -0,0 +1,128 @@
+package prompt
+
+import (
+	"ai-orchestrator/internal/common/logger"
+	"ai-orchestrator/internal/domain/model"
+	"ai-orchestrator/internal/infra/telemetry/tracing"
+	"ai-orchestrator/internal/transport/http/helper"
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+type ScheduleService interface {
+	GetSchedule(ctx context.Context, id, userID uuid.UUID) (*model.Schedule, error)
+	ListSchedules(ctx context.Context, userID uuid.UUID) ([]model.Schedule, error)
+	DeleteSchedule(ctx context.Context, id, userID uuid.UUID) error
+}
+
+// ScheduleHandler serves the schedules created by submitting a prompt with a cron expression.
+type ScheduleHandler struct {
+	logger  logger.Logger
+	service ScheduleService
+}
+
+func NewScheduleHandler(l logger.Logger, s ScheduleService) (*ScheduleHandler, error) {
+	if l == nil {
+		return nil, logger.ErrNilLogger
+	}
+	if s == nil {
+		return nil, ErrNilService
+	}
+
+	return &ScheduleHandler{
+		logger:  l,
+		service: s,
+	}, nil
+}
+
+func (h *ScheduleHandler) ListSchedules(rw http.ResponseWriter, r *http.Request) {
+	span, ctx := tracing.InitContextFromHttp(r, "list_schedules")
+	defer span.End()
+	h.logger.InfoContext(ctx, "Incoming request:", "path", "scheduleHandler.ListSchedules")
+
+	userID, err := uuid.Parse(r.URL.Query().Get("userID"))
+	if err != nil {
+		helper.WriteJSONError(rw, http.StatusBadRequest, "missing or invalid userID", nil)
+		return
+	}
+
+	schedules, err := h.service.ListSchedules(ctx, userID)
+	if err != nil {
+		h.logger.WarnContext(ctx, "failed to list schedules", "error", err)
+		helper.WriteJSONError(rw, http.StatusInternalServerError, "failed to list schedules", err)
+		return
+	}
+
+	response := ScheduleListResponse{Schedules: make([]ScheduleResponse, 0, len(schedules))}
+	for _, s := range schedules {
+		response.Schedules = append(response.Schedules, ScheduleFromDomain(s))
+	}
+	helper.WriteJSONResponse(rw, http.StatusOK, response)
+}
+
+func (h *ScheduleHandler) GetSchedule(rw http.ResponseWriter, r *http.Request) {
+	span, ctx := tracing.InitContextFromHttp(r, "get_schedule")
+	defer span.End()
+	h.logger.InfoContext(ctx, "Incoming request:", "path", "scheduleHandler.GetSchedule")
+
+	scheduleID, userID, ok := scheduleParams(rw, r)
+	if !ok {
+		return
+	}
+
+	schedule, err := h.service.GetSchedule(ctx, scheduleID, userID)
+	if errors.Is(err, model.ErrScheduleNotFound) {
+		helper.WriteJSONError(rw, http.StatusNotFound, "schedule not found", nil)
+		return
+	}
+	if err != nil {
+		h.logger.WarnContext(ctx, "failed to get schedule", "error", err, "schedule_id", scheduleID)
+		helper.WriteJSONError(rw, http.StatusInternalServerError, "failed to get schedule", err)
+		return
+	}
+
+	helper.WriteJSONResponse(rw, http.StatusOK, ScheduleFromDomain(*schedule))
+}
+
+// DeleteSchedule stops the schedule, the prompts it already submitted keep running.
+func (h *ScheduleHandler) DeleteSchedule(rw http.ResponseWriter, r *http.Request) {
+	span, ctx := tracing.InitContextFromHttp(r, "delete_schedule")
+	defer span.End()
+	h.logger.InfoContext(ctx, "Incoming request:", "path", "scheduleHandler.DeleteSchedule")
+
+	scheduleID, userID, ok := scheduleParams(rw, r)
+	if !ok {
+		return
+	}
+
+	err := h.service.DeleteSchedule(ctx, scheduleID, userID)
+	if errors.Is(err, model.ErrScheduleNotFound) {
+		helper.WriteJSONError(rw, http.StatusNotFound, "schedule not found", nil)
+		return
+	}
+	if err != nil {
+		h.logger.WarnContext(ctx, "failed to delete schedule", "error", err, "schedule_id", scheduleID)
+		helper.WriteJSONError(rw, http.StatusInternalServerError, "failed to delete schedule", err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func scheduleParams(rw http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	scheduleID, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		helper.WriteJSONError(rw, http.StatusBadRequest, "invalid schedule id", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	userID, err := uuid.Parse(r.URL.Query().Get("userID"))
+	if err != nil {
+		helper.WriteJSONError(rw, http.StatusBadRequest, "missing or invalid userID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return scheduleID, userID, true
+}
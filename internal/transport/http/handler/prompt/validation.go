@@ -0,0 +1,164 @@
+package prompt
+
+import (
+	"ai-orchestrator/internal/config/api"
+	"ai-orchestrator/internal/domain/model"
+	"ai-orchestrator/internal/transport/http/helper"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrNilValidationConfig = errors.New("validation config is nil")
+	ErrNilCatalogue        = errors.New("model catalogue is nil")
+)
+
+type Catalogue interface {
+	Resolve(id string) (model.Model, error)
+}
+
+// Validator performs the request checks that do not need the database, so
+// malformed prompts are rejected before anything is persisted.
+type Validator struct {
+	cfg       *api.ValidationConfig
+	catalogue Catalogue
+}
+
+func NewValidator(cfg *api.ValidationConfig, catalogue Catalogue) (*Validator, error) {
+	if cfg == nil {
+		return nil, ErrNilValidationConfig
+	}
+	if catalogue == nil {
+		return nil, ErrNilCatalogue
+	}
+
+	return &Validator{
+		cfg:       cfg,
+		catalogue: catalogue,
+	}, nil
+}
+
+// MaxBodyBytes is the body limit extended by the attachment budget. Base64
+// inflates the attachments by a third, which covers the multipart overhead too.
+func (v *Validator) MaxBodyBytes() int64 {
+	attachments := v.cfg.Attachments
+	return v.cfg.MaxBodyBytes + int64(attachments.MaxCount)*attachments.MaxSize*4/3
+}
+
+func (v *Validator) ValidateCreate(r *CreateRequest) helper.ValidationErrors {
+	errs := helper.ValidationErrors{}
+
+	if r.UserID == uuid.Nil {
+		errs.Add("user_id", "is required")
+	}
+
+	switch {
+	case r.TemplateID != nil && r.Prompt != "":
+		errs.Add("prompt", "must be empty when template_id is set")
+	case r.TemplateID != nil:
+		if r.TemplateVersion < 0 {
+			errs.Add("template_version", "must be positive")
+		}
+	case r.TemplateVersion != 0 || len(r.Variables) > 0:
+		errs.Add("template_id", "is required along with template_version and variables")
+	case strings.TrimSpace(r.Prompt) == "":
+		errs.Add("prompt", "must not be empty")
+	case utf8.RuneCountInString(r.Prompt) > v.cfg.MaxPromptLength:
+		errs.Add("prompt", fmt.Sprintf("must be at most %d characters long", v.cfg.MaxPromptLength))
+	}
+
+	// An empty model ID falls back to the default model of the catalogue.
+	m, err := v.catalogue.Resolve(r.ModelID)
+	if err != nil {
+		errs.Add("model_id", err.Error())
+	}
+	if len(r.Attachments) > 0 && err == nil && !m.Attachments {
+		errs.Add("attachments", fmt.Sprintf("model %q does not accept attachments", m.ID))
+	}
+	if len(r.Tools) > 0 && err == nil && !m.Tools {
+		errs.Add("tools", fmt.Sprintf("model %q does not support tools", m.ID))
+	}
+	v.validateAttachments(r.Attachments, errs)
+	if err := model.ValidateTools(r.Tools); err != nil {
+		errs.Add("tools", err.Error())
+	}
+
+	if _, err := model.ParsePriority(r.Priority); err != nil {
+		errs.Add("priority", err.Error())
+	}
+	switch {
+	case r.Cron != "" && r.RunAt != nil:
+		errs.Add("cron", "must be empty when run_at is set")
+	case r.Cron != "":
+		if _, err := model.ParseCron(r.Cron); err != nil {
+			errs.Add("cron", err.Error())
+		}
+		// Every firing is a fresh prompt, while attachments are stored for a single one.
+		if len(r.Attachments) > 0 {
+			errs.Add("attachments", "are not accepted with cron")
+		}
+		// Schedules keep no tools, a firing would run without them.
+		if len(r.Tools) > 0 {
+			errs.Add("tools", "are not accepted with cron")
+		}
+	}
+	if r.TimeoutSeconds < 0 {
+		errs.Add("timeout_seconds", "must be positive")
+	}
+	if t := r.Temperature; t != nil && (*t < 0 || *t > 2) {
+		errs.Add("temperature", "must be between 0 and 2")
+	}
+	if p := r.TopP; p != nil && (*p < 0 || *p > 1) {
+		errs.Add("top_p", "must be between 0 and 1")
+	}
+	if r.MaxOutputTokens < 0 {
+		errs.Add("max_output_tokens", "must be positive")
+	}
+
+	return errs
+}
+
+func (v *Validator) validateAttachments(attachments []AttachmentRequest, errs helper.ValidationErrors) {
+	limits := v.cfg.Attachments
+	if len(attachments) > limits.MaxCount {
+		errs.Add("attachments", fmt.Sprintf("must contain at most %d files", limits.MaxCount))
+		return
+	}
+
+	for i := range attachments {
+		a := &attachments[i]
+		field := fmt.Sprintf("attachments[%d]", i)
+
+		size := int64(len(a.Data))
+		switch {
+		case size == 0:
+			errs.Add(field, "must not be empty")
+			continue
+		case size > limits.MaxSize:
+			errs.Add(field, fmt.Sprintf("must be at most %d bytes long", limits.MaxSize))
+			continue
+		}
+
+		// The declared type is normalised, and sniffed from the content when missing.
+		declared := a.MimeType
+		if declared == "" {
+			declared = http.DetectContentType(a.Data)
+		}
+		mimeType, _, err := mime.ParseMediaType(declared)
+		if err != nil {
+			errs.Add(field, "has an invalid mime type")
+			continue
+		}
+		if !slices.Contains(limits.AllowedTypes, mimeType) {
+			errs.Add(field, fmt.Sprintf("type %q is not allowed", mimeType))
+			continue
+		}
+		a.MimeType = mimeType
+	}
+}
@@ -0,0 +1,46 @@
+package template
+
+import (
+	"ai-orchestrator/internal/domain/model"
+	"github.com/google/uuid"
+	"time"
+)
+
+type SaveRequest struct {
+	UserID uuid.UUID `json:"user_id"`
+	Name   string    `json:"name"`
+	Body   string    `json:"body"`
+}
+
+func (r *SaveRequest) ToDomain(id uuid.UUID) model.Template {
+	return model.Template{
+		ID:     id,
+		UserID: r.UserID,
+		Name:   r.Name,
+		Body:   r.Body,
+	}
+}
+
+type TemplateResponse struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Version   int       `json:"version"`
+	Name      string    `json:"name"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ListResponse struct {
+	Templates []TemplateResponse `json:"templates"`
+}
+
+func FromDomain(t model.Template) TemplateResponse {
+	return TemplateResponse{
+		ID:        t.ID,
+		UserID:    t.UserID,
+		Version:   t.Version,
+		Name:      t.Name,
+		Body:      t.Body,
+		CreatedAt: t.CreatedAt,
+	}
+}
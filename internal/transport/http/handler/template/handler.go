@@ -0,0 +1,215 @@
+package template
+
+import (
+	"ai-orchestrator/internal/common/logger"
+	"ai-orchestrator/internal/domain/model"
+	"ai-orchestrator/internal/infra/telemetry/tracing"
+	"ai-orchestrator/internal/transport/http/helper"
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const maxNameLength = 255
+
+var ErrNilService = errors.New("service is nil")
+
+type Service interface {
+	CreateTemplate(ctx context.Context, template *model.Template) error
+	UpdateTemplate(ctx context.Context, template model.Template) (*model.Template, error)
+	GetTemplate(ctx context.Context, id uuid.UUID, version int) (*model.Template, error)
+	ListTemplates(ctx context.Context) ([]model.Template, error)
+	DeleteTemplate(ctx context.Context, id, userID uuid.UUID) error
+}
+
+type Handler struct {
+	logger  logger.Logger
+	service Service
+}
+
+func NewHandler(l logger.Logger, s Service) (*Handler, error) {
+	if l == nil {
+		return nil, logger.ErrNilLogger
+	}
+	if s == nil {
+		return nil, ErrNilService
+	}
+
+	return &Handler{
+		logger:  l,
+		service: s,
+	}, nil
+}
+
+func (h *Handler) CreateTemplate(rw http.ResponseWriter, r *http.Request) {
+	span, ctx := tracing.InitContextFromHttp(r, "create_template")
+	defer span.End()
+	h.logger.InfoContext(ctx, "Incoming request:", "path", "templateHandler.CreateTemplate")
+
+	request, ok := h.decodeSaveRequest(rw, r)
+	if !ok {
+		return
+	}
+
+	template := request.ToDomain(uuid.Nil)
+	err := h.service.CreateTemplate(ctx, &template)
+	if err != nil {
+		h.writeError(ctx, rw, err)
+		return
+	}
+
+	helper.WriteJSONResponse(rw, http.StatusCreated, FromDomain(template))
+}
+
+// UpdateTemplate creates a new version of the template.
+func (h *Handler) UpdateTemplate(rw http.ResponseWriter, r *http.Request) {
+	span, ctx := tracing.InitContextFromHttp(r, "update_template")
+	defer span.End()
+	h.logger.InfoContext(ctx, "Incoming request:", "path", "templateHandler.UpdateTemplate")
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		helper.WriteJSONError(rw, http.StatusBadRequest, "invalid template id", nil)
+		return
+	}
+
+	request, ok := h.decodeSaveRequest(rw, r)
+	if !ok {
+		return
+	}
+
+	template, err := h.service.UpdateTemplate(ctx, request.ToDomain(id))
+	if err != nil {
+		h.writeError(ctx, rw, err)
+		return
+	}
+
+	helper.WriteJSONResponse(rw, http.StatusOK, FromDomain(*template))
+}
+
+// GetTemplate returns the latest version of the template, or the one given by the version query parameter.
+func (h *Handler) GetTemplate(rw http.ResponseWriter, r *http.Request) {
+	span, ctx := tracing.InitContextFromHttp(r, "get_template")
+	defer span.End()
+	h.logger.InfoContext(ctx, "Incoming request:", "path", "templateHandler.GetTemplate")
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		helper.WriteJSONError(rw, http.StatusBadRequest, "invalid template id", nil)
+		return
+	}
+
+	version := 0
+	if v := r.URL.Query().Get("version"); v != "" {
+		version, err = strconv.Atoi(v)
+		if err != nil || version < 1 {
+			helper.WriteJSONError(rw, http.StatusBadRequest, "invalid template version", nil)
+			return
+		}
+	}
+
+	template, err := h.service.GetTemplate(ctx, id, version)
+	if err != nil {
+		h.writeError(ctx, rw, err)
+		return
+	}
+
+	helper.WriteJSONResponse(rw, http.StatusOK, FromDomain(*template))
+}
+
+func (h *Handler) ListTemplates(rw http.ResponseWriter, r *http.Request) {
+	span, ctx := tracing.InitContextFromHttp(r, "list_templates")
+	defer span.End()
+	h.logger.InfoContext(ctx, "Incoming request:", "path", "templateHandler.ListTemplates")
+
+	templates, err := h.service.ListTemplates(ctx)
+	if err != nil {
+		h.writeError(ctx, rw, err)
+		return
+	}
+
+	response := ListResponse{
+		Templates: make([]TemplateResponse, 0, len(templates)),
+	}
+	for _, t := range templates {
+		response.Templates = append(response.Templates, FromDomain(t))
+	}
+
+	helper.WriteJSONResponse(rw, http.StatusOK, response)
+}
+
+func (h *Handler) DeleteTemplate(rw http.ResponseWriter, r *http.Request) {
+	span, ctx := tracing.InitContextFromHttp(r, "delete_template")
+	defer span.End()
+	h.logger.InfoContext(ctx, "Incoming request:", "path", "templateHandler.DeleteTemplate")
+
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		helper.WriteJSONError(rw, http.StatusBadRequest, "invalid template id", nil)
+		return
+	}
+
+	userID, err := uuid.Parse(r.URL.Query().Get("userID"))
+	if err != nil {
+		helper.WriteJSONError(rw, http.StatusBadRequest, "missing or invalid userID", nil)
+		return
+	}
+
+	err = h.service.DeleteTemplate(ctx, id, userID)
+	if err != nil {
+		h.writeError(ctx, rw, err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) decodeSaveRequest(rw http.ResponseWriter, r *http.Request) (*SaveRequest, bool) {
+	request := &SaveRequest{}
+	err := helper.FromJSON(r.Body, request)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "failed to decode request body", "error", err, "handler", "templateHandler")
+		helper.WriteDecodeError(rw, err)
+		return nil, false
+	}
+
+	errs := helper.ValidationErrors{}
+	if request.UserID == uuid.Nil {
+		errs.Add("user_id", "is required")
+	}
+	switch {
+	case strings.TrimSpace(request.Name) == "":
+		errs.Add("name", "must not be empty")
+	case len(request.Name) > maxNameLength:
+		errs.Add("name", "is too long")
+	}
+	if strings.TrimSpace(request.Body) == "" {
+		errs.Add("body", "must not be empty")
+	}
+	if !errs.Empty() {
+		helper.WriteValidationErrors(rw, errs)
+		return nil, false
+	}
+
+	return request, true
+}
+
+func (h *Handler) writeError(ctx context.Context, rw http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, model.ErrTemplateNotFound):
+		helper.WriteJSONError(rw, http.StatusNotFound, "template not found", nil)
+	case errors.Is(err, model.ErrInvalidTemplate):
+		errs := helper.ValidationErrors{}
+		errs.Add("body", err.Error())
+		helper.WriteValidationErrors(rw, errs)
+	case errors.Is(err, model.ErrTemplateConflict):
+		helper.WriteJSONError(rw, http.StatusConflict, "template was updated concurrently, retry", nil)
+	default:
+		h.logger.WarnContext(ctx, "template request failed", "error", err)
+		helper.WriteJSONError(rw, http.StatusInternalServerError, "template request failed", err)
+	}
+}
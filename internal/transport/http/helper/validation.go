@@ -0,0 +1,63 @@
+package helper
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// ValidationErrors maps request fields to the reasons they were rejected.
+type ValidationErrors map[string]string
+
+func (v ValidationErrors) Add(field, message string) {
+	if _, exists := v[field]; !exists {
+		v[field] = message
+	}
+}
+
+func (v ValidationErrors) Empty() bool {
+	return len(v) == 0
+}
+
+// String joins the messages in the order of their fields, for transports
+// that report a single message.
+func (v ValidationErrors) String() string {
+	fields := make([]string, 0, len(v))
+	for field := range v {
+		fields = append(fields, field)
+	}
+	slices.Sort(fields)
+
+	messages := make([]string, 0, len(fields))
+	for _, field := range fields {
+		messages = append(messages, field+" "+v[field])
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+type validationResponse struct {
+	Message string           `json:"message"`
+	Errors  ValidationErrors `json:"errors"`
+}
+
+// WriteValidationErrors responds with 422 and the per-field messages.
+func WriteValidationErrors(w http.ResponseWriter, errs ValidationErrors) {
+	WriteJSONResponse(w, http.StatusUnprocessableEntity, validationResponse{
+		Message: "validation failed",
+		Errors:  errs,
+	})
+}
+
+// WriteDecodeError responds to a body that could not be decoded, telling apart
+// bodies exceeding the http.MaxBytesReader limit from malformed ones.
+func WriteDecodeError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		WriteJSONError(w, http.StatusRequestEntityTooLarge, "request body too large", nil)
+		return
+	}
+
+	WriteJSONError(w, http.StatusBadRequest, "invalid request body", nil)
+}
@@ -0,0 +1,119 @@
+package prompt
+
+import (
+	"ai-orchestrator/internal/domain/model"
+	httpPrompt "ai-orchestrator/internal/transport/http/handler/prompt"
+	"encoding/json"
+	"github.com/google/uuid"
+	"time"
+)
+
+type SubmitRequest struct {
+	ModelID        string `json:"model_id"`
+	Prompt         string `json:"prompt"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty"`
+	Priority       string `json:"priority,omitempty"`
+
+	TemplateID      *uuid.UUID     `json:"template_id,omitempty"`
+	TemplateVersion int            `json:"template_version,omitempty"`
+	Variables       map[string]any `json:"variables,omitempty"`
+
+	Tools []string `json:"tools,omitempty"`
+
+	// BypassCache forces a fresh response, like "Cache-Control: no-cache" on /ask.
+	BypassCache bool `json:"bypass_cache,omitempty"`
+
+	model.GenerationOptions
+}
+
+func (r *SubmitRequest) ToDomain(userID uuid.UUID) model.Prompt {
+	return model.Prompt{
+		ID:       uuid.New(),
+		UserID:   userID,
+		ModelID:  r.ModelID,
+		Text:     r.Prompt,
+		Timeout:  time.Duration(r.TimeoutSeconds) * time.Second,
+		Options:  r.GenerationOptions,
+		Priority: model.Priority(r.Priority),
+
+		Template:    r.templateRef(),
+		Tools:       r.Tools,
+		BypassCache: r.BypassCache,
+	}
+}
+
+// asCreateRequest lets the submission go through the checks of /ask.
+func (r *SubmitRequest) asCreateRequest(userID uuid.UUID) *httpPrompt.CreateRequest {
+	return &httpPrompt.CreateRequest{
+		UserID:            userID,
+		ModelID:           r.ModelID,
+		Prompt:            r.Prompt,
+		TimeoutSeconds:    r.TimeoutSeconds,
+		Priority:          r.Priority,
+		TemplateID:        r.TemplateID,
+		TemplateVersion:   r.TemplateVersion,
+		Variables:         r.Variables,
+		Tools:             r.Tools,
+		GenerationOptions: r.GenerationOptions,
+	}
+}
+
+func (r *SubmitRequest) templateRef() *model.TemplateRef {
+	if r.TemplateID == nil {
+		return nil
+	}
+
+	return &model.TemplateRef{
+		ID:        *r.TemplateID,
+		Version:   r.TemplateVersion,
+		Variables: r.Variables,
+	}
+}
+
+type PromptRequest struct {
+	PromptID uuid.UUID `json:"prompt_id"`
+}
+
+type AckResponse struct {
+	PromptID uuid.UUID    `json:"prompt_id"`
+	Status   model.Status `json:"status"`
+	Deadline *time.Time   `json:"deadline,omitempty"`
+	Cached   bool         `json:"cached,omitempty"`
+}
+
+type StatusResponse struct {
+	PromptID  uuid.UUID       `json:"prompt_id"`
+	ModelID   string          `json:"model_id"`
+	Status    model.Status    `json:"status"`
+	Response  string          `json:"response,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	ErrorCode model.ErrorCode `json:"error_code,omitempty"`
+	Deadline  *time.Time      `json:"deadline,omitempty"`
+	Cached    bool            `json:"cached,omitempty"`
+
+	// ServedModelID is the model that answered, it differs from ModelID after a fallback.
+	ServedModelID    string           `json:"served_model_id,omitempty"`
+	StructuredOutput json.RawMessage  `json:"structured_output,omitempty"`
+	ToolCalls        []model.ToolCall `json:"tool_calls,omitempty"`
+}
+
+func FromDomain(domain *model.Prompt) StatusResponse {
+	response := StatusResponse{
+		PromptID:  domain.ID,
+		ModelID:   domain.ModelID,
+		Status:    domain.Status,
+		Response:  domain.Response,
+		Error:     domain.Error,
+		ErrorCode: domain.ErrorCode,
+		Cached:    domain.Cached,
+
+		ServedModelID:    domain.ServedModelID,
+		StructuredOutput: domain.StructuredOutput,
+		ToolCalls:        domain.ToolCalls,
+	}
+	if !domain.Deadline.IsZero() {
+		response.Deadline = &domain.Deadline
+	}
+
+	return response
+}
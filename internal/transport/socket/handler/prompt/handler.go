@@ -0,0 +1,178 @@
+package prompt
+
+import (
+	"ai-orchestrator/internal/common/logger"
+	"ai-orchestrator/internal/domain/model"
+	"ai-orchestrator/internal/infra/telemetry/tracing"
+	"ai-orchestrator/internal/infra/websocket"
+	httpPrompt "ai-orchestrator/internal/transport/http/handler/prompt"
+	"ai-orchestrator/internal/transport/http/helper"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNilService   = errors.New("service is nil")
+	ErrNilValidator = errors.New("validator is nil")
+)
+
+// Validator runs the checks of /ask on the submitted prompts.
+type Validator interface {
+	ValidateCreate(r *httpPrompt.CreateRequest) helper.ValidationErrors
+}
+
+type Service interface {
+	PostPrompt(ctx context.Context, prompt *model.Prompt) error
+}
+
+type StatusService interface {
+	GetPrompt(ctx context.Context, id uuid.UUID) (*model.Prompt, error)
+}
+
+type CancelService interface {
+	CancelPrompt(ctx context.Context, id, userID uuid.UUID) error
+}
+
+type Handler struct {
+	logger        logger.Logger
+	service       Service
+	statusService StatusService
+	cancelService CancelService
+	validator     Validator
+}
+
+func NewHandler(l logger.Logger, s Service, ss StatusService, cs CancelService, v Validator) (*Handler, error) {
+	if l == nil {
+		return nil, logger.ErrNilLogger
+	}
+	if s == nil || ss == nil || cs == nil {
+		return nil, ErrNilService
+	}
+	if v == nil {
+		return nil, ErrNilValidator
+	}
+
+	return &Handler{
+		logger:        l,
+		service:       s,
+		statusService: ss,
+		cancelService: cs,
+		validator:     v,
+	}, nil
+}
+
+func (h *Handler) HandleMessage(ctx context.Context, userID string, msg websocket.Message) websocket.Message {
+	span, ctx := tracing.InitSpan(ctx, "ws_"+string(msg.Type))
+	defer span.End()
+	h.logger.InfoContext(ctx, "Incoming websocket message:", "type", msg.Type, "handler", "socketPromptHandler.HandleMessage")
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return websocket.NewErrorMessage(msg.RequestID, websocket.ErrCodeInvalidMessage, "userID must be a valid UUID")
+	}
+
+	switch msg.Type {
+	case websocket.TypeSubmit:
+		return h.submit(ctx, uid, msg)
+	case websocket.TypeStatus:
+		return h.status(ctx, uid, msg)
+	case websocket.TypeCancel:
+		return h.cancel(ctx, uid, msg)
+	default:
+		return websocket.NewErrorMessage(msg.RequestID, websocket.ErrCodeInvalidMessage, websocket.ErrUnknownMessageType.Error())
+	}
+}
+
+func (h *Handler) submit(ctx context.Context, userID uuid.UUID, msg websocket.Message) websocket.Message {
+	request := &SubmitRequest{}
+	if err := json.Unmarshal(msg.Payload, request); err != nil {
+		h.logger.WarnContext(ctx, "failed to decode submit payload", "error", err, "handler", "socketPromptHandler.submit")
+		return websocket.NewErrorMessage(msg.RequestID, websocket.ErrCodeInvalidMessage, "invalid submit payload")
+	}
+
+	if errs := h.validator.ValidateCreate(request.asCreateRequest(userID)); !errs.Empty() {
+		return websocket.NewErrorMessage(msg.RequestID, websocket.ErrCodeInvalidMessage, errs.String())
+	}
+
+	domainPrompt := request.ToDomain(userID)
+	err := h.service.PostPrompt(ctx, &domainPrompt)
+	if isValidationError(err) {
+		return websocket.NewErrorMessage(msg.RequestID, websocket.ErrCodeInvalidMessage, err.Error())
+	}
+	if err != nil {
+		h.logger.WarnContext(ctx, "failed to post prompt", "error", err, "domainPrompt", domainPrompt)
+		return websocket.NewErrorMessage(msg.RequestID, websocket.ErrCodeInternal, "failed to post prompt")
+	}
+
+	ack := AckResponse{
+		PromptID: domainPrompt.ID,
+		Status:   domainPrompt.Status,
+		Cached:   domainPrompt.Cached,
+	}
+	if !domainPrompt.Deadline.IsZero() {
+		ack.Deadline = &domainPrompt.Deadline
+	}
+
+	return websocket.NewMessage(websocket.TypeAck, msg.RequestID, ack)
+}
+
+func (h *Handler) status(ctx context.Context, userID uuid.UUID, msg websocket.Message) websocket.Message {
+	request := &PromptRequest{}
+	if err := json.Unmarshal(msg.Payload, request); err != nil {
+		h.logger.WarnContext(ctx, "failed to decode status payload", "error", err, "handler", "socketPromptHandler.status")
+		return websocket.NewErrorMessage(msg.RequestID, websocket.ErrCodeInvalidMessage, "invalid status payload")
+	}
+
+	domainPrompt, err := h.statusService.GetPrompt(ctx, request.PromptID)
+	if err != nil {
+		if errors.Is(err, model.ErrPromptNotFound) {
+			return websocket.NewErrorMessage(msg.RequestID, websocket.ErrCodeNotFound, model.ErrPromptNotFound.Error())
+		}
+		return websocket.NewErrorMessage(msg.RequestID, websocket.ErrCodeInternal, "failed to get prompt")
+	}
+
+	// Prompts of other users are reported as missing to not leak their existence.
+	if domainPrompt.UserID != userID {
+		return websocket.NewErrorMessage(msg.RequestID, websocket.ErrCodeNotFound, model.ErrPromptNotFound.Error())
+	}
+
+	return websocket.NewMessage(websocket.TypeStatus, msg.RequestID, FromDomain(domainPrompt))
+}
+
+func (h *Handler) cancel(ctx context.Context, userID uuid.UUID, msg websocket.Message) websocket.Message {
+	request := &PromptRequest{}
+	if err := json.Unmarshal(msg.Payload, request); err != nil {
+		h.logger.WarnContext(ctx, "failed to decode cancel payload", "error", err, "handler", "socketPromptHandler.cancel")
+		return websocket.NewErrorMessage(msg.RequestID, websocket.ErrCodeInvalidMessage, "invalid cancel payload")
+	}
+
+	err := h.cancelService.CancelPrompt(ctx, request.PromptID, userID)
+	switch {
+	case errors.Is(err, model.ErrPromptNotFound):
+		return websocket.NewErrorMessage(msg.RequestID, websocket.ErrCodeNotFound, err.Error())
+	case errors.Is(err, model.ErrPromptNotCancellable):
+		return websocket.NewErrorMessage(msg.RequestID, websocket.ErrCodeConflict, err.Error())
+	case err != nil:
+		h.logger.WarnContext(ctx, "failed to cancel prompt", "error", err, "prompt_id", request.PromptID)
+		return websocket.NewErrorMessage(msg.RequestID, websocket.ErrCodeInternal, "failed to cancel prompt")
+	}
+
+	return websocket.NewMessage(websocket.TypeAck, msg.RequestID, AckResponse{
+		PromptID: request.PromptID,
+		Status:   model.Discarded,
+	})
+}
+
+func isValidationError(err error) bool {
+	return errors.Is(err, model.ErrUnknownModel) ||
+		errors.Is(err, model.ErrModelDisabled) ||
+		errors.Is(err, model.ErrNoModel) ||
+		errors.Is(err, model.ErrInvalidTimeout) ||
+		errors.Is(err, model.ErrInvalidGenerationOptions) ||
+		errors.Is(err, model.ErrInvalidPriority) ||
+		errors.Is(err, model.ErrInvalidTools) ||
+		errors.Is(err, model.ErrTemplateNotFound) ||
+		errors.Is(err, model.ErrTemplateRender)
+}
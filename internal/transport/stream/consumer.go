@@ -13,10 +13,15 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
+	"slices"
 	"strings"
 	"time"
 )
 
+// ErrDrainTimeout interrupts the tasks still running when the drain timeout of
+// a stopping consumer elapses.
+var ErrDrainTimeout = errors.New("consumer drain timed out")
+
 type UseCase interface {
 	Use(ctx context.Context, entity string) error
 }
@@ -30,19 +35,34 @@ type Consumer struct {
 	usecase UseCase
 
 	streamCfg         *shared.StreamConfig
+	lanes             []*Lane
 	backoffCfg        *shared.BackoffConfig
 	contextPropagator *tracing.PropagationConfig
 
-	backoff manager.Backoff
+	backoff *manager.Backoff
 }
 
 type ConsumerResult struct {
 	Headers   map[string]string
+	Stream    string
 	MessageID string
 	Entity    string
 }
 
-func NewConsumer(workerID int, l logger.Logger, client *redis.Client, usecase UseCase, streamCfg *shared.StreamConfig, backoffCfg *shared.BackoffConfig, propagator *tracing.PropagationConfig, backoff manager.Backoff) (*Consumer, error) {
+// Lane is a stream read by the consumer. When several lanes are given, the
+// consumer prefers them by smooth weighted round-robin: with weights 6, 3 and 1
+// and backlog in every lane, 6 messages out of 10 come from the first one, while
+// the others are never starved.
+type Lane struct {
+	Stream string
+	Weight int
+
+	current int
+}
+
+// NewConsumer creates a consumer of the streamCfg stream, or of the given lanes
+// when there are any. Lanes are listed from the most to the least urgent one.
+func NewConsumer(workerID int, l logger.Logger, client *redis.Client, usecase UseCase, streamCfg *shared.StreamConfig, lanes []Lane, backoffCfg *shared.BackoffConfig, propagator *tracing.PropagationConfig, backoff *manager.Backoff) (*Consumer, error) {
 	if l == nil {
 		return nil, logger.ErrNilLogger
 	}
@@ -58,6 +78,9 @@ func NewConsumer(workerID int, l logger.Logger, client *redis.Client, usecase Us
 	if propagator == nil {
 		return nil, errors.New("propagator is nil")
 	}
+	if backoff == nil {
+		return nil, errors.New("backoff is nil")
+	}
 	var workerFullID string
 	if workerID == 0 {
 		workerFullID = streamCfg.Group.ConsumerPrimarilyID
@@ -65,11 +88,24 @@ func NewConsumer(workerID int, l logger.Logger, client *redis.Client, usecase Us
 		workerFullID = fmt.Sprintf("%s-%d", streamCfg.Group.ConsumerPrimarilyID, workerID)
 	}
 
+	if len(lanes) == 0 {
+		lanes = []Lane{{Stream: streamCfg.ID, Weight: 1}}
+	}
+	// Every consumer keeps its own round-robin state.
+	consumerLanes := make([]*Lane, 0, len(lanes))
+	for _, lane := range lanes {
+		if lane.Weight < 0 {
+			return nil, fmt.Errorf("lane %q has a negative weight", lane.Stream)
+		}
+		consumerLanes = append(consumerLanes, &Lane{Stream: lane.Stream, Weight: lane.Weight})
+	}
+
 	return &Consumer{
 		logger:            l,
 		usecase:           usecase,
 		client:            client,
 		streamCfg:         streamCfg,
+		lanes:             consumerLanes,
 		backoffCfg:        backoffCfg,
 		WorkerID:          workerFullID,
 		contextPropagator: propagator,
@@ -80,22 +116,34 @@ func NewConsumer(workerID int, l logger.Logger, client *redis.Client, usecase Us
 func (c *Consumer) Consume(ctx context.Context) error {
 	c.logger.Info("Worker started", "id", c.WorkerID)
 
-	err := c.createGroup(ctx, c.streamCfg.ID, c.streamCfg.Group.ID)
-	if err != nil {
-		c.logger.Error("Failed to create group", "id", c.WorkerID, "err", err)
-		return err
+	for _, lane := range c.lanes {
+		err := c.createGroup(ctx, lane.Stream, c.streamCfg.Group.ID)
+		if err != nil {
+			c.logger.Error("Failed to create group", "id", c.WorkerID, "err", err)
+			return err
+		}
 	}
 
+	// The tasks outlive ctx by the drain timeout: once ctx ends, no message is
+	// read anymore, while the running task gets the time to finish.
+	tasks, cancelTasks := context.WithCancelCause(context.WithoutCancel(ctx))
+	defer cancelTasks(nil)
+	stopDrain := context.AfterFunc(ctx, func() {
+		c.logger.Info("Draining consumer", "worker_id", c.WorkerID, "drain_timeout", c.streamCfg.DrainTimeout)
+		time.AfterFunc(c.streamCfg.DrainTimeout, func() { cancelTasks(ErrDrainTimeout) })
+	})
+	defer stopDrain()
+
 	for {
 		select {
 		case <-ctx.Done():
 			c.logger.Info("Stopping consumer", "worker_id", c.WorkerID)
 			return ctx.Err()
 		default:
-			res, err := manager.WithBackoff[ConsumerResult](
+			results, err := manager.WithBackoff[[]ConsumerResult](
 				ctx,
-				&c.backoff,
-				func(ctx context.Context) (ConsumerResult, error) {
+				c.backoff,
+				func(ctx context.Context) ([]ConsumerResult, error) {
 					return c.consume(ctx)
 				},
 				func(callErr error) bool {
@@ -106,37 +154,83 @@ func (c *Consumer) Consume(ctx context.Context) error {
 				return err
 			}
 
-			if res.Entity == "" {
-				continue
-			}
-
-			parentCtx := otel.GetTextMapPropagator().Extract(context.Background(), propagation.MapCarrier(res.Headers))
-
-			tracer := otel.Tracer(c.contextPropagator.AppID)
-			ctx, span := tracer.Start(parentCtx, c.contextPropagator.ProcessID,
-				trace.WithSpanKind(trace.SpanKindConsumer),
-				trace.WithAttributes(attribute.String("redis.message_id", res.MessageID)),
-			)
-			ctx = logger.WithMessageID(ctx, res.MessageID)
-			c.logger.InfoContext(ctx, "Received message from stream")
-
-			err = c.usecase.Use(ctx, res.Entity)
-			span.End()
-			if err == nil {
-				ackCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
-				ackErr := c.ack(ackCtx, c.streamCfg.ID, c.streamCfg.Group.ID, res.MessageID)
-				cancel()
-
-				if ackErr != nil {
-					c.logger.Error("Failed to ack message", "error", ackErr)
+			for _, res := range results {
+				// Messages read along with the running one are not started anymore.
+				if ctx.Err() != nil {
+					c.release(res)
+					continue
 				}
-				continue
+				c.handle(tasks, res)
 			}
-			c.logger.ErrorContext(ctx, "Failed to process message", "error", err)
 		}
 	}
 }
 
+func (c *Consumer) handle(tasks context.Context, res ConsumerResult) {
+	if res.Entity == "" {
+		return
+	}
+
+	parentCtx := otel.GetTextMapPropagator().Extract(tasks, propagation.MapCarrier(res.Headers))
+
+	tracer := otel.Tracer(c.contextPropagator.AppID)
+	ctx, span := tracer.Start(parentCtx, c.contextPropagator.ProcessID,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("redis.message_id", res.MessageID),
+			attribute.String("redis.stream", res.Stream),
+		),
+	)
+	ctx = logger.WithMessageID(ctx, res.MessageID)
+	c.logger.InfoContext(ctx, "Received message from stream", "stream", res.Stream)
+
+	err := c.usecase.Use(ctx, res.Entity)
+	span.End()
+	if err != nil && errors.Is(context.Cause(tasks), ErrDrainTimeout) {
+		c.logger.WarnContext(ctx, "Task interrupted by the drain timeout", "error", err)
+		c.release(res)
+		return
+	}
+	if err != nil {
+		c.logger.ErrorContext(ctx, "Failed to process message", "error", err)
+		return
+	}
+
+	ackCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 2*time.Second)
+	ackErr := c.ack(ackCtx, res.Stream, c.streamCfg.Group.ID, res.MessageID)
+	cancel()
+
+	if ackErr != nil {
+		c.logger.Error("Failed to ack message", "error", ackErr)
+	}
+}
+
+// release hands an unfinished message over to the other consumers: a copy is
+// added at the end of its stream and the original is acknowledged at once, so
+// it does not stay pending for a consumer that is gone.
+func (c *Consumer) release(res ConsumerResult) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	values := make(map[string]any, len(res.Headers)+1)
+	for k, v := range res.Headers {
+		values[k] = v
+	}
+	values["data"] = res.Entity
+
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAdd(ctx, &redis.XAddArgs{Stream: res.Stream, Values: values})
+		pipe.XAck(ctx, res.Stream, c.streamCfg.Group.ID, res.MessageID)
+		return nil
+	})
+	if err != nil {
+		c.logger.Error("Failed to release message", "stream", res.Stream, "message_id", res.MessageID, "error", err)
+		return
+	}
+
+	c.logger.Info("Released unfinished message", "stream", res.Stream, "message_id", res.MessageID)
+}
+
 func (c *Consumer) createGroup(ctx context.Context, stream, group string) error {
 
 	const EarliestMessage = "0" // Redis specific alias: start from the beginning of the stream
@@ -151,57 +245,129 @@ func (c *Consumer) createGroup(ctx context.Context, stream, group string) error
 	return nil
 }
 
-// consume Consumes a message from the specified stream. Returns Headers, MessageID, Data, Error
-func (c *Consumer) consume(ctx context.Context) (ConsumerResult, error) {
+// CheckGroups reports whether the consumer group exists on every lane.
+func (c *Consumer) CheckGroups(ctx context.Context) error {
+	for _, lane := range c.lanes {
+		groups, err := c.client.XInfoGroups(ctx, lane.Stream).Result()
+		if err != nil {
+			return fmt.Errorf("stream %s: %w", lane.Stream, err)
+		}
+		if !slices.ContainsFunc(groups, func(g redis.XInfoGroup) bool { return g.Name == c.streamCfg.Group.ID }) {
+			return fmt.Errorf("stream %s has no group %s", lane.Stream, c.streamCfg.Group.ID)
+		}
+	}
+
+	return nil
+}
+
+// consume reads the next messages. With several lanes, the lanes are polled
+// without blocking in the weighted order first; when all of them are empty the
+// consumer blocks on all the lanes at once and takes whatever comes first.
+func (c *Consumer) consume(ctx context.Context) ([]ConsumerResult, error) {
+	if len(c.lanes) == 1 {
+		return c.read(ctx, []string{c.lanes[0].Stream}, c.streamCfg.BlockTime)
+	}
+
+	const noBlock = -1 // go-redis omits the BLOCK argument for negative durations
+
+	for _, lane := range c.order() {
+		results, err := c.read(ctx, []string{lane.Stream}, noBlock)
+		if err != nil || len(results) > 0 {
+			return results, err
+		}
+	}
+
+	streams := make([]string, 0, len(c.lanes))
+	for _, lane := range c.lanes {
+		streams = append(streams, lane.Stream)
+	}
+
+	return c.read(ctx, streams, c.streamCfg.BlockTime)
+}
+
+// order returns the lanes starting with the one picked by smooth weighted
+// round-robin, followed by the others from the most urgent one.
+func (c *Consumer) order() []*Lane {
+	total := 0
+	var picked *Lane
+	for _, lane := range c.lanes {
+		lane.current += lane.Weight
+		total += lane.Weight
+		if picked == nil || lane.current > picked.current {
+			picked = lane
+		}
+	}
+	picked.current -= total
+
+	ordered := make([]*Lane, 0, len(c.lanes))
+	ordered = append(ordered, picked)
+	for _, lane := range c.lanes {
+		if lane != picked {
+			ordered = append(ordered, lane)
+		}
+	}
+
+	return ordered
+}
+
+// read consumes new messages of the streams, in the order of the streams.
+func (c *Consumer) read(ctx context.Context, streams []string, block time.Duration) ([]ConsumerResult, error) {
 
 	const undeliveredMessages = ">" // Redis specific alias: starts from the unconsumed message
 
+	args := make([]string, 0, 2*len(streams))
+	args = append(args, streams...)
+	for range streams {
+		args = append(args, undeliveredMessages)
+	}
+
 	res, err := c.client.XReadGroup(ctx, &redis.XReadGroupArgs{
-		Streams:  []string{c.streamCfg.ID, undeliveredMessages},
+		Streams:  args,
 		Group:    c.streamCfg.Group.ID,
 		Consumer: c.WorkerID,
 		Count:    c.streamCfg.ReadCount,
-		Block:    c.streamCfg.BlockTime,
+		Block:    block,
 	}).Result()
 
-	var result ConsumerResult
 	if err != nil {
 		if errors.Is(err, redis.Nil) {
-			return result, nil
+			return nil, nil
 		}
 		if errors.Is(err, context.Canceled) {
-			return result, nil
+			return nil, nil
 		}
 
-		c.logger.Error("Failed to consume message.", "error", err, "stream", c.streamCfg.ID, "group", c.streamCfg.Group.ID, "consumer", c.WorkerID)
-		return result, err
+		c.logger.Error("Failed to consume message.", "error", err, "streams", streams, "group", c.streamCfg.Group.ID, "consumer", c.WorkerID)
+		return nil, err
 	}
 
-	if len(res) == 0 || len(res[0].Messages) == 0 {
-		return result, nil
-	}
-
-	message := res[0].Messages[0]
-
-	headers := make(map[string]string)
-
-	var data string
-	for k, v := range message.Values {
-		if strVal, ok := v.(string); ok {
-			if k == "data" {
-				data = strVal
-			} else {
-				headers[k] = strVal
+	var results []ConsumerResult
+	for _, stream := range res {
+		for _, message := range stream.Messages {
+			headers := make(map[string]string)
+
+			var data string
+			for k, v := range message.Values {
+				if strVal, ok := v.(string); ok {
+					if k == "data" {
+						data = strVal
+					} else {
+						headers[k] = strVal
+					}
+				}
 			}
+
+			c.logger.Debug("Received message", "stream", stream.Stream, "group", c.streamCfg.Group.ID, "consumer", c.WorkerID, "data", data)
+			results = append(results, ConsumerResult{
+				Headers:   headers,
+				Stream:    stream.Stream,
+				MessageID: message.ID,
+				Entity:    data,
+			})
 		}
 	}
 
-	c.logger.Debug("Received message", "stream", c.streamCfg.ID, "group", c.streamCfg.Group.ID, "consumer", c.WorkerID, "data", data)
-	result.Headers = headers
-	result.MessageID = message.ID
-	result.Entity = data
-
-	return result, nil
+	return results, nil
 }
 
 func (c *Consumer) ack(ctx context.Context, stream, group, messageId string) error {
@@ -212,5 +378,3 @@ func (c *Consumer) ack(ctx context.Context, stream, group, messageId string) err
 	}
 	return nil
 }
-
-// TODO add unified backoff process
@@ -0,0 +1,205 @@
+package pipeline
+
+import (
+	"ai-orchestrator/internal/common/logger"
+	"ai-orchestrator/internal/domain/model"
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"strings"
+)
+
+type PipelineUsecase struct {
+	logger  logger.Logger
+	repo    Repository
+	prompts PromptService
+}
+
+func NewPipelineUsecase(l logger.Logger, repository Repository, prompts PromptService) (*PipelineUsecase, error) {
+	if l == nil {
+		return nil, logger.ErrNilLogger
+	}
+	if repository == nil {
+		return nil, ErrNilRepository
+	}
+	if prompts == nil {
+		return nil, ErrNilPromptService
+	}
+
+	return &PipelineUsecase{
+		logger:  l,
+		repo:    repository,
+		prompts: prompts,
+	}, nil
+}
+
+// StartPipeline saves the pipeline and submits the steps without dependencies.
+// The pipeline is updated in place with its ID and the status of its steps.
+func (uc *PipelineUsecase) StartPipeline(ctx context.Context, pipeline *model.Pipeline) error {
+	err := pipeline.Validate()
+	if err != nil {
+		uc.logger.WarnContext(ctx, "invalid pipeline", "error", err)
+		return err
+	}
+
+	pipeline.Priority, err = model.ParsePriority(string(pipeline.Priority))
+	if err != nil {
+		return err
+	}
+
+	pipeline.ID = uuid.New()
+	pipeline.Status = model.PipelineProcessing
+	for i := range pipeline.Steps {
+		pipeline.Steps[i].Status = model.StepPending
+	}
+
+	err = uc.repo.InsertPipeline(ctx, *pipeline)
+	if err != nil {
+		uc.logger.ErrorContext(ctx, "saving pipeline failed", "error", err)
+		return err
+	}
+
+	uc.submitReady(ctx, pipeline)
+	return nil
+}
+
+// GetPipeline returns the pipeline with the results of its steps. Only the owner of the pipeline may see it.
+func (uc *PipelineUsecase) GetPipeline(ctx context.Context, id, userID uuid.UUID) (*model.Pipeline, error) {
+	pipeline, err := uc.repo.GetPipelineByID(ctx, id)
+	if err != nil {
+		uc.logger.WarnContext(ctx, "failed to get pipeline by id", "error", err, "pipeline_id", id)
+		return nil, err
+	}
+	if pipeline.UserID != userID {
+		return nil, model.ErrPipelineNotFound
+	}
+
+	return pipeline, nil
+}
+
+// Advance records the final status of the prompt of a step, then submits the
+// steps it unblocked or finishes the pipeline. It may be called again for the
+// same prompt.
+func (uc *PipelineUsecase) Advance(ctx context.Context, prompt *model.Prompt) error {
+	ref := prompt.Pipeline
+	if ref == nil {
+		return nil
+	}
+
+	status := model.StepCompleted
+	var message string
+	switch prompt.Status {
+	case model.Completed:
+	case model.Discarded:
+		status = model.StepFailed
+		message = "the prompt of the step was discarded"
+	default:
+		status = model.StepFailed
+	}
+
+	// A result delivered again finds the step finished already. The rest is
+	// done again all the same, as the first delivery may have failed past this
+	// point: finishing the pipeline and starting a step happen only once.
+	_, err := uc.repo.FinishStep(ctx, ref.ID, ref.Step, status, message)
+	if err != nil {
+		return err
+	}
+
+	pipeline, err := uc.repo.GetPipelineByID(ctx, ref.ID)
+	if err != nil {
+		return err
+	}
+	if pipeline.Status != model.PipelineProcessing {
+		return nil
+	}
+
+	if status == model.StepFailed {
+		uc.logger.InfoContext(ctx, "pipeline step failed", "pipeline_id", pipeline.ID, "step", ref.Step)
+		return uc.repo.FinishPipeline(ctx, pipeline.ID, model.PipelineFailed)
+	}
+
+	if pipeline.Done() {
+		uc.logger.InfoContext(ctx, "pipeline completed", "pipeline_id", pipeline.ID)
+		return uc.repo.FinishPipeline(ctx, pipeline.ID, model.PipelineCompleted)
+	}
+
+	uc.submitReady(ctx, pipeline)
+	return nil
+}
+
+// submitReady submits the prompts of the steps whose dependencies are completed.
+// A step that cannot be submitted fails the pipeline.
+func (uc *PipelineUsecase) submitReady(ctx context.Context, pipeline *model.Pipeline) {
+	for _, step := range pipeline.Ready() {
+		promptID := uuid.New()
+		started, err := uc.repo.StartStep(ctx, pipeline.ID, step.Name, promptID)
+		if err != nil || !started {
+			continue
+		}
+		step.Status = model.StepRunning
+		step.PromptID = &promptID
+
+		prompt, err := uc.stepPrompt(pipeline, step)
+		if err == nil {
+			prompt.ID = promptID
+			err = uc.prompts.PostPrompt(ctx, &prompt)
+		}
+		if err != nil {
+			uc.logger.WarnContext(ctx, "failed to submit pipeline step", "error", err, "pipeline_id", pipeline.ID, "step", step.Name)
+
+			step.Status = model.StepFailed
+			step.Error = err.Error()
+			if _, err = uc.repo.FinishStep(ctx, pipeline.ID, step.Name, model.StepFailed, step.Error); err == nil {
+				err = uc.repo.FinishPipeline(ctx, pipeline.ID, model.PipelineFailed)
+			}
+			if err != nil {
+				uc.logger.ErrorContext(ctx, "failed to fail pipeline", "error", err, "pipeline_id", pipeline.ID)
+			}
+			pipeline.Status = model.PipelineFailed
+			return
+		}
+
+		uc.logger.InfoContext(ctx, "pipeline step submitted", "pipeline_id", pipeline.ID, "step", step.Name, "prompt_id", promptID)
+
+		// A response served from the cache is already there, the pipeline moves on right away.
+		if prompt.Cached {
+			if err = uc.Advance(ctx, &prompt); err != nil {
+				uc.logger.ErrorContext(ctx, "failed to advance pipeline", "error", err, "pipeline_id", pipeline.ID)
+			}
+		}
+	}
+}
+
+// stepPrompt creates the prompt of the step. Inline templates are rendered
+// here, stored ones are rendered on submission like any templated prompt.
+func (uc *PipelineUsecase) stepPrompt(pipeline *model.Pipeline, step *model.PipelineStep) (model.Prompt, error) {
+	prompt := model.Prompt{
+		UserID:   pipeline.UserID,
+		ModelID:  step.ModelID,
+		Options:  step.Options,
+		Priority: pipeline.Priority,
+		Pipeline: &model.PipelineRef{ID: pipeline.ID, Step: step.Name},
+	}
+
+	variables := pipeline.StepVariables()
+	if step.Template != nil {
+		prompt.Template = &model.TemplateRef{
+			ID:        step.Template.ID,
+			Version:   step.Template.Version,
+			Variables: variables,
+		}
+		return prompt, nil
+	}
+
+	template := model.Template{Name: step.Name, Body: step.Body}
+	text, err := template.Render(variables)
+	if err != nil {
+		return prompt, err
+	}
+	if strings.TrimSpace(text) == "" {
+		return prompt, fmt.Errorf("%w: step %q rendered an empty prompt", model.ErrTemplateRender, step.Name)
+	}
+	prompt.Text = text
+
+	return prompt, nil
+}
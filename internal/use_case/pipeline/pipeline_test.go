@@ -0,0 +1,120 @@
+package pipeline
+
+import (
+	"ai-orchestrator/internal/domain/model"
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"log/slog"
+	"testing"
+)
+
+// memoryRepository keeps a single pipeline, with the conditional updates of
+// the database. readFailures fails that many reads of the pipeline first.
+type memoryRepository struct {
+	pipeline     model.Pipeline
+	readFailures int
+}
+
+func (r *memoryRepository) InsertPipeline(_ context.Context, pipeline model.Pipeline) error {
+	r.pipeline = pipeline
+	return nil
+}
+
+func (r *memoryRepository) GetPipelineByID(context.Context, uuid.UUID) (*model.Pipeline, error) {
+	if r.readFailures > 0 {
+		r.readFailures--
+		return nil, errors.New("connection reset")
+	}
+
+	pipeline := r.pipeline
+	pipeline.Steps = append([]model.PipelineStep(nil), r.pipeline.Steps...)
+	return &pipeline, nil
+}
+
+func (r *memoryRepository) StartStep(_ context.Context, _ uuid.UUID, name string, promptID uuid.UUID) (bool, error) {
+	step := r.step(name)
+	if step.Status != model.StepPending {
+		return false, nil
+	}
+	step.Status = model.StepRunning
+	step.PromptID = &promptID
+	return true, nil
+}
+
+func (r *memoryRepository) FinishStep(_ context.Context, _ uuid.UUID, name string, status model.StepStatus, message string) (bool, error) {
+	step := r.step(name)
+	if step.Status != model.StepRunning {
+		return false, nil
+	}
+	step.Status = status
+	step.Error = message
+	return true, nil
+}
+
+func (r *memoryRepository) FinishPipeline(_ context.Context, _ uuid.UUID, status model.PipelineStatus) error {
+	if r.pipeline.Status == model.PipelineProcessing {
+		r.pipeline.Status = status
+	}
+	return nil
+}
+
+func (r *memoryRepository) step(name string) *model.PipelineStep {
+	for i := range r.pipeline.Steps {
+		if r.pipeline.Steps[i].Name == name {
+			return &r.pipeline.Steps[i]
+		}
+	}
+	return &model.PipelineStep{}
+}
+
+type recordingPrompts struct {
+	posted []string
+}
+
+func (p *recordingPrompts) PostPrompt(_ context.Context, prompt *model.Prompt) error {
+	p.posted = append(p.posted, prompt.Pipeline.Step)
+	return nil
+}
+
+// A result redelivered after the pipeline failed to advance moves it on, once.
+func TestAdvanceRetry(t *testing.T) {
+	id := uuid.New()
+	repo := &memoryRepository{
+		pipeline: model.Pipeline{
+			ID:     id,
+			Status: model.PipelineProcessing,
+			Steps: []model.PipelineStep{
+				{Name: "draft", Body: "Draft a summary", Status: model.StepRunning},
+				{Name: "review", Body: "Review the summary", DependsOn: []string{"draft"}, Status: model.StepPending},
+			},
+		},
+		readFailures: 1,
+	}
+	prompts := &recordingPrompts{}
+
+	uc, err := NewPipelineUsecase(slog.New(slog.DiscardHandler), repo, prompts)
+	if err != nil {
+		t.Fatalf("NewPipelineUsecase unexpected error: %v", err)
+	}
+
+	result := &model.Prompt{Status: model.Completed, Pipeline: &model.PipelineRef{ID: id, Step: "draft"}}
+	if err := uc.Advance(context.Background(), result); err == nil {
+		t.Fatal("Advance succeeded while the pipeline could not be read")
+	}
+	if len(prompts.posted) != 0 {
+		t.Fatalf("steps submitted on the failed delivery: %v", prompts.posted)
+	}
+
+	for i := range 2 {
+		if err := uc.Advance(context.Background(), result); err != nil {
+			t.Fatalf("delivery %d: Advance unexpected error: %v", i+2, err)
+		}
+	}
+	if len(prompts.posted) != 1 || prompts.posted[0] != "review" {
+		t.Fatalf("submitted steps %v, want review once", prompts.posted)
+	}
+	if status := repo.step("review").Status; status != model.StepRunning {
+		t.Fatalf("review step status = %s, want %s", status, model.StepRunning)
+	}
+}
@@ -0,0 +1,24 @@
+package pipeline
+
+import (
+	"ai-orchestrator/internal/domain/model"
+	"context"
+	"errors"
+	"github.com/google/uuid"
+)
+
+var ErrNilRepository = errors.New("repository is nil")
+
+type Repository interface {
+	InsertPipeline(ctx context.Context, pipeline model.Pipeline) error
+	GetPipelineByID(ctx context.Context, id uuid.UUID) (*model.Pipeline, error)
+	StartStep(ctx context.Context, id uuid.UUID, name string, promptID uuid.UUID) (bool, error)
+	FinishStep(ctx context.Context, id uuid.UUID, name string, status model.StepStatus, message string) (bool, error)
+	FinishPipeline(ctx context.Context, id uuid.UUID, status model.PipelineStatus) error
+}
+
+var ErrNilPromptService = errors.New("prompt service is nil")
+
+type PromptService interface {
+	PostPrompt(ctx context.Context, prompt *model.Prompt) error
+}
@@ -0,0 +1,125 @@
+package prompt
+
+import (
+	"ai-orchestrator/internal/common/logger"
+	"ai-orchestrator/internal/config/shared"
+	"ai-orchestrator/internal/domain/model"
+	"ai-orchestrator/internal/infra/cache"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+const responseCachePrefix = "prompt:response:"
+
+var ErrNilCache = errors.New("cache is nil")
+
+type Cache interface {
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key string, data any, ttl time.Duration) error
+}
+
+type ModelLookup interface {
+	Get(id string) (model.Model, bool)
+}
+
+// ResponseCache stores the responses of completed prompts, so an identical
+// prompt is answered without calling the provider. Prompts are identical when
+// they target the same model with the same generation options and the same
+// text, ignoring whitespace differences.
+type ResponseCache struct {
+	logger logger.Logger
+	cache  Cache
+	models ModelLookup
+	config *shared.CacheConfig
+}
+
+func NewResponseCache(l logger.Logger, c Cache, models ModelLookup, cfg *shared.CacheConfig) (*ResponseCache, error) {
+	if l == nil {
+		return nil, logger.ErrNilLogger
+	}
+	if c == nil {
+		return nil, ErrNilCache
+	}
+	if models == nil {
+		return nil, ErrNilModelResolver
+	}
+	if cfg == nil {
+		return nil, errors.New("cache config is nil")
+	}
+
+	return &ResponseCache{
+		logger: l,
+		cache:  c,
+		models: models,
+		config: cfg,
+	}, nil
+}
+
+// Lookup returns the cached response of an identical prompt.
+func (rc *ResponseCache) Lookup(ctx context.Context, prompt *model.Prompt) (string, bool) {
+	if rc.ttl(prompt) <= 0 {
+		return "", false
+	}
+
+	response, err := rc.cache.Get(ctx, responseCachePrefix+PromptKey(prompt))
+	if err != nil {
+		if !errors.Is(err, cache.ErrCacheMiss) {
+			rc.logger.WarnContext(ctx, "failed to look up cached response", "error", err, "prompt_id", prompt.ID)
+		}
+		return "", false
+	}
+
+	return response, true
+}
+
+// Store caches the response of a completed prompt. Failures are only logged, the cache is an optimisation.
+func (rc *ResponseCache) Store(ctx context.Context, prompt *model.Prompt) {
+	ttl := rc.ttl(prompt)
+	if ttl <= 0 || prompt.Status != model.Completed {
+		return
+	}
+
+	err := rc.cache.Set(ctx, responseCachePrefix+PromptKey(prompt), prompt.Response, ttl)
+	if err != nil {
+		rc.logger.WarnContext(ctx, "failed to cache response", "error", err, "prompt_id", prompt.ID)
+	}
+}
+
+// ttl returns zero for prompts that must not be cached. Attachments are not
+// part of the key, so prompts carrying them are never cached. Neither are the
+// prompts with tools, whose answers depend on what the tools return, nor the
+// answers of fallback models, which would be served as the requested model ones.
+func (rc *ResponseCache) ttl(prompt *model.Prompt) time.Duration {
+	if !rc.config.Enabled || len(prompt.Attachments) > 0 || len(prompt.Tools) > 0 {
+		return 0
+	}
+	if prompt.ServedModelID != "" && prompt.ServedModelID != prompt.ModelID {
+		return 0
+	}
+
+	m, ok := rc.models.Get(prompt.ModelID)
+	if ok && m.CacheTTL != 0 {
+		return m.CacheTTL
+	}
+
+	return rc.config.TTL
+}
+
+// PromptKey identifies prompts expected to produce the same response.
+func PromptKey(prompt *model.Prompt) string {
+	options, _ := json.Marshal(prompt.Options)
+
+	hash := sha256.New()
+	hash.Write([]byte(prompt.ModelID))
+	hash.Write([]byte{0})
+	hash.Write(options)
+	hash.Write([]byte{0})
+	hash.Write([]byte(strings.Join(strings.Fields(prompt.Text), " ")))
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,99 @@
+package prompt
+
+import (
+	"ai-orchestrator/internal/common/logger"
+	"ai-orchestrator/internal/domain/model"
+	"context"
+	"errors"
+	"github.com/google/uuid"
+)
+
+var ErrNilCancelPublisher = errors.New("cancel publisher is nil")
+
+type CancelPublisher interface {
+	PublishCancel(ctx context.Context, promptID uuid.UUID) error
+}
+
+type EventDiscarder interface {
+	DiscardEvents(ctx context.Context, aggregateID uuid.UUID) error
+}
+
+type CancelPromptUsecase struct {
+	logger    logger.Logger
+	repo      Repository
+	publisher CancelPublisher
+	events    EventDiscarder
+	pipelines PipelineAdvancer
+}
+
+func NewCancelPromptUsecase(l logger.Logger, repository Repository, publisher CancelPublisher, events EventDiscarder, pipelines PipelineAdvancer) (*CancelPromptUsecase, error) {
+	if l == nil {
+		return nil, logger.ErrNilLogger
+	}
+	if repository == nil {
+		return nil, ErrNilRepository
+	}
+	if publisher == nil {
+		return nil, ErrNilCancelPublisher
+	}
+	if events == nil {
+		return nil, ErrNilOutbox
+	}
+	if pipelines == nil {
+		return nil, ErrNilPipelineAdvancer
+	}
+
+	return &CancelPromptUsecase{
+		logger:    l,
+		repo:      repository,
+		publisher: publisher,
+		events:    events,
+		pipelines: pipelines,
+	}, nil
+}
+
+// CancelPrompt discards a prompt that has not been processed yet and signals the
+// workers to stop generating it. Only the owner of the prompt may cancel it.
+func (c *CancelPromptUsecase) CancelPrompt(ctx context.Context, id, userID uuid.UUID) error {
+	prompt, err := c.repo.GetPromptByID(ctx, id)
+	if err != nil {
+		c.logger.WarnContext(ctx, "failed to get prompt by id", "error", err, "prompt_id", id)
+		return err
+	}
+	if prompt.UserID != userID {
+		return model.ErrPromptNotFound
+	}
+
+	discarded, err := c.repo.UpdatePromptStatus(ctx, id, model.Accepted, model.Discarded)
+	if err != nil {
+		c.logger.ErrorContext(ctx, "failed to discard prompt", "error", err, "prompt_id", id)
+		return err
+	}
+	if !discarded {
+		return model.ErrPromptNotCancellable
+	}
+
+	// Discarding a step fails its pipeline.
+	if prompt.Pipeline != nil {
+		prompt.Status = model.Discarded
+		err = c.pipelines.Advance(ctx, prompt)
+		if err != nil {
+			c.logger.WarnContext(ctx, "failed to advance pipeline of discarded prompt", "error", err, "pipeline_id", prompt.Pipeline.ID)
+		}
+	}
+
+	// A delayed prompt is still in the outbox, it does not need to reach the workers at all.
+	err = c.events.DiscardEvents(ctx, id)
+	if err != nil {
+		c.logger.WarnContext(ctx, "failed to discard prompt events", "error", err, "prompt_id", id)
+	}
+
+	// The prompt is discarded at this point, and its result will be dropped anyway.
+	// The signal only saves the worker from wasting time on it.
+	err = c.publisher.PublishCancel(ctx, id)
+	if err != nil {
+		c.logger.WarnContext(ctx, "failed to publish cancellation", "error", err, "prompt_id", id)
+	}
+
+	return nil
+}
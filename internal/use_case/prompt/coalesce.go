@@ -0,0 +1,92 @@
+package prompt
+
+import (
+	"ai-orchestrator/internal/domain/gateway"
+	"context"
+	"sync"
+)
+
+// flight is a Generate call shared by the tasks waiting for it.
+type flight struct {
+	done     chan struct{}
+	response gateway.Response
+	err      error
+
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// coalescer shares one call between concurrent callers using the same key.
+// Unlike singleflight, the call is not bound to the context of the caller that
+// started it: it runs until it completes or every caller stops waiting, so a
+// cancelled or expired task does not fail the others, while each caller still
+// observes its own deadline and cancellation.
+type coalescer struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+func newCoalescer() *coalescer {
+	return &coalescer{
+		flights: make(map[string]*flight),
+	}
+}
+
+// Do runs fn, or joins the call already running for the key. shared reports whether the call was joined.
+func (c *coalescer) Do(ctx context.Context, key string, fn func(ctx context.Context) (gateway.Response, error)) (response gateway.Response, shared bool, err error) {
+	c.mu.Lock()
+	f, shared := c.flights[key]
+	if shared {
+		f.waiters++
+	} else {
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		f = &flight{
+			done:    make(chan struct{}),
+			waiters: 1,
+			cancel:  cancel,
+		}
+		c.flights[key] = f
+
+		go c.run(callCtx, key, f, fn)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.response, shared, f.err
+	case <-ctx.Done():
+		c.leave(key, f)
+		return gateway.Response{}, shared, context.Cause(ctx)
+	}
+}
+
+func (c *coalescer) run(ctx context.Context, key string, f *flight, fn func(ctx context.Context) (gateway.Response, error)) {
+	defer f.cancel()
+
+	f.response, f.err = fn(ctx)
+
+	c.mu.Lock()
+	if c.flights[key] == f {
+		delete(c.flights, key)
+	}
+	c.mu.Unlock()
+
+	close(f.done)
+}
+
+// leave interrupts the call once nobody waits for it anymore. The flight is
+// forgotten right away, so new callers start a fresh call instead of joining a cancelled one.
+func (c *coalescer) leave(key string, f *flight) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f.waiters--
+	if f.waiters > 0 {
+		return
+	}
+
+	if c.flights[key] == f {
+		delete(c.flights, key)
+	}
+	f.cancel()
+}
@@ -0,0 +1,215 @@
+package prompt
+
+import (
+	"ai-orchestrator/internal/domain/gateway"
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockingCall is a provider call that returns once released.
+type blockingCall struct {
+	calls    atomic.Int32
+	release  chan struct{}
+	response gateway.Response
+	err      error
+
+	// cancelled receives the cause of the interruption of a call.
+	cancelled chan error
+}
+
+func newBlockingCall(response gateway.Response, err error) *blockingCall {
+	return &blockingCall{
+		release:   make(chan struct{}),
+		response:  response,
+		err:       err,
+		cancelled: make(chan error, 1),
+	}
+}
+
+func (b *blockingCall) fn(ctx context.Context) (gateway.Response, error) {
+	b.calls.Add(1)
+
+	select {
+	case <-b.release:
+		return b.response, b.err
+	case <-ctx.Done():
+		b.cancelled <- ctx.Err()
+		return gateway.Response{}, ctx.Err()
+	}
+}
+
+type coalescedResult struct {
+	response gateway.Response
+	shared   bool
+	err      error
+}
+
+// join starts a caller and waits until it waits for the flight of the key.
+func join(t *testing.T, c *coalescer, ctx context.Context, key string, call *blockingCall, waiters int) <-chan coalescedResult {
+	t.Helper()
+
+	results := make(chan coalescedResult, 1)
+	go func() {
+		response, shared, err := c.Do(ctx, key, call.fn)
+		results <- coalescedResult{response: response, shared: shared, err: err}
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		c.mu.Lock()
+		f, ok := c.flights[key]
+		joined := ok && f.waiters == waiters
+		c.mu.Unlock()
+		if joined {
+			return results
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("caller %d did not join the flight of %q", waiters, key)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func receive(t *testing.T, results <-chan coalescedResult) coalescedResult {
+	t.Helper()
+
+	select {
+	case result := <-results:
+		return result
+	case <-time.After(time.Second):
+		t.Fatal("caller did not return")
+		return coalescedResult{}
+	}
+}
+
+func TestCoalescerSharesCall(t *testing.T) {
+	c := newCoalescer()
+	call := newBlockingCall(gateway.Response{Text: "answer"}, nil)
+
+	first := join(t, c, context.Background(), "key", call, 1)
+	second := join(t, c, context.Background(), "key", call, 2)
+	third := join(t, c, context.Background(), "key", call, 3)
+	close(call.release)
+
+	for i, results := range []<-chan coalescedResult{first, second, third} {
+		result := receive(t, results)
+		if result.err != nil || result.response.Text != "answer" {
+			t.Fatalf("caller %d got %v, %v, want the answer", i, result.response, result.err)
+		}
+		if result.shared != (i > 0) {
+			t.Fatalf("caller %d shared = %v, want %v", i, result.shared, i > 0)
+		}
+	}
+	if calls := call.calls.Load(); calls != 1 {
+		t.Fatalf("provider called %d times, want 1", calls)
+	}
+}
+
+func TestCoalescerSharesError(t *testing.T) {
+	failure := errors.New("failure")
+	c := newCoalescer()
+	call := newBlockingCall(gateway.Response{}, failure)
+
+	first := join(t, c, context.Background(), "key", call, 1)
+	second := join(t, c, context.Background(), "key", call, 2)
+	close(call.release)
+
+	for i, results := range []<-chan coalescedResult{first, second} {
+		if result := receive(t, results); !errors.Is(result.err, failure) {
+			t.Fatalf("caller %d error = %v, want %v", i, result.err, failure)
+		}
+	}
+}
+
+func TestCoalescerSeparatesKeys(t *testing.T) {
+	c := newCoalescer()
+	call := newBlockingCall(gateway.Response{Text: "answer"}, nil)
+
+	first := join(t, c, context.Background(), "a", call, 1)
+	second := join(t, c, context.Background(), "b", call, 1)
+	close(call.release)
+
+	for _, results := range []<-chan coalescedResult{first, second} {
+		if result := receive(t, results); result.shared {
+			t.Fatal("caller of another key shared the call")
+		}
+	}
+	if calls := call.calls.Load(); calls != 2 {
+		t.Fatalf("provider called %d times, want 2", calls)
+	}
+}
+
+// A completed call is forgotten, the responses are cached elsewhere.
+func TestCoalescerForgetsCompletedCall(t *testing.T) {
+	c := newCoalescer()
+	call := newBlockingCall(gateway.Response{Text: "answer"}, nil)
+	close(call.release)
+
+	for i := range 2 {
+		_, shared, err := c.Do(context.Background(), "key", call.fn)
+		if err != nil || shared {
+			t.Fatalf("call %d: shared = %v, error = %v, want a fresh call", i, shared, err)
+		}
+	}
+	if calls := call.calls.Load(); calls != 2 {
+		t.Fatalf("provider called %d times, want 2", calls)
+	}
+}
+
+func TestCoalescerCallerLeaves(t *testing.T) {
+	c := newCoalescer()
+	call := newBlockingCall(gateway.Response{Text: "answer"}, nil)
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cause := errors.New("cancelled by user")
+	first := join(t, c, ctx, "key", call, 1)
+	second := join(t, c, context.Background(), "key", call, 2)
+
+	// The caller that started the call leaves, the other one keeps it running.
+	cancel(cause)
+	if result := receive(t, first); !errors.Is(result.err, cause) {
+		t.Fatalf("leaving caller error = %v, want %v", result.err, cause)
+	}
+	select {
+	case err := <-call.cancelled:
+		t.Fatalf("call interrupted with %v while a caller still waits", err)
+	default:
+	}
+
+	close(call.release)
+	if result := receive(t, second); result.err != nil || result.response.Text != "answer" {
+		t.Fatalf("remaining caller got %v, %v, want the answer", result.response, result.err)
+	}
+}
+
+func TestCoalescerAllCallersLeave(t *testing.T) {
+	c := newCoalescer()
+	call := newBlockingCall(gateway.Response{Text: "answer"}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := join(t, c, ctx, "key", call, 1)
+	second := join(t, c, ctx, "key", call, 2)
+	cancel()
+
+	for _, results := range []<-chan coalescedResult{first, second} {
+		if result := receive(t, results); !errors.Is(result.err, context.Canceled) {
+			t.Fatalf("caller error = %v, want %v", result.err, context.Canceled)
+		}
+	}
+	select {
+	case <-call.cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("call not interrupted once nobody waits for it")
+	}
+
+	// A new caller starts a fresh call instead of joining the interrupted one.
+	fresh := newBlockingCall(gateway.Response{Text: "fresh"}, nil)
+	close(fresh.release)
+	response, shared, err := c.Do(context.Background(), "key", fresh.fn)
+	if err != nil || shared || response.Text != "fresh" {
+		t.Fatalf("new caller got %v, %v, %v, want a fresh call", response, shared, err)
+	}
+}
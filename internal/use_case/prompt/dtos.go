@@ -5,29 +5,71 @@ import (
 	"ai-orchestrator/internal/infra/persistence/repository/outbox"
 	"encoding/json"
 	"github.com/google/uuid"
+	"time"
 )
 
 type TaskPayload struct {
-	ID      uuid.UUID `json:"id"`
-	UserID  uuid.UUID `json:"user_id"`
-	ModelID string    `json:"model_id"`
-	Text    string    `json:"text"`
+	ID       uuid.UUID               `json:"id"`
+	UserID   uuid.UUID               `json:"user_id"`
+	ModelID  string                  `json:"model_id"`
+	Text     string                  `json:"text"`
+	Options  model.GenerationOptions `json:"options"`
+	Deadline time.Time               `json:"deadline"`
+	Priority model.Priority          `json:"priority,omitempty"`
+
+	// Timeout bounds the processing from the moment a worker picks the task up,
+	// for tasks without a deadline.
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// Attachments only reference the blobs, the content is loaded by the worker.
+	Attachments []model.Attachment `json:"attachments,omitempty"`
+
+	// Tools the model may call while generating the response.
+	Tools []string `json:"tools,omitempty"`
 }
 
 type ResultPayload struct {
-	ID       uuid.UUID `json:"id"`
-	Response string    `json:"response"`
-	Error    string    `json:"error,omitempty"`
+	ID        uuid.UUID       `json:"id"`
+	Response  string          `json:"response"`
+	ModelID   string          `json:"model_id,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	ErrorCode model.ErrorCode `json:"error_code,omitempty"`
+	Cached    bool            `json:"cached,omitempty"`
+
+	StructuredOutput json.RawMessage  `json:"structured_output,omitempty"`
+	ToolCalls        []model.ToolCall `json:"tool_calls,omitempty"`
 }
 
 type WebSocketResult struct {
-	ID       uuid.UUID    `json:"id"`
-	UserID   uuid.UUID    `json:"user_id"`
-	ModelID  string       `json:"model_id"`
-	Text     string       `json:"text"`
-	Response string       `json:"response"`
-	Status   model.Status `json:"status"`
-	Error    string       `json:"error,omitempty"`
+	ID        uuid.UUID       `json:"id"`
+	UserID    uuid.UUID       `json:"user_id"`
+	ModelID   string          `json:"model_id"`
+	Text      string          `json:"text"`
+	Response  string          `json:"response"`
+	Status    model.Status    `json:"status"`
+	Error     string          `json:"error,omitempty"`
+	ErrorCode model.ErrorCode `json:"error_code,omitempty"`
+	Cached    bool            `json:"cached,omitempty"`
+
+	// ServedModelID is the model that answered, it differs from ModelID after a fallback.
+	ServedModelID    string           `json:"served_model_id,omitempty"`
+	StructuredOutput json.RawMessage  `json:"structured_output,omitempty"`
+	ToolCalls        []model.ToolCall `json:"tool_calls,omitempty"`
+}
+
+func NewTaskPayload(prompt *model.Prompt) TaskPayload {
+	return TaskPayload{
+		ID:          prompt.ID,
+		UserID:      prompt.UserID,
+		ModelID:     prompt.ModelID,
+		Text:        prompt.Text,
+		Options:     prompt.Options,
+		Deadline:    prompt.Deadline,
+		Priority:    prompt.Priority,
+		Timeout:     prompt.Timeout,
+		Attachments: prompt.Attachments,
+		Tools:       prompt.Tools,
+	}
 }
 
 func (tp *TaskPayload) ToEvent(eventType string) outbox.Event {
@@ -40,18 +82,25 @@ func (tp *TaskPayload) ToEvent(eventType string) outbox.Event {
 		EventType:     eventType,
 		Payload:       data,
 		Status:        outbox.Pending,
+		Priority:      string(tp.Priority),
 		RetryCount:    0,
 	}
 }
 
 func DomainToWebsocket(d *model.Prompt) WebSocketResult {
 	return WebSocketResult{
-		ID:       d.ID,
-		UserID:   d.UserID,
-		ModelID:  d.ModelID,
-		Text:     d.Text,
-		Response: d.Response,
-		Status:   d.Status,
-		Error:    d.Error,
+		ID:        d.ID,
+		UserID:    d.UserID,
+		ModelID:   d.ModelID,
+		Text:      d.Text,
+		Response:  d.Response,
+		Status:    d.Status,
+		Error:     d.Error,
+		ErrorCode: d.ErrorCode,
+		Cached:    d.Cached,
+
+		ServedModelID:    d.ServedModelID,
+		StructuredOutput: d.StructuredOutput,
+		ToolCalls:        d.ToolCalls,
 	}
 }
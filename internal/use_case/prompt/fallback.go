@@ -0,0 +1,105 @@
+package prompt
+
+import (
+	"ai-orchestrator/internal/domain/gateway"
+	"ai-orchestrator/internal/domain/model"
+	"context"
+	"errors"
+	"time"
+)
+
+// generateWithFallback tries the fallbacks of the model in order, as long as
+// the providers are rate limited or unavailable once their own retries are
+// exhausted. When the circuits of all of them are open, the prompt is deferred
+// until the first one lets calls through again, if its deadline allows it.
+func (uc *SendPromptUsecase) generateWithFallback(ctx context.Context, task *TaskPayload, request gateway.Request) (gateway.Response, error) {
+	chain := uc.fallbackChain(request)
+
+	for {
+		res, wait, err := uc.tryChain(ctx, task, request, chain)
+		if wait <= 0 {
+			return res, err
+		}
+
+		deadline, ok := ctx.Deadline()
+		if !ok || time.Until(deadline) <= wait {
+			return res, err
+		}
+
+		uc.logger.InfoContext(ctx, "Circuits of the models are open, deferring the prompt", "prompt_id", task.ID, "wait", wait)
+		select {
+		case <-ctx.Done():
+			return res, err
+		case <-time.After(wait):
+		}
+	}
+}
+
+// tryChain calls the models of the chain in order. When all of them were
+// rejected by their open circuit, it returns the shortest time to wait for one.
+func (uc *SendPromptUsecase) tryChain(ctx context.Context, task *TaskPayload, request gateway.Request, chain []string) (gateway.Response, time.Duration, error) {
+	var res gateway.Response
+	var err error
+	var wait time.Duration
+	for i, modelID := range chain {
+		if i > 0 {
+			uc.logger.WarnContext(ctx, "Model is unavailable, falling back", "prompt_id", task.ID, "from", chain[i-1], "to", modelID, "error", err)
+		}
+
+		request.Model = modelID
+		res, err = uc.generate(ctx, task, request)
+		if err == nil || !exhausted(err) || ctx.Err() != nil {
+			return res, 0, err
+		}
+
+		var open *gateway.CircuitOpenError
+		if !errors.As(err, &open) {
+			wait = -1
+		} else if wait == 0 || (wait > 0 && open.RetryAfter < wait) {
+			wait = open.RetryAfter
+		}
+	}
+
+	return res, wait, err
+}
+
+func exhausted(err error) bool {
+	code := gateway.ErrorCodeOf(err)
+	return code == model.ErrorCodeRateLimited || code == model.ErrorCodeProviderUnavailable
+}
+
+// fallbackChain returns the model of the request followed by its enabled
+// fallbacks able to serve the request.
+func (uc *SendPromptUsecase) fallbackChain(request gateway.Request) []string {
+	chain := []string{request.Model}
+
+	m, ok := uc.models.Get(request.Model)
+	if !ok {
+		return chain
+	}
+	for _, id := range m.Fallbacks {
+		if fallback, ok := uc.models.Get(id); ok && serves(fallback, request) {
+			chain = append(chain, id)
+		}
+	}
+
+	return chain
+}
+
+// serves tells whether the model supports what the request relies on.
+func serves(m model.Model, request gateway.Request) bool {
+	switch {
+	case !m.Enabled:
+		return false
+	case len(request.Attachments) > 0 && !m.Attachments:
+		return false
+	case len(request.Tools) > 0 && !m.Tools:
+		return false
+	case request.Options.WantsJSON() && m.JSONMode != nil && !*m.JSONMode:
+		return false
+	case m.MaxOutputTokens > 0 && request.Options.MaxOutputTokens > m.MaxOutputTokens:
+		return false
+	}
+
+	return true
+}
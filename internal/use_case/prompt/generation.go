@@ -0,0 +1,73 @@
+package prompt
+
+import (
+	"ai-orchestrator/internal/config/shared"
+	"ai-orchestrator/internal/domain/model"
+	"errors"
+	"fmt"
+)
+
+var ErrNilGenerationConfig = errors.New("generation config is nil")
+
+// GenerationPolicy checks the generation options against the limits
+// configured for the model.
+type GenerationPolicy struct {
+	cfg *shared.GenerationConfig
+}
+
+func NewGenerationPolicy(cfg *shared.GenerationConfig) (*GenerationPolicy, error) {
+	if cfg == nil {
+		return nil, ErrNilGenerationConfig
+	}
+
+	return &GenerationPolicy{cfg: cfg}, nil
+}
+
+func (gp *GenerationPolicy) Validate(m model.Model, options model.GenerationOptions) error {
+	maxOutputTokens, jsonMode := gp.limits(m)
+	modelID := m.ID
+
+	if t := options.Temperature; t != nil && (*t < 0 || *t > 2) {
+		return fmt.Errorf("%w: temperature must be between 0 and 2", model.ErrInvalidGenerationOptions)
+	}
+	if p := options.TopP; p != nil && (*p < 0 || *p > 1) {
+		return fmt.Errorf("%w: top_p must be between 0 and 1", model.ErrInvalidGenerationOptions)
+	}
+	if options.MaxOutputTokens < 0 || options.MaxOutputTokens > maxOutputTokens {
+		return fmt.Errorf("%w: max_output_tokens must be between 1 and %d for model %q", model.ErrInvalidGenerationOptions, maxOutputTokens, modelID)
+	}
+
+	if len(options.StopSequences) > gp.cfg.MaxStopSequences {
+		return fmt.Errorf("%w: at most %d stop_sequences are allowed", model.ErrInvalidGenerationOptions, gp.cfg.MaxStopSequences)
+	}
+	for _, seq := range options.StopSequences {
+		if seq == "" {
+			return fmt.Errorf("%w: stop_sequences must not be empty", model.ErrInvalidGenerationOptions)
+		}
+	}
+
+	if options.WantsJSON() && !jsonMode {
+		return fmt.Errorf("%w: model %q does not support JSON mode", model.ErrInvalidGenerationOptions, modelID)
+	}
+	if len(options.ResponseSchema) > 0 {
+		if _, err := model.ParseJSONSchema(options.ResponseSchema); err != nil {
+			return fmt.Errorf("%w: response_schema: %w", model.ErrInvalidGenerationOptions, err)
+		}
+	}
+
+	return nil
+}
+
+func (gp *GenerationPolicy) limits(m model.Model) (int32, bool) {
+	maxOutputTokens := gp.cfg.MaxOutputTokens
+	jsonMode := gp.cfg.JSONMode
+
+	if m.MaxOutputTokens > 0 {
+		maxOutputTokens = m.MaxOutputTokens
+	}
+	if m.JSONMode != nil {
+		jsonMode = *m.JSONMode
+	}
+
+	return maxOutputTokens, jsonMode
+}
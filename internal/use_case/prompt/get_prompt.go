@@ -0,0 +1,37 @@
+package prompt
+
+import (
+	"ai-orchestrator/internal/common/logger"
+	"ai-orchestrator/internal/domain/model"
+	"context"
+	"github.com/google/uuid"
+)
+
+type GetPromptUsecase struct {
+	logger logger.Logger
+	repo   Repository
+}
+
+func NewGetPromptUsecase(l logger.Logger, repository Repository) (*GetPromptUsecase, error) {
+	if l == nil {
+		return nil, logger.ErrNilLogger
+	}
+	if repository == nil {
+		return nil, ErrNilRepository
+	}
+
+	return &GetPromptUsecase{
+		logger: l,
+		repo:   repository,
+	}, nil
+}
+
+func (g *GetPromptUsecase) GetPrompt(ctx context.Context, id uuid.UUID) (*model.Prompt, error) {
+	prompt, err := g.repo.GetPromptByID(ctx, id)
+	if err != nil {
+		g.logger.WarnContext(ctx, "failed to get prompt by id", "error", err, "prompt_id", id)
+		return nil, err
+	}
+
+	return prompt, nil
+}
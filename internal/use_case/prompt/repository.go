@@ -12,5 +12,6 @@ var ErrNilRepository = errors.New("repository is nil")
 type Repository interface {
 	GetPromptByID(ctx context.Context, id uuid.UUID) (*model.Prompt, error)
 	InsertPrompt(ctx context.Context, prompt model.Prompt) error
-	UpdatePrompt(ctx context.Context, prompt model.Prompt) error
+	UpdatePrompt(ctx context.Context, prompt model.Prompt, expected model.Status) (bool, error)
+	UpdatePromptStatus(ctx context.Context, id uuid.UUID, expected, status model.Status) (bool, error)
 }
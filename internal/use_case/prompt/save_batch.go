@@ -0,0 +1,171 @@
+package prompt
+
+import (
+	"ai-orchestrator/internal/common/logger"
+	"ai-orchestrator/internal/domain/model"
+	"ai-orchestrator/internal/infra/persistence/repository/outbox"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+)
+
+var ErrNilBatchRepository = errors.New("batch repository is nil")
+
+type BatchRepository interface {
+	InsertBatch(ctx context.Context, batch model.Batch) error
+	GetBatchByID(ctx context.Context, id uuid.UUID) (*model.Batch, error)
+}
+
+type BatchPromptRepository interface {
+	InsertPrompts(ctx context.Context, prompts []model.Prompt) error
+	GetPromptsByBatch(ctx context.Context, batchID uuid.UUID) ([]model.Prompt, error)
+}
+
+type BatchOutboxRepository interface {
+	CreateEvents(ctx context.Context, events []outbox.Event) error
+}
+
+var ErrNilPreparer = errors.New("prompt preparer is nil")
+
+type PromptPreparer interface {
+	Prepare(ctx context.Context, prompt *model.Prompt) error
+}
+
+// BatchItemError tells which prompt of a batch was rejected.
+type BatchItemError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchItemError) Error() string {
+	return fmt.Sprintf("prompt %d: %s", e.Index, e.Err)
+}
+
+func (e *BatchItemError) Unwrap() error {
+	return e.Err
+}
+
+type SaveBatchUsecase struct {
+	logger   logger.Logger
+	preparer PromptPreparer
+	prompts  BatchPromptRepository
+	batches  BatchRepository
+	tx       Transactor
+	outbox   BatchOutboxRepository
+}
+
+func NewSaveBatchUsecase(l logger.Logger, preparer PromptPreparer, prompts BatchPromptRepository, batches BatchRepository, tx Transactor, or BatchOutboxRepository) (*SaveBatchUsecase, error) {
+	if l == nil {
+		return nil, logger.ErrNilLogger
+	}
+	if preparer == nil {
+		return nil, ErrNilPreparer
+	}
+	if prompts == nil {
+		return nil, ErrNilRepository
+	}
+	if batches == nil {
+		return nil, ErrNilBatchRepository
+	}
+	if tx == nil {
+		return nil, ErrNilTransactor
+	}
+	if or == nil {
+		return nil, ErrNilOutbox
+	}
+
+	return &SaveBatchUsecase{
+		logger:   l,
+		preparer: preparer,
+		prompts:  prompts,
+		batches:  batches,
+		tx:       tx,
+		outbox:   or,
+	}, nil
+}
+
+// PostBatch saves the batch with all its prompts and their task events at once.
+// Either every prompt is accepted or the whole batch is rejected with a
+// BatchItemError. Prompts answered by the response cache are completed right away.
+func (uc *SaveBatchUsecase) PostBatch(ctx context.Context, batch *model.Batch, prompts []model.Prompt) error {
+	batch.ID = uuid.New()
+	batch.Status = model.BatchProcessing
+	batch.Total = len(prompts)
+
+	events := make([]outbox.Event, 0, len(prompts))
+	for i := range prompts {
+		prompt := &prompts[i]
+		prompt.UserID = batch.UserID
+		prompt.Batch = &model.BatchRef{ID: batch.ID, Index: i}
+
+		if len(prompt.Attachments) > 0 {
+			return &BatchItemError{Index: i, Err: fmt.Errorf("%w: batches do not accept attachments", model.ErrInvalidAttachments)}
+		}
+
+		err := uc.preparer.Prepare(ctx, prompt)
+		if err != nil {
+			return &BatchItemError{Index: i, Err: err}
+		}
+
+		if prompt.Cached {
+			batch.Completed++
+			continue
+		}
+
+		payload := NewTaskPayload(prompt)
+		events = append(events, payload.ToEvent("PostPrompt"))
+	}
+	if batch.Pending() == 0 {
+		batch.Status = model.BatchCompleted
+	}
+
+	return uc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := uc.batches.InsertBatch(ctx, *batch)
+		if err != nil {
+			uc.logger.ErrorContext(ctx, "saving batch failed", "error", err)
+			return err
+		}
+
+		err = uc.prompts.InsertPrompts(ctx, prompts)
+		if err != nil {
+			uc.logger.ErrorContext(ctx, "saving batch prompts failed", "error", err, "batch_id", batch.ID)
+			return err
+		}
+
+		err = uc.outbox.CreateEvents(ctx, events)
+		if err != nil {
+			uc.logger.ErrorContext(ctx, "saving batch events failed", "error", err, "batch_id", batch.ID)
+			return err
+		}
+
+		return nil
+	})
+}
+
+// GetBatch returns the batch with its progress. Only the owner of the batch may see it.
+func (uc *SaveBatchUsecase) GetBatch(ctx context.Context, id, userID uuid.UUID) (*model.Batch, error) {
+	batch, err := uc.batches.GetBatchByID(ctx, id)
+	if err != nil {
+		uc.logger.WarnContext(ctx, "failed to get batch by id", "error", err, "batch_id", id)
+		return nil, err
+	}
+	if batch.UserID != userID {
+		return nil, model.ErrBatchNotFound
+	}
+
+	return batch, nil
+}
+
+// GetBatchResults returns the prompts of a completed batch in the submitted order.
+func (uc *SaveBatchUsecase) GetBatchResults(ctx context.Context, id, userID uuid.UUID) ([]model.Prompt, error) {
+	batch, err := uc.GetBatch(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if batch.Status != model.BatchCompleted {
+		return nil, model.ErrBatchNotComplete
+	}
+
+	return uc.prompts.GetPromptsByBatch(ctx, id)
+}
@@ -2,10 +2,17 @@ package prompt
 
 import (
 	"ai-orchestrator/internal/common/logger"
+	"ai-orchestrator/internal/domain/gateway"
 	"ai-orchestrator/internal/domain/model"
 	"ai-orchestrator/internal/infra/persistence/repository/outbox"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 var ErrNilTransactor = errors.New("transactor is nil")
@@ -20,14 +27,55 @@ type OutboxRepository interface {
 	CreateEvent(ctx context.Context, event outbox.Event) error
 }
 
+var ErrNilTimeoutResolver = errors.New("timeout resolver is nil")
+
+type TimeoutResolver interface {
+	Resolve(m model.Model, requested time.Duration) (time.Duration, error)
+}
+
+var ErrNilGenerationValidator = errors.New("generation validator is nil")
+
+type GenerationValidator interface {
+	Validate(m model.Model, options model.GenerationOptions) error
+}
+
+var ErrNilModelResolver = errors.New("model resolver is nil")
+
+type ModelResolver interface {
+	Resolve(id string) (model.Model, error)
+}
+
+var ErrNilTemplateProvider = errors.New("template provider is nil")
+
+type TemplateProvider interface {
+	GetTemplate(ctx context.Context, id uuid.UUID, version int) (*model.Template, error)
+}
+
+var ErrNilResponseLookup = errors.New("response lookup is nil")
+
+var ErrInvalidMaxPromptLength = errors.New("max prompt length must be positive")
+
+type ResponseLookup interface {
+	Lookup(ctx context.Context, prompt *model.Prompt) (string, bool)
+}
+
 type SavePromptUsecase struct {
-	logger logger.Logger
-	repo   Repository
-	tx     Transactor
-	outbox OutboxRepository
+	logger     logger.Logger
+	repo       Repository
+	tx         Transactor
+	outbox     OutboxRepository
+	models     ModelResolver
+	timeouts   TimeoutResolver
+	generation GenerationValidator
+	blobs      gateway.BlobStore
+	templates  TemplateProvider
+	responses  ResponseLookup
+	results    Producer
+
+	maxPromptLength int
 }
 
-func NewSavePromptUsecase(l logger.Logger, repository Repository, tx Transactor, or OutboxRepository) (*SavePromptUsecase, error) {
+func NewSavePromptUsecase(l logger.Logger, repository Repository, tx Transactor, or OutboxRepository, models ModelResolver, timeouts TimeoutResolver, generation GenerationValidator, blobs gateway.BlobStore, templates TemplateProvider, responses ResponseLookup, results Producer, maxPromptLength int) (*SavePromptUsecase, error) {
 	if l == nil {
 		return nil, logger.ErrNilLogger
 	}
@@ -40,33 +88,78 @@ func NewSavePromptUsecase(l logger.Logger, repository Repository, tx Transactor,
 	if or == nil {
 		return nil, ErrNilOutbox
 	}
+	if models == nil {
+		return nil, ErrNilModelResolver
+	}
+	if timeouts == nil {
+		return nil, ErrNilTimeoutResolver
+	}
+	if generation == nil {
+		return nil, ErrNilGenerationValidator
+	}
+	if blobs == nil {
+		return nil, gateway.ErrNilBlobStore
+	}
+	if templates == nil {
+		return nil, ErrNilTemplateProvider
+	}
+	if responses == nil {
+		return nil, ErrNilResponseLookup
+	}
+	if results == nil {
+		return nil, errors.New("producer is nil")
+	}
+	if maxPromptLength <= 0 {
+		return nil, ErrInvalidMaxPromptLength
+	}
 
 	return &SavePromptUsecase{
-		logger: l,
-		repo:   repository,
-		tx:     tx,
-		outbox: or,
+		logger:     l,
+		repo:       repository,
+		tx:         tx,
+		outbox:     or,
+		models:     models,
+		timeouts:   timeouts,
+		generation: generation,
+		blobs:      blobs,
+		templates:  templates,
+		responses:  responses,
+		results:    results,
+
+		maxPromptLength: maxPromptLength,
 	}, nil
 }
 
-func (s *SavePromptUsecase) PostPrompt(ctx context.Context, prompt model.Prompt) error {
-	prompt.Status = model.Accepted
+// PostPrompt saves the prompt along with the task event. The prompt is updated
+// in place with its status, the resolved deadline and the rendered template text.
+func (s *SavePromptUsecase) PostPrompt(ctx context.Context, prompt *model.Prompt) error {
+	err := s.Prepare(ctx, prompt)
+	if err != nil {
+		return err
+	}
 
-	payload := TaskPayload{
-		ID:      prompt.ID,
-		UserID:  prompt.UserID,
-		ModelID: prompt.ModelID,
-		Text:    prompt.Text,
+	if prompt.Cached {
+		return s.completeFromCache(ctx, prompt)
 	}
 
+	err = s.storeAttachments(ctx, prompt)
+	if err != nil {
+		return err
+	}
+
+	payload := NewTaskPayload(prompt)
+
 	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
-		err := s.repo.InsertPrompt(ctx, prompt)
+		err := s.repo.InsertPrompt(ctx, *prompt)
 		if err != nil {
 			s.logger.ErrorContext(ctx, "saving prompt failed", "error", err)
 			return err
 		}
 
-		err = s.outbox.CreateEvent(ctx, payload.ToEvent("PostPrompt"))
+		event := payload.ToEvent("PostPrompt")
+		event.AvailableAt = prompt.RunAt
+
+		err = s.outbox.CreateEvent(ctx, event)
 		if err != nil {
 			s.logger.ErrorContext(ctx, "saving event failed", "error", err)
 			return err
@@ -75,3 +168,162 @@ func (s *SavePromptUsecase) PostPrompt(ctx context.Context, prompt model.Prompt)
 		return nil
 	})
 }
+
+// Prepare validates the prompt against its model and resolves everything
+// needed to save it, without saving anything. A prompt answered by the
+// response cache is returned completed.
+func (s *SavePromptUsecase) Prepare(ctx context.Context, prompt *model.Prompt) error {
+	err := s.renderTemplate(ctx, prompt)
+	if err != nil {
+		return err
+	}
+	// The handlers check the length of the submitted text, the variables of a
+	// template may expand it past the limit.
+	if n := utf8.RuneCountInString(prompt.Text); n > s.maxPromptLength {
+		s.logger.WarnContext(ctx, "rendered prompt is too long", "length", n, "max", s.maxPromptLength)
+		return fmt.Errorf("%w: the rendered prompt is %d characters long, at most %d are accepted", model.ErrTemplateRender, n, s.maxPromptLength)
+	}
+
+	m, err := s.models.Resolve(prompt.ModelID)
+	if err != nil {
+		s.logger.WarnContext(ctx, "invalid prompt model", "error", err, "model_id", prompt.ModelID)
+		return err
+	}
+	prompt.ModelID = m.ID
+
+	prompt.Priority, err = model.ParsePriority(string(prompt.Priority))
+	if err != nil {
+		s.logger.WarnContext(ctx, "invalid prompt priority", "error", err)
+		return err
+	}
+
+	timeout, err := s.timeouts.Resolve(m, prompt.Timeout)
+	if err != nil {
+		s.logger.WarnContext(ctx, "invalid prompt timeout", "error", err, "requested", prompt.Timeout)
+		return err
+	}
+
+	err = s.generation.Validate(m, prompt.Options)
+	if err != nil {
+		s.logger.WarnContext(ctx, "invalid generation options", "error", err)
+		return err
+	}
+
+	if len(prompt.Attachments) > 0 && !m.Attachments {
+		s.logger.WarnContext(ctx, "model does not accept attachments", "model_id", m.ID)
+		return fmt.Errorf("%w: model %q does not accept attachments", model.ErrInvalidAttachments, m.ID)
+	}
+
+	err = model.ValidateTools(prompt.Tools)
+	if err != nil {
+		s.logger.WarnContext(ctx, "invalid prompt tools", "error", err)
+		return err
+	}
+	if len(prompt.Tools) > 0 && !m.Tools {
+		s.logger.WarnContext(ctx, "model does not support tools", "model_id", m.ID)
+		return fmt.Errorf("%w: model %q does not support tools", model.ErrInvalidTools, m.ID)
+	}
+
+	// A delayed prompt is answered when it runs, not from the responses cached at submission.
+	delayed := prompt.RunAt.After(time.Now())
+	if !prompt.BypassCache && !delayed {
+		if response, ok := s.responses.Lookup(ctx, prompt); ok {
+			s.logger.InfoContext(ctx, "serving prompt from cache", "prompt_id", prompt.ID, "model_id", prompt.ModelID)
+
+			prompt.Status = model.Completed
+			prompt.Response = response
+			prompt.Cached = true
+			// Answers of fallback models are not cached, the model itself answered.
+			prompt.ServedModelID = prompt.ModelID
+			// Only the responses matching the schema are completed, so cached.
+			if output := model.ExtractJSON(response); len(prompt.Options.ResponseSchema) > 0 && json.Valid(output) {
+				prompt.StructuredOutput = output
+			}
+			return nil
+		}
+	}
+
+	prompt.Status = model.Accepted
+	prompt.Timeout = timeout
+	// Prompts of a batch wait in the queue behind each other and delayed prompts
+	// wait for their time, so their timeout only applies once a worker picks them up.
+	if prompt.Batch == nil && !delayed {
+		prompt.Deadline = time.Now().UTC().Add(timeout)
+	}
+
+	return nil
+}
+
+// completeFromCache saves the prompt completed with the cached response.
+// The result goes through the results stream like any other one, so the
+// response is pushed to the user by SaveResponse.
+func (s *SavePromptUsecase) completeFromCache(ctx context.Context, prompt *model.Prompt) error {
+	err := s.repo.InsertPrompt(ctx, *prompt)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "saving prompt failed", "error", err)
+		return err
+	}
+
+	// SaveResponse overwrites the prompt with the result, which must carry everything Prepare completed.
+	result, _ := json.Marshal(ResultPayload{
+		ID:               prompt.ID,
+		Response:         prompt.Response,
+		ModelID:          prompt.ServedModelID,
+		StructuredOutput: prompt.StructuredOutput,
+		Cached:           true,
+	})
+	err = s.results.Publish(ctx, result)
+	if err != nil {
+		// The prompt is already completed and its response is returned to the caller.
+		s.logger.WarnContext(ctx, "failed to publish cached result", "error", err, "prompt_id", prompt.ID)
+	}
+
+	return nil
+}
+
+// renderTemplate replaces the prompt text with the rendered template and pins the template version.
+func (s *SavePromptUsecase) renderTemplate(ctx context.Context, prompt *model.Prompt) error {
+	ref := prompt.Template
+	if ref == nil {
+		return nil
+	}
+
+	template, err := s.templates.GetTemplate(ctx, ref.ID, ref.Version)
+	if err != nil {
+		s.logger.WarnContext(ctx, "failed to get prompt template", "error", err, "template_id", ref.ID, "version", ref.Version)
+		return err
+	}
+
+	text, err := template.Render(ref.Variables)
+	if err != nil {
+		s.logger.WarnContext(ctx, "failed to render prompt template", "error", err, "template_id", ref.ID, "version", template.Version)
+		return err
+	}
+	if strings.TrimSpace(text) == "" {
+		return fmt.Errorf("%w: template %s rendered an empty prompt", model.ErrTemplateRender, ref.ID)
+	}
+
+	prompt.Text = text
+	ref.Version = template.Version
+	return nil
+}
+
+// storeAttachments uploads the attachment contents to the blob store before the
+// prompt is saved, so the task never references a missing blob. Blobs of a
+// prompt that fails to be saved afterwards are left orphaned.
+func (s *SavePromptUsecase) storeAttachments(ctx context.Context, prompt *model.Prompt) error {
+	for i := range prompt.Attachments {
+		a := &prompt.Attachments[i]
+		a.Key = fmt.Sprintf("prompts/%s/%d", prompt.ID, i)
+		a.Size = int64(len(a.Data))
+
+		err := s.blobs.Put(ctx, a.Key, a.Data)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "storing attachment failed", "error", err, "key", a.Key)
+			return err
+		}
+		a.Data = nil
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package prompt
+
+import (
+	"ai-orchestrator/internal/domain/model"
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+type staticTemplates struct {
+	template model.Template
+}
+
+func (s staticTemplates) GetTemplate(context.Context, uuid.UUID, int) (*model.Template, error) {
+	template := s.template
+	return &template, nil
+}
+
+// The variables of a short template may expand it past the maximum length.
+func TestPrepareRenderedLength(t *testing.T) {
+	s := &SavePromptUsecase{
+		logger:          slog.New(slog.DiscardHandler),
+		templates:       staticTemplates{template: model.Template{Name: "echo", Body: "Say {{.text}}", Version: 1}},
+		maxPromptLength: 16,
+	}
+
+	prompt := &model.Prompt{
+		Template: &model.TemplateRef{Variables: map[string]any{"text": strings.Repeat("a", 32)}},
+	}
+	err := s.Prepare(context.Background(), prompt)
+	if !errors.Is(err, model.ErrTemplateRender) {
+		t.Fatalf("Prepare error = %v, want %v", err, model.ErrTemplateRender)
+	}
+}
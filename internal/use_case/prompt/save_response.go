@@ -14,13 +14,27 @@ type SocketProvider interface {
 	SendToClient(ctx context.Context, userID string, data json.RawMessage) error
 }
 
+var ErrNilResponseStore = errors.New("response store is nil")
+
+type ResponseStore interface {
+	Store(ctx context.Context, prompt *model.Prompt)
+}
+
+var ErrNilPipelineAdvancer = errors.New("pipeline advancer is nil")
+
+type PipelineAdvancer interface {
+	Advance(ctx context.Context, prompt *model.Prompt) error
+}
+
 type SaveResponse struct {
-	logger logger.Logger
-	socket SocketProvider
-	repo   Repository
+	logger    logger.Logger
+	socket    SocketProvider
+	repo      Repository
+	responses ResponseStore
+	pipelines PipelineAdvancer
 }
 
-func NewSaveResponse(l logger.Logger, socket SocketProvider, repo Repository) (*SaveResponse, error) {
+func NewSaveResponse(l logger.Logger, socket SocketProvider, repo Repository, responses ResponseStore, pipelines PipelineAdvancer) (*SaveResponse, error) {
 	if l == nil {
 		return nil, logger.ErrNilLogger
 	}
@@ -30,11 +44,19 @@ func NewSaveResponse(l logger.Logger, socket SocketProvider, repo Repository) (*
 	if repo == nil {
 		return nil, ErrNilRepository
 	}
+	if responses == nil {
+		return nil, ErrNilResponseStore
+	}
+	if pipelines == nil {
+		return nil, ErrNilPipelineAdvancer
+	}
 
 	return &SaveResponse{
-		logger: l,
-		socket: socket,
-		repo:   repo,
+		logger:    l,
+		socket:    socket,
+		repo:      repo,
+		responses: responses,
+		pipelines: pipelines,
 	}, nil
 }
 
@@ -52,19 +74,56 @@ func (sr *SaveResponse) Use(ctx context.Context, entity string) error {
 		return err
 	}
 
+	if domainPrompt.Status == model.Discarded {
+		sr.logger.InfoContext(ctx, "prompt was discarded, dropping the result", "prompt_id", domainPrompt.ID)
+		return nil
+	}
+
+	status := domainPrompt.Status
+
 	domainPrompt.Response = result.Response
 	if result.Error != "" {
 		domainPrompt.Status = model.Failed
 		domainPrompt.Error = result.Error
+		domainPrompt.ErrorCode = result.ErrorCode
 	} else {
 		domainPrompt.Status = model.Completed
 	}
+	domainPrompt.Cached = result.Cached
+	domainPrompt.ServedModelID = result.ModelID
+	domainPrompt.StructuredOutput = result.StructuredOutput
+	domainPrompt.ToolCalls = result.ToolCalls
 
-	err = sr.repo.UpdatePrompt(ctx, *domainPrompt)
+	// The prompt may be cancelled between the read and the update, its result is dropped then.
+	saved, err := sr.repo.UpdatePrompt(ctx, *domainPrompt, status)
 	if err != nil {
 		sr.logger.WarnContext(ctx, "failed to save prompt", "error", err)
 		return err
 	}
+	if !saved {
+		sr.logger.InfoContext(ctx, "prompt was discarded, dropping the result", "prompt_id", domainPrompt.ID)
+		return nil
+	}
+	if !result.Cached {
+		sr.responses.Store(ctx, domainPrompt)
+	}
+
+	// Batch results are downloaded with the batch instead of being pushed one by
+	// one, and its progress is counted from the statuses of its prompts.
+	if domainPrompt.Batch != nil {
+		return nil
+	}
+
+	// Pipeline steps are retrieved with their pipeline as well. A result delivered
+	// again advances it again, in case the first delivery failed to.
+	if domainPrompt.Pipeline != nil {
+		err = sr.pipelines.Advance(ctx, domainPrompt)
+		if err != nil {
+			sr.logger.ErrorContext(ctx, "failed to advance pipeline", "error", err, "pipeline_id", domainPrompt.Pipeline.ID)
+			return err
+		}
+		return nil
+	}
 
 	wsResult := DomainToWebsocket(domainPrompt)
 	wsJson, err := json.Marshal(wsResult)
@@ -0,0 +1,97 @@
+package prompt
+
+import (
+	"ai-orchestrator/internal/domain/model"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"log/slog"
+	"testing"
+)
+
+// memoryRepository keeps a single prompt, updated only from the expected status.
+type memoryRepository struct {
+	prompt model.Prompt
+}
+
+func (r *memoryRepository) GetPromptByID(context.Context, uuid.UUID) (*model.Prompt, error) {
+	prompt := r.prompt
+	return &prompt, nil
+}
+
+func (r *memoryRepository) InsertPrompt(context.Context, model.Prompt) error {
+	return nil
+}
+
+func (r *memoryRepository) UpdatePrompt(_ context.Context, prompt model.Prompt, expected model.Status) (bool, error) {
+	if r.prompt.Status != expected {
+		return false, nil
+	}
+	r.prompt = prompt
+	return true, nil
+}
+
+func (r *memoryRepository) UpdatePromptStatus(_ context.Context, _ uuid.UUID, expected, status model.Status) (bool, error) {
+	if r.prompt.Status != expected {
+		return false, nil
+	}
+	r.prompt.Status = status
+	return true, nil
+}
+
+type discardSocket struct{}
+
+func (discardSocket) SendToClient(context.Context, string, json.RawMessage) error {
+	return nil
+}
+
+type discardResponses struct{}
+
+func (discardResponses) Store(context.Context, *model.Prompt) {}
+
+// failingAdvancer fails the given number of times, then records the statuses it advances with.
+type failingAdvancer struct {
+	failures int
+	advanced []model.Status
+}
+
+func (a *failingAdvancer) Advance(_ context.Context, prompt *model.Prompt) error {
+	if a.failures > 0 {
+		a.failures--
+		return errors.New("connection reset")
+	}
+	a.advanced = append(a.advanced, prompt.Status)
+	return nil
+}
+
+// A step whose pipeline failed to advance is advanced again when its result is redelivered.
+func TestSaveResponseAdvanceRetry(t *testing.T) {
+	id := uuid.New()
+	repo := &memoryRepository{prompt: model.Prompt{
+		ID:       id,
+		Status:   model.Accepted,
+		Pipeline: &model.PipelineRef{ID: uuid.New(), Step: "summary"},
+	}}
+	advancer := &failingAdvancer{failures: 1}
+
+	sr, err := NewSaveResponse(slog.New(slog.DiscardHandler), discardSocket{}, repo, discardResponses{}, advancer)
+	if err != nil {
+		t.Fatalf("NewSaveResponse unexpected error: %v", err)
+	}
+
+	entity, _ := json.Marshal(ResultPayload{ID: id, Response: "answer"})
+	if err := sr.Use(context.Background(), string(entity)); err == nil {
+		t.Fatal("Use succeeded while the pipeline failed to advance")
+	}
+	if repo.prompt.Status != model.Completed {
+		t.Fatalf("prompt status = %s, want %s", repo.prompt.Status, model.Completed)
+	}
+
+	if err := sr.Use(context.Background(), string(entity)); err != nil {
+		t.Fatalf("Use of the redelivered result unexpected error: %v", err)
+	}
+	if len(advancer.advanced) != 1 || advancer.advanced[0] != model.Completed {
+		t.Fatalf("pipeline advanced with %v, want once with %s", advancer.advanced, model.Completed)
+	}
+}
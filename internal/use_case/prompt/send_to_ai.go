@@ -2,37 +2,98 @@ package prompt
 
 import (
 	"ai-orchestrator/internal/common/logger"
+	"ai-orchestrator/internal/config/shared"
 	"ai-orchestrator/internal/domain/gateway"
+	"ai-orchestrator/internal/domain/model"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"sync"
+	"time"
 )
 
+var ErrPromptCancelled = errors.New("prompt cancelled by user")
+
 type Producer interface {
 	Publish(ctx context.Context, data json.RawMessage) error
 }
 
+var ErrNilCancelChecker = errors.New("cancel checker is nil")
+
+type CancelChecker interface {
+	IsCancelled(ctx context.Context, promptID uuid.UUID) (bool, error)
+}
+
+var ErrNilToolRunner = errors.New("tool runner is nil")
+
+// ToolRunner declares and runs the tools the models may call.
+type ToolRunner interface {
+	Declarations(names []string) ([]gateway.Tool, error)
+	Call(ctx context.Context, name string, arguments json.RawMessage) (string, error)
+}
+
 type SendPromptUsecase struct {
 	logger     logger.Logger
 	aiProvider gateway.AIProvider
+	models     ModelLookup
 	producer   Producer
+	cancels    CancelChecker
+	blobs      gateway.BlobStore
+	tools      ToolRunner
+	toolsCfg   *shared.ToolsConfig
+	outputCfg  *shared.StructuredOutputConfig
+
+	// inFlight keeps cancel functions of the prompts being generated right now.
+	inFlight map[uuid.UUID]context.CancelCauseFunc
+	mu       sync.Mutex
+
+	// flights coalesces concurrent identical prompts into one provider call.
+	flights *coalescer
 }
 
-func NewSendPromptUsecase(l logger.Logger, provider gateway.AIProvider, producer Producer) (*SendPromptUsecase, error) {
+func NewSendPromptUsecase(l logger.Logger, provider gateway.AIProvider, models ModelLookup, producer Producer, cancels CancelChecker, blobs gateway.BlobStore, tools ToolRunner, toolsCfg *shared.ToolsConfig, outputCfg *shared.StructuredOutputConfig) (*SendPromptUsecase, error) {
 	if l == nil {
 		return nil, logger.ErrNilLogger
 	}
 	if provider == nil {
 		return nil, gateway.ErrNilProvider
 	}
+	if models == nil {
+		return nil, ErrNilModelResolver
+	}
 	if producer == nil {
 		return nil, errors.New("producer is nil")
 	}
+	if cancels == nil {
+		return nil, ErrNilCancelChecker
+	}
+	if blobs == nil {
+		return nil, gateway.ErrNilBlobStore
+	}
+	if tools == nil {
+		return nil, ErrNilToolRunner
+	}
+	if toolsCfg == nil {
+		return nil, errors.New("tools config is nil")
+	}
+	if outputCfg == nil {
+		return nil, errors.New("structured output config is nil")
+	}
 
 	return &SendPromptUsecase{
 		logger:     l,
 		aiProvider: provider,
+		models:     models,
 		producer:   producer,
+		cancels:    cancels,
+		blobs:      blobs,
+		tools:      tools,
+		toolsCfg:   toolsCfg,
+		outputCfg:  outputCfg,
+		inFlight:   make(map[uuid.UUID]context.CancelCauseFunc),
+		flights:    newCoalescer(),
 	}, nil
 }
 
@@ -46,17 +107,100 @@ func (uc *SendPromptUsecase) Use(ctx context.Context, entity string) error {
 		return err
 	}
 
-	res, err := uc.aiProvider.Generate(ctx, userPrompt.ModelID, userPrompt.Text)
+	// Registering before the check guarantees that a cancellation published in
+	// between is not lost.
+	ctx, done := uc.track(ctx, userPrompt.ID)
+	defer done()
 
-	uc.logger.InfoContext(ctx, "Received the result", "response", res)
-	resultPayload := &ResultPayload{
-		ID:       userPrompt.ID,
-		Response: res,
+	cancelled, err := uc.cancels.IsCancelled(ctx, userPrompt.ID)
+	if err != nil {
+		uc.logger.WarnContext(ctx, "failed to check prompt cancellation, processing anyway", "error", err)
+	}
+	if cancelled {
+		uc.logger.InfoContext(ctx, "Prompt was cancelled before processing, skipping", "prompt_id", userPrompt.ID)
+		return nil
+	}
+
+	// Tasks queued without a deadline, like the ones of batches, are bounded from now on.
+	if userPrompt.Deadline.IsZero() && userPrompt.Timeout > 0 {
+		userPrompt.Deadline = time.Now().UTC().Add(userPrompt.Timeout)
+	}
+
+	if !userPrompt.Deadline.IsZero() {
+		if time.Now().After(userPrompt.Deadline) {
+			uc.logger.WarnContext(ctx, "Prompt expired before processing", "prompt_id", userPrompt.ID, "deadline", userPrompt.Deadline)
+			return uc.publish(ctx, failedResult(userPrompt.ID, model.ErrorCodeTimeout))
+		}
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, userPrompt.Deadline)
+		defer cancel()
+	}
+
+	attachments, err := uc.loadAttachments(ctx, userPrompt.Attachments)
+	if errors.Is(err, gateway.ErrBlobNotFound) {
+		uc.logger.ErrorContext(ctx, "Prompt attachment is missing", "prompt_id", userPrompt.ID, "error", err)
+		return uc.publish(ctx, failedResult(userPrompt.ID, model.ErrorCodeInternal))
 	}
 	if err != nil {
-		resultPayload.Error = err.Error()
+		return err
 	}
 
+	declarations, err := uc.tools.Declarations(userPrompt.Tools)
+	if err != nil {
+		uc.logger.WarnContext(ctx, "Prompt requested unavailable tools", "prompt_id", userPrompt.ID, "error", err)
+		return uc.publish(ctx, failedResult(userPrompt.ID, model.ErrorCodeInvalidRequest))
+	}
+
+	request := gateway.Request{
+		Model:       userPrompt.ModelID,
+		Prompt:      userPrompt.Text,
+		Options:     userPrompt.Options,
+		Attachments: attachments,
+		Tools:       declarations,
+	}
+	gen, err := uc.generateWithTools(ctx, userPrompt, request)
+	if err == nil && len(userPrompt.Options.ResponseSchema) > 0 {
+		gen, err = uc.structuredOutput(ctx, userPrompt, request, gen)
+	}
+	if errors.Is(context.Cause(ctx), ErrPromptCancelled) {
+		uc.logger.InfoContext(ctx, "Prompt was cancelled during processing, result is not published", "prompt_id", userPrompt.ID)
+		return nil
+	}
+	if errors.Is(ctx.Err(), context.Canceled) {
+		// The worker is shutting down: the task is handed over to another one, not failed.
+		uc.logger.InfoContext(ctx, "Prompt processing was interrupted, result is not published", "prompt_id", userPrompt.ID)
+		return context.Cause(ctx)
+	}
+
+	uc.logger.InfoContext(ctx, "Received the result", "response", gen.response, "model_id", gen.modelID)
+	resultPayload := &ResultPayload{
+		ID:               userPrompt.ID,
+		Response:         gen.response,
+		ModelID:          gen.modelID,
+		StructuredOutput: gen.output,
+		ToolCalls:        gen.calls,
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		uc.logger.WarnContext(ctx, "Prompt processing exceeded the deadline", "prompt_id", userPrompt.ID, "deadline", userPrompt.Deadline)
+		resultPayload = failedResult(userPrompt.ID, model.ErrorCodeTimeout)
+
+		// The task context is expired, but the result must be delivered anyway.
+		ctx = context.WithoutCancel(ctx)
+	} else if err != nil {
+		// The raw provider error stays in the logs, the user gets only the sanitised message.
+		uc.logger.WarnContext(ctx, "Prompt processing failed", "prompt_id", userPrompt.ID, "error", err)
+		resultPayload = failedResult(userPrompt.ID, gateway.ErrorCodeOf(err))
+		// An answer rejected by the response schema is kept to ease debugging.
+		resultPayload.Response = gen.response
+		resultPayload.ModelID = gen.modelID
+		resultPayload.ToolCalls = gen.calls
+	}
+
+	return uc.publish(ctx, resultPayload)
+}
+
+func (uc *SendPromptUsecase) publish(ctx context.Context, resultPayload *ResultPayload) error {
 	resultJson, err := json.Marshal(resultPayload)
 	if err != nil {
 		uc.logger.WarnContext(ctx, "failed to marshal result", "error", err)
@@ -71,3 +215,171 @@ func (uc *SendPromptUsecase) Use(ctx context.Context, entity string) error {
 
 	return nil
 }
+
+// generation is the outcome of the processing of a prompt.
+type generation struct {
+	response string
+	// modelID is the model that answered, another one than requested after a fallback.
+	modelID string
+	output  json.RawMessage
+	calls   []model.ToolCall
+}
+
+// generateWithTools runs the tool loop: as long as the model asks for tool
+// calls, they are run and their results are sent back along with the previous
+// rounds. The model gets the configured number of rounds to produce the answer.
+func (uc *SendPromptUsecase) generateWithTools(ctx context.Context, task *TaskPayload, request gateway.Request) (generation, error) {
+	gen := generation{modelID: request.Model, calls: request.ToolCalls}
+
+	for round := 1; ; round++ {
+		res, err := uc.generateWithFallback(ctx, task, request)
+		if err != nil {
+			return gen, err
+		}
+
+		// The next rounds stay on the model that took over, it made the calls.
+		request.Model = res.Model
+		gen.modelID = res.Model
+		if len(res.ToolCalls) == 0 {
+			gen.response = res.Text
+			return gen, nil
+		}
+		if round > uc.toolsCfg.MaxRounds {
+			uc.logger.WarnContext(ctx, "Prompt exceeded the tool call rounds", "prompt_id", task.ID, "rounds", uc.toolsCfg.MaxRounds)
+			return gen, gateway.NewProviderError(model.ErrorCodeToolLoop, errors.New("tool call rounds exceeded"))
+		}
+
+		for _, call := range res.ToolCalls {
+			call.Round = round
+			output, err := uc.tools.Call(ctx, call.Name, call.Arguments)
+			if err != nil {
+				uc.logger.InfoContext(ctx, "Tool call failed", "prompt_id", task.ID, "tool", call.Name, "error", err)
+				call.Error = err.Error()
+			} else {
+				call.Output = output
+			}
+			request.ToolCalls = append(request.ToolCalls, call)
+		}
+		gen.calls = request.ToolCalls
+
+		if err := ctx.Err(); err != nil {
+			return gen, err
+		}
+	}
+}
+
+// structuredOutput validates the answer against the response schema. An invalid
+// answer is sent back to the model along with the violations, up to the
+// configured number of repairs.
+func (uc *SendPromptUsecase) structuredOutput(ctx context.Context, task *TaskPayload, request gateway.Request, gen generation) (generation, error) {
+	schema, err := model.ParseJSONSchema(task.Options.ResponseSchema)
+	if err != nil {
+		return gen, gateway.NewProviderError(model.ErrorCodeInvalidRequest, err)
+	}
+
+	for repair := 0; ; repair++ {
+		output := model.ExtractJSON(gen.response)
+		err := schema.Validate(output)
+		if err == nil {
+			gen.output = output
+			return gen, nil
+		}
+		if repair >= uc.outputCfg.MaxRepairs {
+			uc.logger.WarnContext(ctx, "Answer does not match the response schema", "prompt_id", task.ID, "repairs", repair, "error", err)
+			return gen, gateway.NewProviderError(model.ErrorCodeInvalidOutput, err)
+		}
+
+		uc.logger.InfoContext(ctx, "Answer does not match the response schema, asking for a repair", "prompt_id", task.ID, "repair", repair+1, "error", err)
+		request.Model = gen.modelID
+		request.Prompt = repairPrompt(task.Text, gen.response, err)
+		request.ToolCalls = gen.calls
+		gen, err = uc.generateWithTools(ctx, task, request)
+		if err != nil {
+			return gen, err
+		}
+	}
+}
+
+func repairPrompt(prompt, answer string, violation error) string {
+	return fmt.Sprintf("%s\n\nYour previous answer was:\n%s\n\nIt is rejected because %v.\nAnswer again with the corrected JSON document only.", prompt, answer, violation)
+}
+
+// generate calls the provider, sharing the call with the concurrent tasks of
+// identical requests. The key is the response cache one; prompts with
+// attachments or tools are never shared, like they are never cached.
+func (uc *SendPromptUsecase) generate(ctx context.Context, task *TaskPayload, request gateway.Request) (gateway.Response, error) {
+	if len(request.Attachments) > 0 || len(request.Tools) > 0 {
+		return uc.aiProvider.Generate(ctx, request)
+	}
+
+	key := PromptKey(&model.Prompt{
+		ModelID: request.Model,
+		Text:    request.Prompt,
+		Options: task.Options,
+	})
+	res, shared, err := uc.flights.Do(ctx, key, func(ctx context.Context) (gateway.Response, error) {
+		return uc.aiProvider.Generate(ctx, request)
+	})
+	if shared {
+		uc.logger.InfoContext(ctx, "Prompt coalesced with an identical in-flight prompt", "prompt_id", task.ID)
+	}
+
+	return res, err
+}
+
+// loadAttachments fetches the content of the attachments referenced by the task.
+func (uc *SendPromptUsecase) loadAttachments(ctx context.Context, refs []model.Attachment) ([]model.Attachment, error) {
+	if len(refs) == 0 {
+		return nil, nil
+	}
+
+	attachments := make([]model.Attachment, 0, len(refs))
+	for _, a := range refs {
+		data, err := uc.blobs.Get(ctx, a.Key)
+		if err != nil {
+			uc.logger.WarnContext(ctx, "failed to load attachment", "error", err, "key", a.Key)
+			return nil, fmt.Errorf("load attachment %q: %w", a.Key, err)
+		}
+
+		a.Data = data
+		attachments = append(attachments, a)
+	}
+
+	return attachments, nil
+}
+
+func failedResult(id uuid.UUID, code model.ErrorCode) *ResultPayload {
+	return &ResultPayload{
+		ID:        id,
+		Error:     code.Message(),
+		ErrorCode: code,
+	}
+}
+
+// Cancel interrupts the generation of the prompt if it is processed by this worker.
+func (uc *SendPromptUsecase) Cancel(promptID uuid.UUID) {
+	uc.mu.Lock()
+	cancel, ok := uc.inFlight[promptID]
+	uc.mu.Unlock()
+
+	if ok {
+		uc.logger.Info("Cancelling in-flight prompt", "prompt_id", promptID)
+		cancel(ErrPromptCancelled)
+	}
+}
+
+func (uc *SendPromptUsecase) track(ctx context.Context, promptID uuid.UUID) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	uc.mu.Lock()
+	uc.inFlight[promptID] = cancel
+	uc.mu.Unlock()
+
+	return ctx, func() {
+		uc.mu.Lock()
+		delete(uc.inFlight, promptID)
+		uc.mu.Unlock()
+
+		cancel(nil)
+	}
+}
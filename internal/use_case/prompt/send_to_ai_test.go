@@ -0,0 +1,195 @@
+package prompt
+
+import (
+	"ai-orchestrator/internal/config/shared"
+	"ai-orchestrator/internal/domain/gateway"
+	"ai-orchestrator/internal/domain/model"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// scriptedProvider answers the calls in order and records their requests.
+type scriptedProvider struct {
+	answers  []gateway.Response
+	err      error
+	requests []gateway.Request
+}
+
+func (p *scriptedProvider) Generate(_ context.Context, request gateway.Request) (gateway.Response, error) {
+	p.requests = append(p.requests, request)
+	if p.err != nil {
+		return gateway.Response{}, p.err
+	}
+	if len(p.requests) > len(p.answers) {
+		return gateway.Response{}, errors.New("unexpected call")
+	}
+
+	return p.answers[len(p.requests)-1], nil
+}
+
+type noModels struct{}
+
+func (noModels) Get(string) (model.Model, bool) {
+	return model.Model{}, false
+}
+
+func newTestSendPrompt(provider gateway.AIProvider, maxRepairs int) *SendPromptUsecase {
+	return &SendPromptUsecase{
+		logger:     slog.New(slog.DiscardHandler),
+		aiProvider: provider,
+		models:     noModels{},
+		toolsCfg:   &shared.ToolsConfig{MaxRounds: 1},
+		outputCfg:  &shared.StructuredOutputConfig{MaxRepairs: maxRepairs},
+		flights:    newCoalescer(),
+	}
+}
+
+func TestStructuredOutputRepair(t *testing.T) {
+	const schema = `{"type": "object", "properties": {"n": {"type": "integer"}}, "required": ["n"]}`
+
+	tests := []struct {
+		name        string
+		schema      string
+		first       string
+		answers     []string
+		providerErr error
+		maxRepairs  int
+		wantCalls   int
+		wantOutput  string
+		wantCode    model.ErrorCode
+	}{
+		{
+			name:       "valid answer",
+			schema:     schema,
+			first:      `{"n": 1}`,
+			maxRepairs: 2,
+			wantOutput: `{"n": 1}`,
+		},
+		{
+			name:       "fenced answer",
+			schema:     schema,
+			first:      "```json\n{\"n\": 1}\n```",
+			maxRepairs: 2,
+			wantOutput: `{"n": 1}`,
+		},
+		{
+			name:       "repaired answer",
+			schema:     schema,
+			first:      `{"n": "one"}`,
+			answers:    []string{`{"n": 1}`},
+			maxRepairs: 2,
+			wantCalls:  1,
+			wantOutput: `{"n": 1}`,
+		},
+		{
+			name:       "repaired on the last attempt",
+			schema:     schema,
+			first:      `{}`,
+			answers:    []string{`{"n": 1.5}`, `{"n": 2}`},
+			maxRepairs: 2,
+			wantCalls:  2,
+			wantOutput: `{"n": 2}`,
+		},
+		{
+			name:       "repairs exhausted",
+			schema:     schema,
+			first:      `{}`,
+			answers:    []string{`{}`, `not JSON`},
+			maxRepairs: 2,
+			wantCalls:  2,
+			wantCode:   model.ErrorCodeInvalidOutput,
+		},
+		{
+			name:       "repairs disabled",
+			schema:     schema,
+			first:      `{}`,
+			maxRepairs: 0,
+			wantCode:   model.ErrorCodeInvalidOutput,
+		},
+		{
+			name:        "repair fails",
+			schema:      schema,
+			first:       `{}`,
+			providerErr: gateway.NewProviderError(model.ErrorCodeProviderUnavailable, errors.New("down")),
+			maxRepairs:  2,
+			wantCalls:   1,
+			wantCode:    model.ErrorCodeProviderUnavailable,
+		},
+		{
+			name:       "invalid schema",
+			schema:     `{"type": "date"}`,
+			first:      `{}`,
+			maxRepairs: 2,
+			wantCode:   model.ErrorCodeInvalidRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &scriptedProvider{err: tt.providerErr}
+			for _, answer := range tt.answers {
+				provider.answers = append(provider.answers, gateway.Response{Text: answer, Model: "fallback"})
+			}
+			uc := newTestSendPrompt(provider, tt.maxRepairs)
+
+			task := &TaskPayload{
+				Text:    "count",
+				Options: model.GenerationOptions{ResponseSchema: json.RawMessage(tt.schema)},
+			}
+			request := gateway.Request{Model: "requested", Prompt: task.Text, Options: task.Options}
+			gen := generation{response: tt.first, modelID: "served"}
+
+			gen, err := uc.structuredOutput(context.Background(), task, request, gen)
+
+			if len(provider.requests) != tt.wantCalls {
+				t.Fatalf("provider called %d times, want %d", len(provider.requests), tt.wantCalls)
+			}
+			if tt.wantCode != "" {
+				if code := gateway.ErrorCodeOf(err); code != tt.wantCode {
+					t.Fatalf("error = %v with code %q, want code %q", err, code, tt.wantCode)
+				}
+				if gen.output != nil {
+					t.Fatalf("output = %s, want none", gen.output)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(gen.output) != tt.wantOutput {
+				t.Fatalf("output = %s, want %s", gen.output, tt.wantOutput)
+			}
+		})
+	}
+}
+
+// A repair goes to the model that gave the answer, with the violations to fix.
+func TestStructuredOutputRepairRequest(t *testing.T) {
+	provider := &scriptedProvider{answers: []gateway.Response{{Text: `{"n": 1}`, Model: "served"}}}
+	uc := newTestSendPrompt(provider, 1)
+
+	task := &TaskPayload{
+		Text:    "count",
+		Options: model.GenerationOptions{ResponseSchema: json.RawMessage(`{"required": ["n"]}`)},
+	}
+	request := gateway.Request{Model: "requested", Prompt: task.Text, Options: task.Options}
+	gen := generation{response: `{"m": 1}`, modelID: "served"}
+
+	if _, err := uc.structuredOutput(context.Background(), task, request, gen); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	repair := provider.requests[0]
+	if repair.Model != "served" {
+		t.Fatalf("repair sent to %q, want the serving model", repair.Model)
+	}
+	for _, part := range []string{"count", `{"m": 1}`, `property "n" is required`} {
+		if !strings.Contains(repair.Prompt, part) {
+			t.Fatalf("repair prompt %q does not contain %q", repair.Prompt, part)
+		}
+	}
+}
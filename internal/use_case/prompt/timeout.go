@@ -0,0 +1,60 @@
+package prompt
+
+import (
+	"ai-orchestrator/internal/config/shared"
+	"ai-orchestrator/internal/domain/model"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrNilTimeoutConfig = errors.New("timeout config is nil")
+
+// TimeoutPolicy resolves the processing timeout of a prompt from the one
+// requested by the client and the limits configured for the model.
+type TimeoutPolicy struct {
+	cfg *shared.TimeoutConfig
+}
+
+func NewTimeoutPolicy(cfg *shared.TimeoutConfig) (*TimeoutPolicy, error) {
+	if cfg == nil {
+		return nil, ErrNilTimeoutConfig
+	}
+
+	return &TimeoutPolicy{cfg: cfg}, nil
+}
+
+// Resolve returns the default timeout of the model when requested is zero.
+func (tp *TimeoutPolicy) Resolve(m model.Model, requested time.Duration) (time.Duration, error) {
+	limits := tp.limits(m)
+
+	switch {
+	case requested < 0:
+		return 0, fmt.Errorf("%w: timeout must be positive", model.ErrInvalidTimeout)
+	case requested == 0:
+		return limits.Default, nil
+	case requested > limits.Max:
+		return 0, fmt.Errorf("%w: timeout exceeds the maximum of %s for model %q", model.ErrInvalidTimeout, limits.Max, m.ID)
+	}
+
+	return requested, nil
+}
+
+func (tp *TimeoutPolicy) limits(m model.Model) shared.TimeoutLimits {
+	limits := shared.TimeoutLimits{
+		Default: tp.cfg.Default,
+		Max:     tp.cfg.Max,
+	}
+
+	if m.DefaultTimeout > 0 {
+		limits.Default = m.DefaultTimeout
+	}
+	if m.MaxTimeout > 0 {
+		limits.Max = m.MaxTimeout
+	}
+	if limits.Default > limits.Max {
+		limits.Default = limits.Max
+	}
+
+	return limits
+}
@@ -0,0 +1,27 @@
+package schedule
+
+import (
+	"ai-orchestrator/internal/domain/model"
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"time"
+)
+
+var ErrNilRepository = errors.New("repository is nil")
+
+type Repository interface {
+	InsertSchedule(ctx context.Context, schedule model.Schedule) error
+	GetScheduleByID(ctx context.Context, id uuid.UUID) (*model.Schedule, error)
+	ListSchedules(ctx context.Context, userID uuid.UUID) ([]model.Schedule, error)
+	GetDueSchedules(ctx context.Context, now time.Time, count int) ([]model.Schedule, error)
+	AdvanceSchedule(ctx context.Context, id uuid.UUID, from, next time.Time) (bool, error)
+	DeleteSchedule(ctx context.Context, id uuid.UUID) error
+}
+
+var ErrNilPromptService = errors.New("prompt service is nil")
+
+type PromptService interface {
+	Prepare(ctx context.Context, prompt *model.Prompt) error
+	PostPrompt(ctx context.Context, prompt *model.Prompt) error
+}
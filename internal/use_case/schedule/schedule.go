@@ -0,0 +1,143 @@
+package schedule
+
+import (
+	"ai-orchestrator/internal/common/logger"
+	"ai-orchestrator/internal/domain/model"
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"time"
+)
+
+type ScheduleUsecase struct {
+	logger  logger.Logger
+	repo    Repository
+	prompts PromptService
+}
+
+func NewScheduleUsecase(l logger.Logger, repository Repository, prompts PromptService) (*ScheduleUsecase, error) {
+	if l == nil {
+		return nil, logger.ErrNilLogger
+	}
+	if repository == nil {
+		return nil, ErrNilRepository
+	}
+	if prompts == nil {
+		return nil, ErrNilPromptService
+	}
+
+	return &ScheduleUsecase{
+		logger:  l,
+		repo:    repository,
+		prompts: prompts,
+	}, nil
+}
+
+// CreateSchedule saves the schedule after checking its prompt the way a
+// submitted one is. The schedule is updated in place with its ID and next run.
+func (uc *ScheduleUsecase) CreateSchedule(ctx context.Context, schedule *model.Schedule) error {
+	cron, err := model.ParseCron(schedule.Cron)
+	if err != nil {
+		uc.logger.WarnContext(ctx, "invalid schedule cron", "error", err, "cron", schedule.Cron)
+		return err
+	}
+
+	now := time.Now().UTC()
+	next := cron.Next(now)
+	if next.IsZero() {
+		return fmt.Errorf("%w: %q never fires", model.ErrInvalidCron, schedule.Cron)
+	}
+
+	// The check works on a copy, the schedule keeps the prompt unresolved.
+	probe := schedule.NewPrompt()
+	probe.BypassCache = true
+	err = uc.prompts.Prepare(ctx, &probe)
+	if err != nil {
+		uc.logger.WarnContext(ctx, "invalid scheduled prompt", "error", err)
+		return err
+	}
+
+	schedule.ID = uuid.New()
+	schedule.NextRunAt = next
+	schedule.CreatedAt = now
+
+	return uc.repo.InsertSchedule(ctx, *schedule)
+}
+
+// GetSchedule returns the schedule. Only the owner of the schedule may see it.
+func (uc *ScheduleUsecase) GetSchedule(ctx context.Context, id, userID uuid.UUID) (*model.Schedule, error) {
+	schedule, err := uc.repo.GetScheduleByID(ctx, id)
+	if err != nil {
+		uc.logger.WarnContext(ctx, "failed to get schedule by id", "error", err, "schedule_id", id)
+		return nil, err
+	}
+	if schedule.UserID != userID {
+		return nil, model.ErrScheduleNotFound
+	}
+
+	return schedule, nil
+}
+
+func (uc *ScheduleUsecase) ListSchedules(ctx context.Context, userID uuid.UUID) ([]model.Schedule, error) {
+	return uc.repo.ListSchedules(ctx, userID)
+}
+
+// DeleteSchedule stops the schedule. Prompts it already fired are left untouched.
+func (uc *ScheduleUsecase) DeleteSchedule(ctx context.Context, id, userID uuid.UUID) error {
+	if _, err := uc.GetSchedule(ctx, id, userID); err != nil {
+		return err
+	}
+
+	return uc.repo.DeleteSchedule(ctx, id)
+}
+
+// FireDue submits a prompt for every schedule due, and returns how many were fired.
+// Firings missed while nothing was polling are not caught up: a late schedule
+// fires once and moves to its next time after now.
+func (uc *ScheduleUsecase) FireDue(ctx context.Context, count int) (int, error) {
+	now := time.Now().UTC()
+
+	schedules, err := uc.repo.GetDueSchedules(ctx, now, count)
+	if err != nil {
+		return 0, err
+	}
+
+	fired := 0
+	for i := range schedules {
+		if uc.fire(ctx, &schedules[i], now) {
+			fired++
+		}
+	}
+
+	return fired, nil
+}
+
+func (uc *ScheduleUsecase) fire(ctx context.Context, schedule *model.Schedule, now time.Time) bool {
+	var next time.Time
+	cron, err := model.ParseCron(schedule.Cron)
+	if err == nil {
+		next = cron.Next(now)
+	}
+	if next.IsZero() {
+		// Only possible with a row edited by hand, the schedule is stopped.
+		uc.logger.ErrorContext(ctx, "schedule has no next run, deleting it", "error", err, "schedule_id", schedule.ID, "cron", schedule.Cron)
+		_ = uc.repo.DeleteSchedule(ctx, schedule.ID)
+		return false
+	}
+
+	claimed, err := uc.repo.AdvanceSchedule(ctx, schedule.ID, schedule.NextRunAt, next)
+	if err != nil || !claimed {
+		return false
+	}
+
+	prompt := schedule.NewPrompt()
+	err = uc.prompts.PostPrompt(ctx, &prompt)
+	if err != nil {
+		// The schedule stays active, a template deleted or a model disabled since may come back.
+		uc.logger.ErrorContext(ctx, "failed to submit scheduled prompt", "error", err, "schedule_id", schedule.ID)
+		return false
+	}
+
+	uc.logger.InfoContext(ctx, "scheduled prompt submitted", "schedule_id", schedule.ID, "prompt_id", prompt.ID, "next_run_at", next)
+	return true
+}
@@ -0,0 +1,18 @@
+package template
+
+import (
+	"ai-orchestrator/internal/domain/model"
+	"context"
+	"errors"
+	"github.com/google/uuid"
+)
+
+var ErrNilRepository = errors.New("repository is nil")
+
+type Repository interface {
+	InsertTemplate(ctx context.Context, template model.Template) error
+	InsertVersion(ctx context.Context, template model.Template) (*model.Template, error)
+	GetTemplate(ctx context.Context, id uuid.UUID, version int) (*model.Template, error)
+	ListTemplates(ctx context.Context) ([]model.Template, error)
+	DeleteTemplate(ctx context.Context, id, userID uuid.UUID) error
+}
@@ -0,0 +1,84 @@
+package template
+
+import (
+	"ai-orchestrator/internal/common/logger"
+	"ai-orchestrator/internal/domain/model"
+	"context"
+	"github.com/google/uuid"
+	"time"
+)
+
+type TemplateUsecase struct {
+	logger logger.Logger
+	repo   Repository
+}
+
+func NewTemplateUsecase(l logger.Logger, repository Repository) (*TemplateUsecase, error) {
+	if l == nil {
+		return nil, logger.ErrNilLogger
+	}
+	if repository == nil {
+		return nil, ErrNilRepository
+	}
+
+	return &TemplateUsecase{
+		logger: l,
+		repo:   repository,
+	}, nil
+}
+
+// CreateTemplate saves the first version of the template. The template is updated in place with its ID and version.
+func (uc *TemplateUsecase) CreateTemplate(ctx context.Context, template *model.Template) error {
+	if _, err := template.Parse(); err != nil {
+		uc.logger.WarnContext(ctx, "invalid template body", "error", err)
+		return err
+	}
+
+	template.ID = uuid.New()
+	template.Version = 1
+	template.CreatedAt = time.Now().UTC()
+
+	return uc.repo.InsertTemplate(ctx, *template)
+}
+
+// UpdateTemplate saves the template as a new version. Prompts rendered from the previous versions keep referencing them.
+// Only the owner of the template may update it.
+func (uc *TemplateUsecase) UpdateTemplate(ctx context.Context, template model.Template) (*model.Template, error) {
+	if _, err := template.Parse(); err != nil {
+		uc.logger.WarnContext(ctx, "invalid template body", "error", err, "template_id", template.ID)
+		return nil, err
+	}
+
+	updated, err := uc.repo.InsertVersion(ctx, template)
+	if err != nil {
+		uc.logger.WarnContext(ctx, "failed to update template", "error", err, "template_id", template.ID)
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+func (uc *TemplateUsecase) GetTemplate(ctx context.Context, id uuid.UUID, version int) (*model.Template, error) {
+	template, err := uc.repo.GetTemplate(ctx, id, version)
+	if err != nil {
+		uc.logger.WarnContext(ctx, "failed to get template", "error", err, "template_id", id, "version", version)
+		return nil, err
+	}
+
+	return template, nil
+}
+
+func (uc *TemplateUsecase) ListTemplates(ctx context.Context) ([]model.Template, error) {
+	return uc.repo.ListTemplates(ctx)
+}
+
+// DeleteTemplate deletes every version of the template. Only the owner of the template may delete it.
+func (uc *TemplateUsecase) DeleteTemplate(ctx context.Context, id, userID uuid.UUID) error {
+	err := uc.repo.DeleteTemplate(ctx, id, userID)
+	if err != nil {
+		uc.logger.WarnContext(ctx, "failed to delete template", "error", err, "template_id", id)
+		return err
+	}
+
+	return nil
+}